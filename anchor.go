@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// findMessage looks up a message by its fully-qualified name in any of the files, returning
+// the path of the file that declares it. Files are searched in path order.
+func findMessage(files map[string]protoreflect.FileDescriptor, name protoreflect.FullName) (protoreflect.MessageDescriptor, string) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if msg := findMessageIn(files[path].Messages(), name); msg != nil {
+			return msg, path
+		}
+	}
+	return nil, ""
+}
+
+// findMessageIn looks up a message by its fully-qualified name among msgs and their nested messages
+func findMessageIn(msgs protoreflect.MessageDescriptors, name protoreflect.FullName) protoreflect.MessageDescriptor {
+	for i := 0; i < msgs.Len(); i++ {
+		msg := msgs.Get(i)
+		if msg.FullName() == name {
+			return msg
+		}
+		if nested := findMessageIn(msg.Messages(), name); nested != nil {
+			return nested
+		}
+	}
+	return nil
+}
+
+// compareAnchoredType compares a single message found by its fully-qualified name in the previous
+// and current files, even when it moved to another file. The report is for the current file.
+func compareAnchoredType(prevFiles, currFiles map[string]protoreflect.FileDescriptor, name string, opts options) (protobreak.FileReport, error) {
+	fullName := protoreflect.FullName(name)
+	if !fullName.IsValid() {
+		return protobreak.FileReport{}, fmt.Errorf("invalid anchor type %q", name)
+	}
+
+	prevMsg, prevPath := findMessage(prevFiles, fullName)
+	if prevMsg == nil {
+		return protobreak.FileReport{}, fmt.Errorf("anchor type %q not found in the previous files", name)
+	}
+
+	currMsg, currPath := findMessage(currFiles, fullName)
+	if currMsg == nil {
+		return protobreak.FileReport{File: prevPath, BreakingChanges: protobreak.CompareMessage(prevMsg, nil, opts.compareOptions()...)}, nil
+	}
+	return protobreak.FileReport{File: currPath, BreakingChanges: protobreak.CompareMessage(prevMsg, currMsg, opts.compareOptions()...)}, nil
+}
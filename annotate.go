@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// writeSuppressions inserts an ignore directive above the element of every change in the proto file
+// at path, using the line each change was located at. Changes without a line are skipped.
+// It returns the number of directives written.
+func writeSuppressions(path string, changes []protobreak.BreakingChange) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+
+	// Collect the rules to suppress above each line
+	rulesByLine := make(map[int][]string)
+	for _, change := range changes {
+		line := change.Line - 1
+		if line < 0 || line >= len(lines) {
+			continue
+		}
+		if !containsString(rulesByLine[line], change.Rule) {
+			rulesByLine[line] = append(rulesByLine[line], change.Rule)
+		}
+	}
+
+	// Insert from the bottom so that earlier line numbers stay valid
+	targetLines := make([]int, 0, len(rulesByLine))
+	for line := range rulesByLine {
+		targetLines = append(targetLines, line)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(targetLines)))
+
+	written := 0
+	for _, line := range targetLines {
+		indent := lines[line][:len(lines[line])-len(strings.TrimLeft(lines[line], " \t"))]
+		var directives []string
+		for _, id := range rulesByLine[line] {
+			directives = append(directives, indent+"// "+protobreak.IgnoreDirective+" "+id+"\n")
+			written++
+		}
+		lines = append(lines[:line], append(directives, lines[line:]...)...)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return 0, fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return written, nil
+}
+
+// annotateReports writes ignore directives for the changes of every report that are accepted,
+// resolving report files to paths on disk with pathOf
+func annotateReports(reports []protobreak.FileReport, pathOf func(file string) string, in io.Reader, out io.Writer, yes bool) error {
+	answers := bufio.NewScanner(in)
+	for _, report := range reports {
+		if len(report.BreakingChanges) == 0 {
+			continue
+		}
+
+		accepted := confirmChanges(answers, out, report.File, report.BreakingChanges, yes)
+		if len(accepted) == 0 {
+			continue
+		}
+		path := pathOf(report.File)
+		written, err := writeSuppressions(path, accepted)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Wrote %d suppression comments to %s\n", written, path)
+	}
+	return nil
+}
+
+// confirmChanges asks on out whether to accept each change, reading one answer per line.
+// With yes, every change is accepted without asking.
+func confirmChanges(answers *bufio.Scanner, out io.Writer, file string, changes []protobreak.BreakingChange, yes bool) []protobreak.BreakingChange {
+	if yes {
+		return changes
+	}
+
+	var accepted []protobreak.BreakingChange
+	for _, change := range changes {
+		fmt.Fprintf(out, "Suppress %s in %s: %s? [y/N] ", change.Rule, file, change.Message)
+		if !answers.Scan() {
+			fmt.Fprintln(out)
+			break
+		}
+		if answer := strings.ToLower(strings.TrimSpace(answers.Text())); answer == "y" || answer == "yes" {
+			accepted = append(accepted, change)
+		}
+	}
+	return accepted
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestWriteSuppressions tests inserting ignore comments above the changed elements and honoring them
+func TestWriteSuppressions(t *testing.T) {
+	dir := t.TempDir()
+	writeProtoFile(t, dir, "old/api.proto", `
+syntax = "proto3";
+package test;
+message TestMessage {
+  string name = 1;
+  int32 age = 2;
+  string email = 3;
+}
+enum Status {
+  UNKNOWN = 0;
+  ACTIVE = 1;
+}
+`)
+	writeProtoFile(t, dir, "new/api.proto", `
+syntax = "proto3";
+package test;
+message TestMessage {
+  string name = 1;
+  int32 email = 3;
+}
+enum Status {
+  UNKNOWN = 0;
+}
+`)
+	oldPath := filepath.Join(dir, "old", "api.proto")
+	newPath := filepath.Join(dir, "new", "api.proto")
+	opts := options{rules: protobreak.DefaultRuleSet()}
+
+	changes, err := compareExplicitFiles(oldPath, newPath, opts)
+	if err != nil {
+		t.Fatalf("Failed to compare files: %v", err)
+	}
+	if len(changes) != 4 {
+		t.Fatalf("Expected 4 changes, got %v", changeMessages(changes))
+	}
+
+	// Only accept the type change of the email field
+	var out bytes.Buffer
+	reports := []protobreak.FileReport{{File: newPath, BreakingChanges: changes}}
+	answers := strings.NewReader("n\nn\nn\ny\n")
+	if err := annotateReports(reports, func(file string) string { return file }, answers, &out, false); err != nil {
+		t.Fatalf("Failed to write suppressions: %v", err)
+	}
+
+	data, err := os.ReadFile(newPath)
+	if err != nil {
+		t.Fatalf("Failed to read annotated file: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	if lines[4] != "  // proto-break:ignore FIELD_SAME_TYPE" || lines[5] != "  int32 email = 3;" {
+		t.Errorf("Expected the comment above the email field, got:\n%s", data)
+	}
+
+	// The accepted change is no longer reported
+	changes, err = compareExplicitFiles(oldPath, newPath, opts)
+	if err != nil {
+		t.Fatalf("Failed to compare files: %v", err)
+	}
+	expected := []string{
+		`Enum value "ACTIVE" (number 1) was removed from enum "Status"`,
+		`Field "age" (number 2) was removed from message "TestMessage"`,
+		`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+	}
+	if !reflect.DeepEqual(changeMessages(changes), expected) {
+		t.Errorf("Expected errors %v, got %v", expected, changeMessages(changes))
+	}
+
+	// Accepting the rest annotates the parents of removed elements
+	reports = []protobreak.FileReport{{File: newPath, BreakingChanges: changes}}
+	if err := annotateReports(reports, func(file string) string { return file }, nil, &out, true); err != nil {
+		t.Fatalf("Failed to write suppressions: %v", err)
+	}
+	changes, err = compareExplicitFiles(oldPath, newPath, opts)
+	if err != nil {
+		t.Fatalf("Failed to compare files: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected every change to be suppressed, got %v", changeMessages(changes))
+	}
+}
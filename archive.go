@@ -0,0 +1,134 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// isArchive reports whether path names a .tar, .tar.gz, .tgz or .zip archive
+func isArchive(file string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(file, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// readArchiveProtos reads the .proto files of an archive into memory, keyed by their slash-separated path
+func readArchiveProtos(file string) (map[string]string, error) {
+	if strings.HasSuffix(file, ".zip") {
+		zr, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return readZipProtos(&zr.Reader)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(file, ".gz") || strings.HasSuffix(file, ".tgz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	return readTarProtos(r)
+}
+
+// readTarProtos reads the .proto files of a tar stream
+func readTarProtos(r io.Reader) (map[string]string, error) {
+	sources := make(map[string]string)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return sources, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || path.Ext(header.Name) != ".proto" {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		sources[archivePath(header.Name)] = string(content)
+	}
+}
+
+// readZipProtos reads the .proto files of a zip archive
+func readZipProtos(zr *zip.Reader) (map[string]string, error) {
+	sources := make(map[string]string)
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() || path.Ext(entry.Name) != ".proto" {
+			continue
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		sources[archivePath(entry.Name)] = string(content)
+	}
+	return sources, nil
+}
+
+// archivePath normalizes the name of an archive entry, e.g. "./api/test.proto" to "api/test.proto"
+func archivePath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// filesFromArchive parses every proto file of an archive, keyed by its path inside the archive.
+// Imports that are not in the archive are resolved from importPaths.
+func filesFromArchive(file string, importPaths []string) (map[string]protoreflect.FileDescriptor, error) {
+	sources, err := readArchiveProtos(file)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fileDescs, err := ParseProtoSources(sources, importPaths, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	filesByPath := make(map[string]protoreflect.FileDescriptor, len(fileDescs))
+	for _, fileDesc := range fileDescs {
+		filesByPath[fileDesc.GetName()] = fileDesc.UnwrapFile()
+	}
+	if len(filesByPath) == 0 {
+		return nil, fmt.Errorf("no proto files found")
+	}
+	return filesByPath, nil
+}
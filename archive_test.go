@@ -0,0 +1,95 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestCompareAgainstArchive tests using an in-memory tar of protos as the baseline
+func TestCompareAgainstArchive(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range map[string]string{
+		"./api/test.proto": `
+			syntax = "proto3";
+			package test;
+			import "api/common.proto";
+			message TestMessage {
+				string name = 1;
+				int32 age = 2;
+				Common common = 3;
+			}
+		`,
+		"./api/common.proto": `
+			syntax = "proto3";
+			package test;
+			message Common {}
+		`,
+		"./README.md": "not a proto file",
+	} {
+		content = strings.TrimSpace(content)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("Failed to close gzip: %v", err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "release.tar.gz")
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	root := t.TempDir()
+	writeProtoFile(t, root, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		import "api/common.proto";
+		message TestMessage {
+			string name = 1;
+			Common common = 3;
+		}
+	`)
+	writeProtoFile(t, root, "api/common.proto", `
+		syntax = "proto3";
+		package test;
+		message Common {}
+	`)
+
+	fileDescs, err := parseProtoTree(root, nil)
+	if err != nil {
+		t.Fatalf("Failed to parse proto tree: %v", err)
+	}
+	prevFiles, err := loadImageFiles(archivePath, nil)
+	if err != nil {
+		t.Fatalf("Failed to load archive: %v", err)
+	}
+	reports := compareAgainstImage(prevFiles, fileDescs, options{rules: protobreak.DefaultRuleSet()})
+
+	if len(reports) != 2 || reports[1].File != "api/test.proto" {
+		t.Fatalf("Expected reports for both files, got %+v", reports)
+	}
+	expected := []string{
+		`Field "age" (number 2) was removed from message "TestMessage"`,
+		`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+	}
+	if !reflect.DeepEqual(changeMessages(reports[1].BreakingChanges), expected) {
+		t.Errorf("Expected errors %v, got %v", expected, changeMessages(reports[1].BreakingChanges))
+	}
+}
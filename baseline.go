@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// baselineKey identifies a change independently of its severity, which may be reconfigured
+type baselineKey struct {
+	file    string
+	rule    string
+	message string
+}
+
+// baseline counts the accepted changes recorded in a baseline file
+type baseline map[baselineKey]int
+
+// loadBaseline reads a baseline written by writeBaseline
+func loadBaseline(path string) (baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline: %v", err)
+	}
+
+	var reports []protobreak.FileReport
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return nil, fmt.Errorf("error parsing baseline %s: %v", path, err)
+	}
+
+	b := make(baseline)
+	for _, report := range reports {
+		for _, change := range report.BreakingChanges {
+			b[baselineKey{file: report.File, rule: change.Rule, message: change.Message}]++
+		}
+	}
+	return b, nil
+}
+
+// writeBaseline records the changes of all reports as accepted.
+// With ignoreWarnings, only changes with the error severity are recorded.
+func writeBaseline(path string, reports []protobreak.FileReport, ignoreWarnings bool) error {
+	baselineReports := []protobreak.FileReport{}
+	for _, report := range reports {
+		changes := report.BreakingChanges
+		if ignoreWarnings {
+			changes = protobreak.FilterSeverity(changes, protobreak.SeverityError)
+		}
+		if len(changes) > 0 {
+			baselineReports = append(baselineReports, protobreak.FileReport{File: report.File, BreakingChanges: changes})
+		}
+	}
+
+	data, err := json.MarshalIndent(baselineReports, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding baseline: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing baseline: %v", err)
+	}
+	return nil
+}
+
+// diff returns the report without the changes recorded in the baseline.
+// A change recorded once only hides one occurrence of it.
+func (b baseline) diff(report protobreak.FileReport) protobreak.FileReport {
+	accepted := make(map[baselineKey]int)
+	var changes []protobreak.BreakingChange
+	for _, change := range report.BreakingChanges {
+		key := baselineKey{file: report.File, rule: change.Rule, message: change.Message}
+		if accepted[key] < b[key] {
+			accepted[key]++
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return protobreak.FileReport{File: report.File, BreakingChanges: changes}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestBaselineDiff tests that only changes added since the baseline are reported
+func TestBaselineDiff(t *testing.T) {
+	prevProto := `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+			string email = 3;
+		}
+	`
+	acceptedProto := `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			string email = 3;
+		}
+	`
+	currProto := `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+		}
+	`
+
+	rules := protobreak.DefaultRuleSet()
+
+	prevFileDesc, acceptedFileDesc := parseTestProtos(t, prevProto, acceptedProto)
+	accepted := protobreak.FileReport{File: "test.proto", BreakingChanges: compareFiles(prevFileDesc, acceptedFileDesc, options{rules: rules})}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := writeBaseline(path, []protobreak.FileReport{accepted}, false); err != nil {
+		t.Fatalf("Failed to write baseline: %v", err)
+	}
+	b, err := loadBaseline(path)
+	if err != nil {
+		t.Fatalf("Failed to load baseline: %v", err)
+	}
+
+	prevFileDesc, currFileDesc := parseTestProtos(t, prevProto, currProto)
+	current := protobreak.FileReport{File: "test.proto", BreakingChanges: compareFiles(prevFileDesc, currFileDesc, options{rules: rules})}
+	if len(current.BreakingChanges) != 4 {
+		t.Fatalf("Expected 4 current changes, got %v", current.BreakingChanges)
+	}
+
+	expected := []string{
+		`Field "email" (number 3) was removed from message "TestMessage"`,
+		`Removed field "email" (number 3) is not reserved in message "TestMessage"`,
+	}
+	if actual := changeMessages(b.diff(current).BreakingChanges); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected delta %v, got %v", expected, actual)
+	}
+
+	// Changes of other files are not hidden by the baseline
+	other := protobreak.FileReport{File: "other.proto", BreakingChanges: current.BreakingChanges}
+	if actual := b.diff(other).BreakingChanges; len(actual) != 4 {
+		t.Errorf("Expected 4 changes in other.proto, got %v", actual)
+	}
+}
+
+// TestBaselineIgnoreWarnings tests that warnings can be left out of a written baseline
+func TestBaselineIgnoreWarnings(t *testing.T) {
+	report := protobreak.FileReport{File: "test.proto", BreakingChanges: []protobreak.BreakingChange{
+		{Rule: protobreak.RuleFieldNoDelete, Severity: protobreak.SeverityError, Message: `Field "age" (number 2) was removed from message "TestMessage"`},
+		{Rule: protobreak.RuleFieldSameLazy, Severity: protobreak.SeverityWarning, Message: `Field "other" lazy option changed from false to true in message "TestMessage"`},
+	}}
+	warnings := protobreak.FileReport{File: "warnings.proto", BreakingChanges: []protobreak.BreakingChange{
+		{Rule: protobreak.RuleFieldSameLazy, Severity: protobreak.SeverityWarning, Message: `Field "other" lazy option changed from false to true in message "Other"`},
+	}}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := writeBaseline(path, []protobreak.FileReport{report, warnings}, true); err != nil {
+		t.Fatalf("Failed to write baseline: %v", err)
+	}
+	b, err := loadBaseline(path)
+	if err != nil {
+		t.Fatalf("Failed to load baseline: %v", err)
+	}
+
+	expected := baseline{{file: "test.proto", rule: protobreak.RuleFieldNoDelete, message: report.BreakingChanges[0].Message}: 1}
+	if !reflect.DeepEqual(b, expected) {
+		t.Errorf("Expected baseline %v, got %v", expected, b)
+	}
+}
+
+// TestLoadBaselineWithPathArrays tests that baselines recording paths as arrays of elements still load
+func TestLoadBaselineWithPathArrays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	data := `[{"file":"test.proto","breaking_changes":[{"rule":"FIELD_NO_DELETE","severity":"ERROR","message":"Field \"age\" (number 2) was removed from message \"TestMessage\"","path":["TestMessage","age"]}]}]`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write baseline: %v", err)
+	}
+	b, err := loadBaseline(path)
+	if err != nil {
+		t.Fatalf("Failed to load baseline: %v", err)
+	}
+
+	expected := baseline{{file: "test.proto", rule: protobreak.RuleFieldNoDelete, message: `Field "age" (number 2) was removed from message "TestMessage"`}: 1}
+	if !reflect.DeepEqual(b, expected) {
+		t.Errorf("Expected baseline %v, got %v", expected, b)
+	}
+}
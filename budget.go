@@ -0,0 +1,111 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// runWithBudget calls process for each file using up to jobs goroutines, until all files are
+// processed or the budget runs out. process returns a function that reports its result; these
+// are called one at a time in file order, so output stays deterministic whatever the number of
+// jobs. With a single job every file is processed and reported before the next one starts.
+// It returns how many files were reported and whether the budget was exceeded.
+// A budget of zero never expires. When the budget is exceeded no more results are reported, and
+// runWithBudget waits for the one being reported before it returns, so callers can read what the
+// reports collected. The files in progress keep running in the background, so callers are expected
+// to stop soon after.
+func runWithBudget(files []string, jobs int, budget time.Duration, process func(file string) func()) (int, bool) {
+	// mu is held while a result is reported, so the budget cannot run out halfway through one
+	var mu sync.Mutex
+	processed, stopped := 0, false
+	report := func(result func()) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return false
+		}
+		result()
+		processed++
+		return true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if jobs <= 1 {
+			for _, file := range files {
+				if !report(process(file)) {
+					return
+				}
+			}
+			return
+		}
+
+		results := make([]chan func(), len(files))
+		for i := range results {
+			results[i] = make(chan func(), 1)
+		}
+		go func() {
+			slots := make(chan struct{}, jobs)
+			for i, file := range files {
+				slots <- struct{}{}
+				go func(i int, file string) {
+					defer func() { <-slots }()
+					results[i] <- process(file)
+				}(i, file)
+			}
+		}()
+		for _, result := range results {
+			if !report(<-result) {
+				return
+			}
+		}
+	}()
+
+	if budget <= 0 {
+		<-done
+		return len(files), false
+	}
+
+	timer := time.NewTimer(budget)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return len(files), false
+	case <-timer.C:
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		return processed, true
+	}
+}
+
+// stageLimits bounds how many files are parsed and how many are compared at once, so that
+// I/O-bound parsing and CPU-bound comparison can be tuned separately
+type stageLimits struct {
+	parse, compare chan struct{}
+}
+
+// newStageLimits allows parseJobs files to be parsed and compareJobs files to be compared at once
+func newStageLimits(parseJobs, compareJobs int) stageLimits {
+	return stageLimits{parse: make(chan struct{}, max(parseJobs, 1)), compare: make(chan struct{}, max(compareJobs, 1))}
+}
+
+// jobs returns how many files must be processed at once to keep both stages busy
+func (l stageLimits) jobs() int {
+	return max(cap(l.parse), cap(l.compare))
+}
+
+// parsing runs fn in one of the parse slots
+func (l stageLimits) parsing(fn func()) {
+	l.parse <- struct{}{}
+	defer func() { <-l.parse }()
+	fn()
+}
+
+// comparing runs fn in one of the compare slots
+func (l stageLimits) comparing(fn func()) {
+	l.compare <- struct{}{}
+	defer func() { <-l.compare }()
+	fn()
+}
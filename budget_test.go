@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRunWithBudget tests aborting when an injected parser is too slow
+func TestRunWithBudget(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	// The parser is fast for a.proto and blocks on b.proto until the test ends
+	slowParse := func(file string) func() {
+		if file == "b.proto" {
+			<-release
+		}
+		return func() {}
+	}
+
+	processed, exceeded := runWithBudget([]string{"a.proto", "b.proto", "c.proto"}, 1, 50*time.Millisecond, slowParse)
+	if !exceeded {
+		t.Fatal("Expected the time budget to be exceeded")
+	}
+	if processed != 1 {
+		t.Errorf("Expected 1 processed file, got %d", processed)
+	}
+
+	var parsed []string
+	processed, exceeded = runWithBudget([]string{"a.proto", "c.proto"}, 1, time.Minute, func(file string) func() {
+		parsed = append(parsed, file)
+		return func() {}
+	})
+	if exceeded || processed != 2 || len(parsed) != 2 {
+		t.Errorf("Expected both files to be processed within the budget, got %d (%v)", processed, parsed)
+	}
+}
+
+// TestRunWithBudgetStopsReporting tests that no result is reported once the budget has run out, so
+// the caller can read what the reports collected without a data race
+func TestRunWithBudgetStopsReporting(t *testing.T) {
+	var files []string
+	for i := 0; i < 20; i++ {
+		files = append(files, fmt.Sprintf("file%d.proto", i))
+	}
+
+	for _, jobs := range []int{1, 4} {
+		var reported []string
+		processed, exceeded := runWithBudget(files, jobs, 30*time.Millisecond, func(file string) func() {
+			time.Sleep(5 * time.Millisecond)
+			return func() {
+				// Reports are slow too, so the budget is likely to run out during one
+				time.Sleep(2 * time.Millisecond)
+				reported = append(reported, file)
+			}
+		})
+		if !exceeded {
+			t.Fatalf("Expected the time budget to be exceeded with %d jobs", jobs)
+		}
+		if len(reported) != processed {
+			t.Errorf("Expected %d reports with %d jobs, got %d", processed, jobs, len(reported))
+		}
+
+		// The files still in progress must not report anything
+		time.Sleep(50 * time.Millisecond)
+		if len(reported) != processed {
+			t.Errorf("Expected no reports after the budget ran out with %d jobs, got %d more", jobs, len(reported)-processed)
+		}
+	}
+}
+
+// TestRunWithBudgetJobs tests that a single job runs sequentially and that reports follow file order
+func TestRunWithBudgetJobs(t *testing.T) {
+	files := []string{"a.proto", "b.proto", "c.proto", "d.proto"}
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	process := func(file string) func() {
+		record("start " + file)
+		// Earlier files are slower, so parallel jobs finish out of order
+		time.Sleep(time.Duration(len(files)-int(file[0]-'a')) * 5 * time.Millisecond)
+		record("end " + file)
+		return func() { record("report " + file) }
+	}
+
+	runWithBudget(files, 1, 0, process)
+	var expected []string
+	for _, file := range files {
+		expected = append(expected, "start "+file, "end "+file, "report "+file)
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected sequential events %v, got %v", expected, events)
+	}
+
+	events = nil
+	runWithBudget(files, 4, 0, process)
+	var reports []string
+	for _, event := range events {
+		if len(event) > 7 && event[:7] == "report " {
+			reports = append(reports, event[7:])
+		}
+	}
+	if !reflect.DeepEqual(reports, files) {
+		t.Errorf("Expected reports in file order %v, got %v", files, reports)
+	}
+}
+
+// TestStageLimits tests that the parse and compare stages each run up to their own number of jobs
+func TestStageLimits(t *testing.T) {
+	files := []string{"a.proto", "b.proto", "c.proto", "d.proto", "e.proto", "f.proto"}
+	limits := newStageLimits(3, 1)
+	if limits.jobs() != 3 {
+		t.Fatalf("Expected 3 jobs to fill both stages, got %d", limits.jobs())
+	}
+
+	var mu sync.Mutex
+	var parsing, comparing, maxParsing, maxComparing int
+	enter := func(count, peak *int) {
+		mu.Lock()
+		defer mu.Unlock()
+		*count++
+		*peak = max(*peak, *count)
+	}
+	leave := func(count *int) {
+		mu.Lock()
+		defer mu.Unlock()
+		*count--
+	}
+
+	runWithBudget(files, limits.jobs(), 0, func(file string) func() {
+		limits.parsing(func() {
+			enter(&parsing, &maxParsing)
+			time.Sleep(20 * time.Millisecond)
+			leave(&parsing)
+		})
+		limits.comparing(func() {
+			enter(&comparing, &maxComparing)
+			time.Sleep(5 * time.Millisecond)
+			leave(&comparing)
+		})
+		return func() {}
+	})
+
+	if maxParsing != 3 {
+		t.Errorf("Expected 3 files to be parsed at once, got %d", maxParsing)
+	}
+	if maxComparing != 1 {
+		t.Errorf("Expected 1 file to be compared at once, got %d", maxComparing)
+	}
+}
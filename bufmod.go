@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// bufLock is the subset of a buf.lock file needed to locate module dependencies
+type bufLock struct {
+	Version string       `yaml:"version"`
+	Deps    []bufLockDep `yaml:"deps"`
+}
+
+// bufLockDep is a pinned module dependency. Version v1 lock files split the module
+// name into remote, owner and repository while v2 uses a single name.
+type bufLockDep struct {
+	Remote     string `yaml:"remote"`
+	Owner      string `yaml:"owner"`
+	Repository string `yaml:"repository"`
+	Name       string `yaml:"name"`
+	Commit     string `yaml:"commit"`
+}
+
+// module returns the full module name, e.g. buf.build/googleapis/googleapis
+func (d bufLockDep) module() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.Remote + "/" + d.Owner + "/" + d.Repository
+}
+
+// defaultBufCacheDir returns the buf cache directory, honouring $BUF_CACHE_DIR like buf does
+func defaultBufCacheDir() (string, error) {
+	if dir := os.Getenv("BUF_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "buf"), nil
+}
+
+// loadBufLock reads the dependencies pinned in a buf.lock file
+func loadBufLock(path string) (bufLock, error) {
+	var lock bufLock
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lock, fmt.Errorf("error reading buf lock file: %v", err)
+	}
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&lock); err != nil && !errors.Is(err, io.EOF) {
+		return lock, fmt.Errorf("error parsing buf lock file %s: %v", path, err)
+	}
+
+	for _, dep := range lock.Deps {
+		if dep.Commit == "" || strings.Count(dep.module(), "/") != 2 {
+			return lock, fmt.Errorf("invalid dependency %q in buf lock file %s", dep.module(), path)
+		}
+	}
+	return lock, nil
+}
+
+// bufImportPaths returns the directories holding the sources of every module pinned
+// in a buf.lock file. Modules are looked up in the buf module cache at
+// <cacheDir>/v1/module/data/<remote>/<owner>/<repository>/<commit>.
+func bufImportPaths(lockPath, cacheDir string) ([]string, error) {
+	lock, err := loadBufLock(lockPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheDir == "" {
+		if cacheDir, err = defaultBufCacheDir(); err != nil {
+			return nil, fmt.Errorf("error locating buf cache: %v", err)
+		}
+	}
+
+	var importPaths []string
+	for _, dep := range lock.Deps {
+		dir := filepath.Join(cacheDir, "v1", "module", "data", filepath.FromSlash(dep.module()), dep.Commit)
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("module %s:%s not found in buf cache %s (run buf mod update to fetch it)", dep.module(), dep.Commit, cacheDir)
+		}
+		importPaths = append(importPaths, dir)
+	}
+	return importPaths, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestBufModuleImports tests resolving imports of modules pinned in buf.lock from the buf cache
+func TestBufModuleImports(t *testing.T) {
+	cacheDir := t.TempDir()
+	writeProtoFile(t, cacheDir, "v1/module/data/buf.build/acme/money/0123abcd/acme/money/v1/money.proto", `
+syntax = "proto3";
+package acme.money.v1;
+message Money {
+  string currency_code = 1;
+  int64 units = 2;
+}
+`)
+
+	lockFiles := map[string]string{
+		"v1": `
+version: v1
+deps:
+  - remote: buf.build
+    owner: acme
+    repository: money
+    commit: 0123abcd
+`,
+		"v2": `
+version: v2
+deps:
+  - name: buf.build/acme/money
+    commit: 0123abcd
+`,
+	}
+
+	for version, lock := range lockFiles {
+		t.Run(version, func(t *testing.T) {
+			root := t.TempDir()
+			writeProtoFile(t, root, "buf.lock", lock)
+			writeProtoFile(t, root, "prev/order.proto", `
+syntax = "proto3";
+package shop;
+import "acme/money/v1/money.proto";
+message Order {
+  acme.money.v1.Money total = 1;
+}
+`)
+			writeProtoFile(t, root, "curr/order.proto", `
+syntax = "proto3";
+package shop;
+import "acme/money/v1/money.proto";
+message Order {
+  int64 total = 1;
+}
+`)
+
+			importPaths, err := bufImportPaths(filepath.Join(root, "buf.lock"), cacheDir)
+			if err != nil {
+				t.Fatalf("bufImportPaths() error: %v", err)
+			}
+
+			prevFileDesc, err := parseProtoFileToReflect(filepath.Join(root, "prev", "order.proto"), importPaths...)
+			if err != nil {
+				t.Fatalf("Failed to parse previous proto: %v", err)
+			}
+			currFileDesc, err := parseProtoFileToReflect(filepath.Join(root, "curr", "order.proto"), importPaths...)
+			if err != nil {
+				t.Fatalf("Failed to parse current proto: %v", err)
+			}
+
+			changes := compareFiles(prevFileDesc, currFileDesc, options{rules: protobreak.DefaultRuleSet()})
+			expected := []string{`Field "total" changed from message acme.money.v1.Money to scalar int64 in message "Order"`}
+			if got := changeMessages(changes); !reflect.DeepEqual(got, expected) {
+				t.Errorf("compareFiles() = %v, want %v", got, expected)
+			}
+		})
+	}
+}
+
+// TestBufModuleNotCached tests that a module missing from the cache is reported
+func TestBufModuleNotCached(t *testing.T) {
+	root := t.TempDir()
+	writeProtoFile(t, root, "buf.lock", `
+version: v2
+deps:
+  - name: buf.build/acme/money
+    commit: 0123abcd
+`)
+
+	_, err := bufImportPaths(filepath.Join(root, "buf.lock"), t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "buf.build/acme/money:0123abcd not found") {
+		t.Errorf("bufImportPaths() error = %v, want module not found", err)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// parseProtoFileToReflect parses a proto file and returns a protoreflect.FileDescriptor
+func parseProtoFileToReflect(filePath string, importPaths ...string) (protoreflect.FileDescriptor, error) {
+	// Use the ParseProtoFile function from parser.go
+	fileDesc, err := ParseProtoFile(filePath, importPaths...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to protoreflect.FileDescriptor
+	return fileDesc.UnwrapFile(), nil
+}
+
+// parseProtoSourceToReflect parses in-memory proto source and returns a protoreflect.FileDescriptor
+func parseProtoSourceToReflect(name, content string) (protoreflect.FileDescriptor, error) {
+	fileDesc, err := ParseProtoSource(name, content)
+	if err != nil {
+		return nil, err
+	}
+	return fileDesc.UnwrapFile(), nil
+}
+
+// loadFileDescriptorSet loads a FileDescriptorSet from a file
+func loadFileDescriptorSet(path string) (*descriptorpb.FileDescriptorSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &fds); err != nil {
+		return nil, err
+	}
+	return &fds, nil
+}
+
+// filesFromDescriptorSet builds file descriptors for every file in a FileDescriptorSet, keyed by path
+func filesFromDescriptorSet(fds *descriptorpb.FileDescriptorSet) (map[string]protoreflect.FileDescriptor, error) {
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, err
+	}
+
+	filesByPath := make(map[string]protoreflect.FileDescriptor)
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		filesByPath[file.Path()] = file
+		return true
+	})
+	return filesByPath, nil
+}
+
+// options holds the settings that control how files are compared
+type options struct {
+	rules            protobreak.RuleSet
+	excludedPackages []string
+	// excludeGlobs are the --exclude patterns of files that are skipped entirely
+	excludeGlobs []string
+	// protoPaths are directories of the working tree searched for imports, relative to its root
+	protoPaths []string
+	// packageRoots also searches the root of each file derived from its package, for trees whose directories follow packages
+	packageRoots bool
+	// importPaths are extra directories searched for imports, e.g. buf module sources
+	importPaths []string
+	// configOptions are the config file settings passed on to protobreak.Compare with the rules
+	configOptions []protobreak.Option
+	// baseline holds accepted changes that are left out of reports
+	baseline baseline
+	// writeBaselinePath is where all current changes are recorded as the new baseline
+	writeBaselinePath string
+	// baselineIgnoreWarnings leaves warnings out of the written baseline
+	baselineIgnoreWarnings bool
+	// suppressions hides known breaking changes listed in the ignore file
+	suppressions suppressions
+	// writeSuppressions inserts ignore comments for accepted changes, asking first unless acceptAll is set
+	writeSuppressions bool
+	acceptAll         bool
+	// requireSyntax rejects analyzed files that use another syntax
+	requireSyntax string
+	// anchorType is the fully-qualified message compared on its own, wherever its file is
+	anchorType string
+	// bitExit combines exit code bits for breaking changes and warnings
+	bitExit bool
+	// failOn is the lowest severity of changes that fails the run, protobreak.SeverityOff for none
+	failOn protobreak.Severity
+	// reportOnlyRules are printed but never affect the exit code, whatever their severity
+	reportOnlyRules map[string]bool
+	// maxFileSize is the size in bytes above which files are not parsed, 0 for no limit.
+	// oversizeAction decides whether such files are skipped with a warning or fail the run.
+	maxFileSize    int64
+	oversizeAction string
+}
+
+// packageExcluded reports whether a file belongs to an excluded package or one of its sub-packages
+func (o options) packageExcluded(file protoreflect.FileDescriptor) bool {
+	pkg := string(file.Package())
+	for _, excluded := range o.excludedPackages {
+		if pkg == excluded || strings.HasPrefix(pkg, excluded+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// importPathsUnder returns the directories searched for imports of files in the tree at root:
+// the proto paths under root, then the extra import paths
+func (o options) importPathsUnder(root string) []string {
+	var paths []string
+	for _, protoPath := range o.protoPaths {
+		paths = append(paths, filepath.Join(root, protoPath))
+	}
+	return append(paths, o.importPaths...)
+}
+
+// compareFiles compares two versions of a file, skipping files in excluded packages entirely
+func compareFiles(prevFileDesc, currFileDesc protoreflect.FileDescriptor, opts options) []protobreak.BreakingChange {
+	if opts.packageExcluded(prevFileDesc) || opts.packageExcluded(currFileDesc) {
+		return nil
+	}
+	return protobreak.Compare(prevFileDesc, currFileDesc, opts.compareOptions()...)
+}
+
+// compareOptions returns the resolved rules and the config settings as options of protobreak.Compare
+func (o options) compareOptions() []protobreak.Option {
+	return append([]protobreak.Option{protobreak.WithSeverities(o.rules)}, o.configOptions...)
+}
+
+// compareProtoFile compares the current and previous versions of a proto file
+func compareProtoFile(repo gitRepo, protoFile, compareCommit string, opts options) ([]protobreak.BreakingChange, error) {
+	prevFileDesc, currFileDesc, err := parseProtoVersions(repo, protoFile, compareCommit, opts)
+	if err != nil {
+		return nil, err
+	}
+	return compareFiles(prevFileDesc, currFileDesc, opts), nil
+}
+
+// parseProtoVersions parses the previous and current versions of a proto file.
+// Imports of the previous version are read from the same commit.
+func parseProtoVersions(repo gitRepo, protoFile, compareCommit string, opts options) (protoreflect.FileDescriptor, protoreflect.FileDescriptor, error) {
+	// Check sizes first, so that pathological files are never read into memory
+	if err := opts.checkFileSizeOnDisk(repo.path(protoFile)); err != nil {
+		return nil, nil, err
+	}
+	if err := opts.checkFileSizeAtCommit(repo, protoFile, compareCommit); err != nil {
+		return nil, nil, err
+	}
+
+	// Search the file's directory, the root derived from its package and the proto paths at the commit,
+	// then the extra import paths on disk
+	prevOpener := commitOpener(repo, compareCommit)
+	prevImportPaths := append(append([]string{filepath.Dir(protoFile)}, opts.packageRootPaths(prevOpener, "", protoFile)...), opts.protoPaths...)
+	for _, importPath := range opts.importPaths {
+		absPath, err := filepath.Abs(importPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error resolving import path %s: %v", importPath, err)
+		}
+		prevImportPaths = append(prevImportPaths, absPath)
+	}
+
+	// Parse proto files directly using protoparse
+	prevFile, err := ParseProtoFileFrom(prevOpener, prevImportPaths, filepath.Base(protoFile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing previous proto file: %v", err)
+	}
+	prevFileDesc := prevFile.UnwrapFile()
+
+	currImportPaths := append(opts.packageRootPaths(openFile, repo.path("."), protoFile), opts.importPathsUnder(repo.path("."))...)
+	currFileDesc, err := parseProtoFileToReflect(repo.path(protoFile), currImportPaths...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing current proto file: %v", err)
+	}
+
+	return prevFileDesc, currFileDesc, nil
+}
+
+// compareDeletedFile reports a proto file that existed at the commit but was deleted from the working tree.
+// The previous version is only parsed when packages are excluded, to check its package.
+func compareDeletedFile(repo gitRepo, protoFile, compareCommit string, opts options) ([]protobreak.BreakingChange, error) {
+	if len(opts.excludedPackages) > 0 {
+		prevOpener := commitOpener(repo, compareCommit)
+		prevImportPaths := append(append([]string{filepath.Dir(protoFile)}, opts.packageRootPaths(prevOpener, "", protoFile)...), opts.protoPaths...)
+		prevFile, err := ParseProtoFileFrom(prevOpener, prevImportPaths, filepath.Base(protoFile))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing previous proto file: %v", err)
+		}
+		if opts.packageExcluded(prevFile.UnwrapFile()) {
+			return nil, nil
+		}
+	}
+	return opts.rules.Filter([]protobreak.BreakingChange{fileRemovedChange(protoFile)}), nil
+}
+
+// fileRemovedChange returns the FILE_NO_DELETE change for a proto file that no longer exists
+func fileRemovedChange(file string) protobreak.BreakingChange {
+	return protobreak.BreakingChange{Rule: protobreak.RuleFileNoDelete, Severity: protobreak.SeverityError, Message: fmt.Sprintf("File %q was removed", file)}
+}
+
+// compareExplicitFiles compares two proto files given by path, without looking at git history
+func compareExplicitFiles(oldPath, newPath string, opts options) ([]protobreak.BreakingChange, error) {
+	prevFileDesc, err := parseProtoFileToReflect(oldPath, opts.importPathsUnder(".")...)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing old proto file: %v", err)
+	}
+
+	currFileDesc, err := parseProtoFileToReflect(newPath, opts.importPathsUnder(".")...)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing new proto file: %v", err)
+	}
+	if err := validateSyntax(currFileDesc, opts.requireSyntax); err != nil {
+		return nil, fmt.Errorf("%s %v", newPath, err)
+	}
+
+	return compareFiles(prevFileDesc, currFileDesc, opts), nil
+}
+
+// runExplicitFiles compares the files given by --old and --new, returning the process exit code.
+// The report is written to out and progress to status, and accepted changes are read from in.
+func runExplicitFiles(oldPath, newPath, format string, in io.Reader, out, status io.Writer, opts options) int {
+	fmt.Fprintf(status, "Analyzing changes from %s to %s...\n", oldPath, newPath)
+	breakingChanges, err := compareExplicitFiles(oldPath, newPath, opts)
+	if err != nil {
+		fmt.Fprintf(status, "Error: %v\n", err)
+		return exitCode(nil, true, opts.bitExit, opts.failOn)
+	}
+
+	report := protobreak.FileReport{File: newPath, BreakingChanges: opts.suppressions.filter(breakingChanges)}
+	warnUnmatchedSuppressions(status, opts.suppressions)
+	if opts.writeBaselinePath != "" {
+		if err := writeBaseline(opts.writeBaselinePath, []protobreak.FileReport{report}, opts.baselineIgnoreWarnings); err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	reports := []protobreak.FileReport{opts.baseline.diff(report)}
+	if err := writeReports(out, format, reports); err != nil {
+		fmt.Fprintf(status, "Error writing report: %v\n", err)
+		return 1
+	}
+	if opts.writeSuppressions {
+		if err := annotateReports(reports, func(file string) string { return file }, in, status, opts.acceptAll); err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			return 1
+		}
+	}
+	return exitCode(failingReports(reports, opts.reportOnlyRules), false, opts.bitExit, opts.failOn)
+}
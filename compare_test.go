@@ -0,0 +1,179 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TestExcludePackage tests that files in excluded packages are skipped
+func TestExcludePackage(t *testing.T) {
+	tests := []struct {
+		name             string
+		pkg              string
+		excludedPackages []string
+		expectedErrors   []string
+	}{
+		{
+			name: "No exclusions",
+			pkg:  "test.experimental",
+			expectedErrors: []string{
+				`Field "age" (number 2) was removed from message "TestMessage"`,
+				`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+			},
+		},
+		{
+			name:             "Excluded package",
+			pkg:              "test.experimental",
+			excludedPackages: []string{"test.experimental"},
+			expectedErrors:   []string{},
+		},
+		{
+			name:             "Excluded parent package",
+			pkg:              "test.experimental.v1",
+			excludedPackages: []string{"test.experimental"},
+			expectedErrors:   []string{},
+		},
+		{
+			name:             "Package with excluded prefix only",
+			pkg:              "test.experimentalapi",
+			excludedPackages: []string{"test.experimental"},
+			expectedErrors: []string{
+				`Field "age" (number 2) was removed from message "TestMessage"`,
+				`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, `
+				syntax = "proto3";
+				package `+tt.pkg+`;
+				message TestMessage {
+					string name = 1;
+					int32 age = 2;
+				}
+			`, `
+				syntax = "proto3";
+				package `+tt.pkg+`;
+				message TestMessage {
+					string name = 1;
+				}
+			`)
+
+			opts := options{rules: protobreak.DefaultRuleSet(), excludedPackages: tt.excludedPackages}
+			actualErrors := changeMessages(compareFiles(prevFileDesc, currFileDesc, opts))
+
+			if !reflect.DeepEqual(actualErrors, tt.expectedErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.expectedErrors, actualErrors)
+			}
+		})
+	}
+}
+
+// TestCompareExplicitFiles tests comparing two files given by path outside of a git repository
+func TestCompareExplicitFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeProtoFile(t, dir, "old/api.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+		}
+	`)
+	writeProtoFile(t, dir, "new/api.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+		}
+	`)
+
+	changes, err := compareExplicitFiles(filepath.Join(dir, "old", "api.proto"), filepath.Join(dir, "new", "api.proto"), options{rules: protobreak.DefaultRuleSet()})
+	if err != nil {
+		t.Fatalf("Failed to compare files: %v", err)
+	}
+	expected := []string{
+		`Field "age" (number 2) was removed from message "TestMessage"`,
+		`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+	}
+	if !reflect.DeepEqual(changeMessages(changes), expected) {
+		t.Errorf("Expected errors %v, got %v", expected, changeMessages(changes))
+	}
+
+	if _, err := compareExplicitFiles(filepath.Join(dir, "missing.proto"), filepath.Join(dir, "new", "api.proto"), options{rules: protobreak.DefaultRuleSet()}); err == nil {
+		t.Error("Expected an error for a missing old file")
+	}
+}
+
+// Helper function to parse a previous and current proto source into file descriptors
+func parseTestProtos(t *testing.T, prevProto, currProto string) (protoreflect.FileDescriptor, protoreflect.FileDescriptor) {
+	t.Helper()
+
+	prevFile, err := createTempProtoFile(prevProto)
+	if err != nil {
+		t.Fatalf("Failed to create previous proto file: %v", err)
+	}
+	defer os.Remove(prevFile)
+
+	currFile, err := createTempProtoFile(currProto)
+	if err != nil {
+		t.Fatalf("Failed to create current proto file: %v", err)
+	}
+	defer os.Remove(currFile)
+
+	prevFileDesc, err := parseProtoFileToReflect(prevFile)
+	if err != nil {
+		t.Fatalf("Failed to parse previous proto file: %v", err)
+	}
+
+	currFileDesc, err := parseProtoFileToReflect(currFile)
+	if err != nil {
+		t.Fatalf("Failed to parse current proto file: %v", err)
+	}
+
+	return prevFileDesc, currFileDesc
+}
+
+// Helper function to extract the messages of breaking changes
+func changeMessages(changes []protobreak.BreakingChange) []string {
+	messages := []string{}
+	for _, change := range changes {
+		messages = append(messages, change.Message)
+	}
+	return messages
+}
+
+// Helper function to create a temporary proto file
+func createTempProtoFile(content string) (string, error) {
+	// Create a temporary file
+	tmpFile, err := ioutil.TempFile("", "test_*.proto")
+	if err != nil {
+		return "", err
+	}
+	defer tmpFile.Close()
+
+	// Write the content to the file
+	content = strings.TrimSpace(content)
+	if _, err := tmpFile.WriteString(content); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	// Get the absolute path
+	absPath, err := filepath.Abs(tmpFile.Name())
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	return absPath, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigPath is loaded when --config is not given and the file exists
+const defaultConfigPath = "protobreak.yaml"
+
+// config is the structure of the protobreak.yaml configuration file
+type config struct {
+	// Rules maps rule IDs to a severity: error, warning or off
+	Rules map[string]string `yaml:"rules" doc:"Rule IDs mapped to a severity: error, warning or off"`
+	// SoftReserved lists field number ranges that new fields should not use
+	SoftReserved []softReservedRange `yaml:"soft_reserved" doc:"Field number ranges that new fields should not use"`
+	// FileOptions maps file option names to the severity of their changes, overriding FILE_SAME_OPTIONS
+	FileOptions map[string]string `yaml:"file_options" doc:"File option names, such as go_package or (acme.api_version), mapped to the severity of their changes: error, warning, info or off"`
+}
+
+// softReservedRange is an inclusive range of field numbers documented as "do not use".
+// An empty Message applies the range to every message.
+type softReservedRange struct {
+	Message string `yaml:"message" doc:"Full name of the message the range applies to; empty for every message"`
+	Start   int32  `yaml:"start" doc:"First field number of the range"`
+	End     int32  `yaml:"end" doc:"Last field number of the range"`
+	Reason  string `yaml:"reason" doc:"Why the numbers should not be used, shown in warnings"`
+}
+
+// loadConfig reads a configuration file. A missing file at the default path is not an error.
+func loadConfig(path string) (config, error) {
+	var cfg config
+
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return cfg, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	for _, r := range cfg.SoftReserved {
+		if r.Start < 1 || r.End < r.Start {
+			return cfg, fmt.Errorf("invalid soft_reserved range %d-%d in config file %s", r.Start, r.End, path)
+		}
+	}
+	return cfg, nil
+}
+
+// fileOptionSeverities returns the severity of changes to each file option listed in the config
+func (c config) fileOptionSeverities() (map[string]protobreak.Severity, error) {
+	severities := make(map[string]protobreak.Severity, len(c.FileOptions))
+	for name, value := range c.FileOptions {
+		severity, err := protobreak.ParseSeverity(value)
+		if err != nil {
+			return nil, fmt.Errorf("file option %s: %v", name, err)
+		}
+		severities[name] = severity
+	}
+	return severities, nil
+}
+
+// compareOptions returns the file option severities and soft reserved ranges of the config as
+// options of protobreak.Compare
+func (c config) compareOptions() ([]protobreak.Option, error) {
+	fileOptionSeverities, err := c.fileOptionSeverities()
+	if err != nil {
+		return nil, err
+	}
+	compareOpts := []protobreak.Option{protobreak.WithFileOptionSeverities(fileOptionSeverities)}
+	for _, r := range c.SoftReserved {
+		compareOpts = append(compareOpts, protobreak.WithSoftReserved(r.Message, r.Start, r.End, r.Reason))
+	}
+	return compareOpts, nil
+}
+
+// ruleSeverities returns the rule severity overrides from the config
+func (c config) ruleSeverities() (map[string]protobreak.Severity, error) {
+	severities := make(map[string]protobreak.Severity, len(c.Rules))
+	for id, value := range c.Rules {
+		severity, err := protobreak.ParseSeverity(value)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %v", id, err)
+		}
+		severities[strings.ToUpper(id)] = severity
+	}
+	return severities, nil
+}
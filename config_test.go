@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestLoadConfig tests loading rule severities from a config file
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "protobreak.yaml")
+	content := `
+rules:
+  FIELD_INT_ENUM_MIGRATION: error
+  field_same_name: off
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	severities, err := cfg.ruleSeverities()
+	if err != nil {
+		t.Fatalf("Failed to read rule severities: %v", err)
+	}
+
+	expected := map[string]protobreak.Severity{
+		protobreak.RuleFieldIntEnumMigration: protobreak.SeverityError,
+		protobreak.RuleFieldSameName:         protobreak.SeverityOff,
+	}
+	if !reflect.DeepEqual(severities, expected) {
+		t.Errorf("Expected severities %v, got %v", expected, severities)
+	}
+}
+
+// TestLoadConfigErrors tests that invalid config files are rejected
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "Unknown key", content: "rulez: {}"},
+		{name: "Unknown severity", content: "rules: {FIELD_SAME_NAME: fatal}"},
+		{name: "Empty soft reserved range", content: "soft_reserved: [{start: 200, end: 100}]"},
+		{name: "Unknown file option severity", content: "file_options: {go_package: fatal}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "protobreak.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			cfg, err := loadConfig(path)
+			if err == nil {
+				_, err = cfg.ruleSeverities()
+			}
+			if err == nil {
+				_, err = cfg.fileOptionSeverities()
+			}
+			if err == nil {
+				t.Error("Expected an error for an invalid config")
+			}
+		})
+	}
+
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected an error for a missing explicit config file")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// findRegularProtoFiles returns the .proto files under root like findProtoFiles,
+// but skips symlinks so that a tree is never compared through links into another.
+func findRegularProtoFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || filepath.Ext(path) != ".proto" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// compareDirFile compares the file at the relative path file in both trees. Imports are resolved from
+// the root of each tree, the root derived from the file's package and the proto paths under it.
+func compareDirFile(oldDir, newDir, file string, opts options) ([]protobreak.BreakingChange, error) {
+	for _, dir := range []string{oldDir, newDir} {
+		if err := opts.checkFileSizeOnDisk(filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+			return nil, err
+		}
+	}
+
+	prevImportPaths := append(append([]string{oldDir}, opts.packageRootPaths(openFile, oldDir, file)...), opts.importPathsUnder(oldDir)...)
+	prevFile, err := ParseProtoFileFrom(openFile, prevImportPaths, file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing old proto file: %v", err)
+	}
+
+	currImportPaths := append(append([]string{newDir}, opts.packageRootPaths(openFile, newDir, file)...), opts.importPathsUnder(newDir)...)
+	currFile, err := ParseProtoFileFrom(openFile, currImportPaths, file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing new proto file: %v", err)
+	}
+	if err := validateSyntax(currFile.UnwrapFile(), opts.requireSyntax); err != nil {
+		return nil, fmt.Errorf("%s %v", file, err)
+	}
+
+	return compareFiles(prevFile.UnwrapFile(), currFile.UnwrapFile(), opts), nil
+}
+
+// compareDirs compares every proto file of oldDir with the file at the same relative path in newDir.
+// Files only present in newDir cannot break anything and are ignored, like files matching --exclude.
+// Files that fail to parse are written to status and reported through failed.
+func compareDirs(oldDir, newDir string, status io.Writer, opts options) (reports []protobreak.FileReport, failed bool, err error) {
+	oldFiles, err := findRegularProtoFiles(oldDir)
+	if err != nil {
+		return nil, false, err
+	}
+	newFiles, err := findRegularProtoFiles(newDir)
+	if err != nil {
+		return nil, false, err
+	}
+	oldFiles = opts.withoutExcluded(oldFiles)
+	present := make(map[string]bool, len(newFiles))
+	for _, file := range newFiles {
+		present[file] = true
+	}
+
+	for _, file := range oldFiles {
+		if !present[file] {
+			changes := opts.rules.Filter([]protobreak.BreakingChange{fileRemovedChange(file)})
+			if len(changes) > 0 {
+				reports = append(reports, protobreak.FileReport{File: file, BreakingChanges: changes})
+			}
+			continue
+		}
+
+		changes, err := compareDirFile(oldDir, newDir, file, opts)
+		if opts.skipsOversized(err) {
+			fmt.Fprintf(status, "Warning: skipping %v\n", err)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(status, "Error comparing %s: %v\n", file, err)
+			failed = true
+			continue
+		}
+		reports = append(reports, protobreak.FileReport{File: file, BreakingChanges: changes})
+	}
+	return reports, failed, nil
+}
+
+// runDirs compares the trees given by --old-dir and --new-dir, returning the process exit code.
+// The report is written to out and progress to status.
+func runDirs(oldDir, newDir, format string, out, status io.Writer, opts options) int {
+	fmt.Fprintf(status, "Analyzing changes from %s to %s...\n", oldDir, newDir)
+	reports, failed, err := compareDirs(oldDir, newDir, status, opts)
+	if err != nil {
+		fmt.Fprintf(status, "Error: %v\n", err)
+		return exitCode(nil, true, opts.bitExit, opts.failOn)
+	}
+
+	return finishReports(reports, failed, format, out, status, opts)
+}
+
+// finishReports applies the suppressions and baseline of opts to reports compared outside of git,
+// writes them to out in the given format and returns the process exit code
+func finishReports(reports []protobreak.FileReport, failed bool, format string, out, status io.Writer, opts options) int {
+	for i, report := range reports {
+		reports[i].BreakingChanges = opts.suppressions.filter(report.BreakingChanges)
+	}
+	warnUnmatchedSuppressions(status, opts.suppressions)
+	if opts.writeBaselinePath != "" {
+		if err := writeBaseline(opts.writeBaselinePath, reports, opts.baselineIgnoreWarnings); err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			return 1
+		}
+	}
+	for i, report := range reports {
+		reports[i] = opts.baseline.diff(report)
+	}
+
+	if err := writeReports(out, format, reports); err != nil {
+		fmt.Fprintf(status, "Error writing report: %v\n", err)
+		return 1
+	}
+	return exitCode(failingReports(reports, opts.reportOnlyRules), failed, opts.bitExit, opts.failOn)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestCompareDirs tests that files are matched by relative path, removed files are reported,
+// and non-proto files and symlinks are skipped
+func TestCompareDirs(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	for _, root := range []string{oldDir, newDir} {
+		writeProtoFile(t, root, "common/types.proto", `
+			syntax = "proto3";
+			package common;
+			message Money {
+				int64 units = 1;
+			}
+		`)
+		writeProtoFile(t, root, "notes.txt", "not a proto file")
+	}
+	writeProtoFile(t, oldDir, "api/orders.proto", `
+		syntax = "proto3";
+		package api;
+		import "common/types.proto";
+		message Order {
+			common.Money total = 1;
+			string note = 2;
+		}
+	`)
+	writeProtoFile(t, oldDir, "api/legacy.proto", `
+		syntax = "proto3";
+		package api;
+		message Legacy {}
+	`)
+	writeProtoFile(t, newDir, "api/orders.proto", `
+		syntax = "proto3";
+		package api;
+		import "common/types.proto";
+		message Order {
+			common.Money total = 1;
+		}
+	`)
+	writeProtoFile(t, newDir, "api/added.proto", `
+		syntax = "proto3";
+		package api;
+		message Added {}
+	`)
+
+	// A symlink only present in the old tree would be reported as removed if it were followed
+	if err := os.Symlink(filepath.Join(oldDir, "api", "legacy.proto"), filepath.Join(oldDir, "linked.proto")); err != nil {
+		t.Skipf("Symlinks are not supported: %v", err)
+	}
+
+	reports, failed, err := compareDirs(oldDir, newDir, io.Discard, options{rules: protobreak.DefaultRuleSet()})
+	if err != nil {
+		t.Fatalf("Failed to compare directories: %v", err)
+	}
+	if failed {
+		t.Fatal("Expected every file to be compared")
+	}
+
+	got := make(map[string][]string)
+	for _, report := range reports {
+		got[report.File] = changeMessages(report.BreakingChanges)
+	}
+	expected := map[string][]string{
+		"api/legacy.proto": {`File "api/legacy.proto" was removed`},
+		"api/orders.proto": {
+			`Field "note" (number 2) was removed from message "Order"`,
+			`Removed field "note" (number 2) is not reserved in message "Order"`,
+		},
+		"common/types.proto": {},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected reports %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// checkExcludePatterns validates the glob patterns of --exclude
+func checkExcludePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid --exclude pattern %q: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchGlob reports whether the slash separated file matches pattern. Segments are matched with
+// path.Match, and a ** segment matches any number of directories, including none.
+func matchGlob(pattern, file string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+// matchSegments matches the remaining pattern segments against the remaining path segments
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
+
+// fileExcluded reports whether a file matches one of the --exclude patterns of opts
+func (o options) fileExcluded(file string) bool {
+	for _, pattern := range o.excludeGlobs {
+		if matchGlob(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutExcluded returns the files that match none of the --exclude patterns, keeping their order
+func (o options) withoutExcluded(files []string) []string {
+	if len(o.excludeGlobs) == 0 {
+		return files
+	}
+	kept := make([]string, 0, len(files))
+	for _, file := range files {
+		if !o.fileExcluded(file) {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
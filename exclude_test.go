@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestExcludeGlobs tests that --exclude patterns match paths with path.Match segments and ** directories
+func TestExcludeGlobs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		matches bool
+	}{
+		{pattern: "google/**", file: "google/protobuf/any.proto", matches: true},
+		{pattern: "google/**", file: "api/google/any.proto", matches: false},
+		{pattern: "**/internal/**", file: "internal/a.proto", matches: true},
+		{pattern: "**/internal/**", file: "api/v1/internal/a.proto", matches: true},
+		{pattern: "**/internal/**", file: "api/internalapi/a.proto", matches: false},
+		{pattern: "api/*.proto", file: "api/a.proto", matches: true},
+		{pattern: "api/*.proto", file: "api/v1/a.proto", matches: false},
+		{pattern: "**/*_test.proto", file: "a_test.proto", matches: true},
+		{pattern: "vendor", file: "vendor/a.proto", matches: false},
+	}
+
+	for _, tt := range tests {
+		if actual := matchGlob(tt.pattern, tt.file); actual != tt.matches {
+			t.Errorf("Expected matchGlob(%q, %q) to be %t, got %t", tt.pattern, tt.file, tt.matches, actual)
+		}
+	}
+
+	opts := options{excludeGlobs: []string{"google/**", "**/internal/**"}}
+	files := []string{"api/a.proto", "api/internal/b.proto", "google/protobuf/any.proto", "users.proto"}
+	if actual, expected := opts.withoutExcluded(files), []string{"api/a.proto", "users.proto"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	if err := checkExcludePatterns([]string{"api/[a-"}); err == nil {
+		t.Error("Expected an error for an invalid --exclude pattern")
+	}
+}
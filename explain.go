@@ -0,0 +1,468 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// ruleExplanation is the long form documentation of a rule shown by --explain
+type ruleExplanation struct {
+	// Why explains what breaks for existing clients and data
+	Why string
+	// Before and After are proto snippets showing a change reported by the rule
+	Before string
+	After  string
+	// Migration recommends how to make the change safely
+	Migration string
+}
+
+// ruleExplanations documents every rule of protobreak.Rules
+var ruleExplanations = map[string]ruleExplanation{
+	protobreak.RuleMessageAdded: {
+		Why:       "Adding a message breaks nothing. It is listed with --verbose so that API reviews see the new types next to the breaking changes.",
+		Before:    "message User {}",
+		After:     "message User {}\nmessage Team {}",
+		Migration: "None needed.",
+	},
+	protobreak.RuleMessageNoDelete: {
+		Why: "Code generated from the previous schema, and other files importing it, still refer to the message. " +
+			"Removing it breaks their compilation and drops the data of fields using it.",
+		Before:    "message Address {\n  string city = 1;\n}",
+		After:     "// Address removed",
+		Migration: "Keep the message and mark it with option deprecated = true until no client uses it.",
+	},
+	protobreak.RuleRPCMessageNoDelete: {
+		Why: "The message is the input or output of a method, so removing it breaks every client and server " +
+			"implementing that method, not only code that refers to the message directly.",
+		Before:    "message GetUserRequest {}\nservice UserService {\n  rpc GetUser(GetUserRequest) returns (User);\n}",
+		After:     "// GetUserRequest removed",
+		Migration: "Add a new method with new messages, deprecate the old method and remove both once no client calls it.",
+	},
+	protobreak.RuleMessageSameWireFormat: {
+		Why: "message_set_wire_format replaces the encoding of the whole message with the legacy MessageSet encoding. " +
+			"Data written with one encoding cannot be read with the other.",
+		Before:    "message Container {\n  extensions 4 to max;\n}",
+		After:     "message Container {\n  option message_set_wire_format = true;\n  extensions 4 to max;\n}",
+		Migration: "Define a new message with the desired encoding and migrate readers and writers to it.",
+	},
+	protobreak.RuleMessageSameMapEntry: {
+		Why: "Messages with map_entry = true are the synthetic entries of a map field, which code generators turn into " +
+			"native maps instead of message types. Descriptors built by hand or by option manipulation can toggle it, " +
+			"changing the generated API of every field using the message.",
+		Before:    "message Entry {\n  string key = 1;\n  string value = 2;\n}",
+		After:     "message Entry {\n  option map_entry = true;\n  string key = 1;\n  string value = 2;\n}",
+		Migration: "Declare a map field instead of setting map_entry, and keep the existing message unchanged.",
+	},
+	protobreak.RuleFieldAdded: {
+		Why: "Old readers skip unknown field numbers, so new fields are safe on the wire. They are listed with --verbose " +
+			"because reviewers usually want to check their names, numbers and presence.",
+		Before:    "message User {\n  string name = 1;\n}",
+		After:     "message User {\n  string name = 1;\n  string email = 2;\n}",
+		Migration: "None needed.",
+	},
+	protobreak.RuleFieldNoDelete: {
+		Why: "Clients compiled against the previous schema still send the field and expect to receive it. " +
+			"Its data is silently dropped, and its number could later be reused for a field with another meaning.",
+		Before:    "message User {\n  string name = 1;\n  int32 age = 2;\n}",
+		After:     "message User {\n  string name = 1;\n}",
+		Migration: "Reserve the number and name of the field so that they are never reused:\n  reserved 2;\n  reserved \"age\";",
+	},
+	protobreak.RuleFieldRemovedNotReserved: {
+		Why: "Without a reservation, a later change can add a new field with the number or name of the removed one. " +
+			"Old data and clients still using the removed field would then be read as the new field.",
+		Before:    "message User {\n  string name = 1;\n  int32 age = 2;\n}",
+		After:     "message User {\n  reserved 2;\n  reserved \"age\";\n  string name = 1;\n}",
+		Migration: "Reserve both the number and the name of every removed field.",
+	},
+	protobreak.RuleFieldMovedToNested: {
+		Why: "Moving a field into a nested message keeps its number but changes where it is encoded. " +
+			"Consumers of the outer message no longer find the field, and the nested message is read as unknown data by old clients.",
+		Before:    "message Address {\n  string zip = 7;\n}",
+		After:     "message Address {\n  message Geo {\n    string zip = 7;\n  }\n  Geo geo = 8;\n}",
+		Migration: "Keep the field in the outer message, deprecated, and write both fields until every consumer reads the nested one.",
+	},
+	protobreak.RuleFieldSameName: {
+		Why: "The binary encoding only uses field numbers, but JSON, text format and generated code use the field name. " +
+			"Renaming a field breaks the compilation of clients and the parsing of JSON data.",
+		Before:    "message User {\n  string name = 1;\n}",
+		After:     "message User {\n  string full_name = 1;\n}",
+		Migration: "Add a field with the new name and a new number, deprecate the old one and reserve it once unused.",
+	},
+	protobreak.RuleFieldUniqueNumber: {
+		Why: "Two fields sharing a number cannot be told apart on the wire, so decoders assign the data of one to the other. " +
+			"Parsers reject such files, but generated descriptors may still contain them.",
+		Before:    "message User {\n  string name = 1;\n}",
+		After:     "message User {\n  string name = 1;\n  string nickname = 1;\n}",
+		Migration: "Give the new field an unused number.",
+	},
+	protobreak.RuleFieldNoNumberReuse: {
+		Why: "A field whose name and type both changed is a new field that took over the number of a removed one. " +
+			"Old data is decoded into the new field, where it is misread or fails to parse.",
+		Before:    "message User {\n  int32 age = 2;\n}",
+		After:     "message User {\n  string email = 2;\n}",
+		Migration: "Give the new field an unused number and reserve the number and name of the old field.",
+	},
+	protobreak.RuleReservedNoDelete: {
+		Why: "Reservations usually keep the numbers of deleted fields from being reused. Once removed, nothing stops a " +
+			"future field from taking the number, and old data still holding the deleted field is then misread.",
+		Before:    "message User {\n  reserved 5;\n  string name = 1;\n}",
+		After:     "message User {\n  string name = 1;\n}",
+		Migration: "Keep the reservation. Reserved numbers cost nothing on the wire.",
+	},
+	protobreak.RuleFieldNoExtensionOverlap: {
+		Why: "Extensions may use any number of a declared extension range, so a field inside it collides with the extensions " +
+			"of other files on the wire. Parsers reject such files, but generated descriptors may still contain them.",
+		Before:    "syntax = \"proto2\";\nmessage Event {\n  extensions 100 to 200;\n}",
+		After:     "syntax = \"proto2\";\nmessage Event {\n  extensions 100 to 200;\n  optional string source = 150;\n}",
+		Migration: "Give the field a number outside the extension ranges.",
+	},
+	protobreak.RuleFieldSameType: {
+		Why: "The new type uses a different wire type, so data written with the previous type cannot be decoded: " +
+			"parsers either fail or treat the value as an unknown field.",
+		Before:    "message User {\n  string id = 1;\n}",
+		After:     "message User {\n  int64 id = 1;\n}",
+		Migration: "Add a field with the new type and a new number, then deprecate and reserve the old one.",
+	},
+	protobreak.RuleFieldWireCompatibleType: {
+		Why: "The new type shares the wire type of the previous one, so old data still decodes, but it is interpreted differently, " +
+			"e.g. invalid UTF-8 bytes read as a string or a string parsed as a message.",
+		Before:    "message Blob {\n  string data = 1;\n}",
+		After:     "message Blob {\n  bytes data = 1;\n}",
+		Migration: "Make sure every existing value is valid for the new type before deploying the change.",
+	},
+	protobreak.RuleFieldSameSignedness: {
+		Why: "int32 and uint32 (and int64 and uint64) share the varint encoding, so the change looks safe and works " +
+			"for non-negative values. Negative values are decoded as very large unsigned numbers, and the other way around.",
+		Before:    "message Account {\n  int64 balance = 1;\n}",
+		After:     "message Account {\n  uint64 balance = 1;\n}",
+		Migration: "Keep the signed type if negative values were ever written, or add a new field with the unsigned type.",
+	},
+	protobreak.RuleFieldSameZigZag: {
+		Why: "sint32 and sint64 zigzag-encode their values so that small negative numbers stay short, while int and uint " +
+			"types write them as plain varints. Both use the varint wire type, so parsers accept old data without error " +
+			"but decode every value as a different number, e.g. -1 written as sint32 is read as 1 by int32, and 1 as 2.",
+		Before:    "message Reading {\n  sint32 delta = 1;\n}",
+		After:     "message Reading {\n  int32 delta = 1;\n}",
+		Migration: "Add a field with the new type and a new number, then deprecate and reserve the old one.",
+	},
+	protobreak.RuleFieldSameMessageEncoding: {
+		Why: "Length-prefixed message fields are written with the bytes wire type, while delimited fields are written " +
+			"like proto2 groups between start and end tags. Parsers cannot read one encoding as the other.",
+		Before:    "edition = \"2023\";\nmessage Order {\n  Details details = 1;\n}",
+		After:     "edition = \"2023\";\nmessage Order {\n  Details details = 1 [features.message_encoding = DELIMITED];\n}",
+		Migration: "Add a new field with the other encoding and deprecate the old one.",
+	},
+	protobreak.RuleFieldIntEnumMigration: {
+		Why: "int32 and enums share the varint encoding, so the data is preserved, but the set of accepted values changes. " +
+			"Values missing from the enum are treated as unknown enum values.",
+		Before:    "message Task {\n  int32 priority = 1;\n}",
+		After:     "message Task {\n  Priority priority = 1;\n}",
+		Migration: "Declare an enum value for every number clients may have written before migrating the field.",
+	},
+	protobreak.RuleFieldSameCardinality: {
+		Why: "Parsers keep only the last element when reading a list into a singular field, so data is lost. " +
+			"Changing a singular field to repeated is safe.",
+		Before:    "message User {\n  repeated string emails = 1;\n}",
+		After:     "message User {\n  string emails = 1;\n}",
+		Migration: "Keep the repeated field and add a new singular field with a new number if needed.",
+	},
+	protobreak.RuleFieldNoNewRequired: {
+		Why: "Parsers reject proto2 messages that lack a required field, so every message written by an existing client " +
+			"or stored before the change without the field fails to parse.",
+		Before:    "syntax = \"proto2\";\nmessage User {\n  optional string email = 2;\n}",
+		After:     "syntax = \"proto2\";\nmessage User {\n  required string email = 2;\n}",
+		Migration: "Keep the field optional and validate its presence in application code instead.",
+	},
+	protobreak.RuleRequiredFieldAdded: {
+		Why: "Parsers reject proto2 messages that lack a required field, so every message written by an existing client " +
+			"or stored before the change fails to parse. Generated Java and C++ builders also refuse to build a message " +
+			"without its required fields, so existing code creating the message fails at run time or no longer compiles.",
+		Before:    "syntax = \"proto2\";\nmessage User {\n  optional string name = 1;\n}",
+		After:     "syntax = \"proto2\";\nmessage User {\n  optional string name = 1;\n  required string email = 2;\n}",
+		Migration: "Add the field as optional and validate its presence in application code instead.",
+	},
+	protobreak.RuleFieldSamePresence: {
+		Why: "Fields with explicit presence can tell an unset field from one set to its default value. " +
+			"After losing presence, clients relying on has-methods or null checks can no longer make that distinction.",
+		Before:    "syntax = \"proto2\";\nmessage User {\n  optional string name = 1;\n}",
+		After:     "syntax = \"proto3\";\nmessage User {\n  string name = 1;\n}",
+		Migration: "Keep explicit presence with the optional keyword in proto3, or field_presence = EXPLICIT in editions.",
+	},
+	protobreak.RuleFieldSameLazy: {
+		Why: "Lazy message fields are only parsed when first accessed, so turning lazy on or off moves parse errors " +
+			"to another place in the program and changes the performance of decoding large messages.",
+		Before:    "syntax = \"proto2\";\nmessage Order {\n  optional Details details = 1;\n}",
+		After:     "syntax = \"proto2\";\nmessage Order {\n  optional Details details = 1 [lazy = true];\n}",
+		Migration: "Check that clients handle malformed nested messages at access time before enabling lazy parsing.",
+	},
+	protobreak.RuleFieldSameWeak: {
+		Why: "The message type of a weak field is only linked into a binary when something else depends on it. " +
+			"Builds relying on weak linkage to leave the type out grow or fail to link when the option is dropped, " +
+			"and code reading the field without linking the type breaks when it is added.",
+		Before:    "syntax = \"proto2\";\nimport weak \"details.proto\";\nmessage Order {\n  optional Details details = 1 [weak = true];\n}",
+		After:     "syntax = \"proto2\";\nimport \"details.proto\";\nmessage Order {\n  optional Details details = 1;\n}",
+		Migration: "Check the build rules of every binary using the message before toggling weak.",
+	},
+	protobreak.RuleFieldSamePacked: {
+		Why: "Packed repeated scalars are written as a single length-delimited record instead of one record per value. " +
+			"Current parsers accept both encodings, but older or hand-written readers only understand the one they were built for. " +
+			"proto3 fields are packed by default, so dropping the default or changing the syntax toggles the encoding too.",
+		Before:    "syntax = \"proto3\";\nmessage Sample {\n  repeated int32 values = 1;\n}",
+		After:     "syntax = \"proto3\";\nmessage Sample {\n  repeated int32 values = 1 [packed = false];\n}",
+		Migration: "Make sure every reader of the field accepts both encodings before changing it.",
+	},
+	protobreak.RuleFieldBecameRepeated: {
+		Why: "Parsers collect a singular value on the wire as a one-element list, so existing data still decodes. " +
+			"The generated code changes from a single value to a list though, so callers need to be updated.",
+		Before:    "message Order {\n  string tag = 1;\n}",
+		After:     "message Order {\n  repeated string tag = 1;\n}",
+		Migration: "Nothing is needed on the wire; update the code using the field when regenerating it.",
+	},
+	protobreak.RuleFieldNoMapConversion: {
+		Why: "A map is encoded as a repeated synthetic entry message whose key is field 1 and value is field 2. " +
+			"A hand-written entry message only decodes as a map entry when it happens to use the same numbers and types, " +
+			"and generated code changes from a list to a map either way.",
+		Before:    "message Config {\n  message Entry {\n    string name = 1;\n    string value = 2;\n  }\n  repeated Entry entries = 1;\n}",
+		After:     "message Config {\n  map<string, string> entries = 1;\n}",
+		Migration: "Add the map as a new field, write both fields during the migration, then deprecate and reserve the repeated field.",
+	},
+	protobreak.RuleMapKeyNoNarrowing: {
+		Why:       "Keys that do not fit in the smaller integer type are truncated, so distinct entries collapse into one.",
+		Before:    "message Index {\n  map<int64, string> names = 1;\n}",
+		After:     "message Index {\n  map<int32, string> names = 1;\n}",
+		Migration: "Add a map field with the new key type and a new number.",
+	},
+	protobreak.RuleMapEnumValueSameZeroValue: {
+		Why:       "Map entries without a value decode to the zero value of the enum, so changing it changes the meaning of existing entries.",
+		Before:    "enum Role {\n  ROLE_NONE = 0;\n  ROLE_ADMIN = 1;\n}",
+		After:     "enum Role {\n  ROLE_ADMIN = 0;\n  ROLE_NONE = 1;\n}",
+		Migration: "Keep the zero value and add new values with new numbers.",
+	},
+	protobreak.RuleFieldSameTextName: {
+		Why: "Text format files, such as configs checked into a repository, identify fields by name. " +
+			"After a rename they fail to parse.",
+		Before:    "message Config {\n  int32 timeout = 1;\n}",
+		After:     "message Config {\n  int32 timeout_seconds = 1;\n}",
+		Migration: "Update every text format file in the same change, or keep the previous name.",
+	},
+	protobreak.RuleFieldSameOneof: {
+		Why: "Generated code exposes oneof members through the oneof, and setting one member clears the others. " +
+			"Moving a field into, out of or between oneofs changes the API and which fields can be set together.",
+		Before:    "message Contact {\n  string email = 1;\n  oneof method {\n    string phone = 2;\n  }\n}",
+		After:     "message Contact {\n  oneof method {\n    string email = 1;\n    string phone = 2;\n  }\n}",
+		Migration: "Add a new field in the intended oneof and deprecate the old one.",
+	},
+	protobreak.RuleFieldSameJSONName: {
+		Why: "The JSON encoding and gRPC-JSON transcoding identify fields by their JSON name, which defaults to the " +
+			"lowerCamelCase field name or is set with json_name. Clients sending or reading the old name lose the field.",
+		Before:    "message User {\n  string display_name = 1;\n}",
+		After:     "message User {\n  string display_name = 1 [json_name = \"name\"];\n}",
+		Migration: "Keep the JSON name, or add a new field with the new name and deprecate the old one.",
+	},
+	protobreak.RuleFieldSameJSONType: {
+		Why: "The binary encoding survives the change, but JSON and gRPC-JSON transcoding clients send values the new type " +
+			"rejects, e.g. a plain string where bytes expect base64. It replaces the FIELD_WIRE_COMPATIBLE_TYPE warning for these changes. " +
+			"Integer types of any width parse both numbers and quoted strings, so they stay compatible.",
+		Before:    "message Blob {\n  string data = 1;\n}",
+		After:     "message Blob {\n  bytes data = 1;\n}",
+		Migration: "Add a field with the new type and a new number for JSON clients, then deprecate and reserve the old one.",
+	},
+	protobreak.RuleOneofNoWrapExistingFields: {
+		Why:       "Setting one field of a oneof clears the others, so clients that set several of the wrapped fields lose data.",
+		Before:    "message Contact {\n  string email = 1;\n  string phone = 2;\n}",
+		After:     "message Contact {\n  oneof method {\n    string email = 1;\n    string phone = 2;\n  }\n}",
+		Migration: "Add the oneof with new fields and deprecate the standalone ones.",
+	},
+	protobreak.RuleFieldNoAddInSoftReserved: {
+		Why:       "The config documents these field numbers as not to be used, e.g. because they are used by a fork or an internal schema.",
+		Before:    "message User {\n  string name = 1;\n}",
+		After:     "message User {\n  string name = 1;\n  string team = 1000;\n}",
+		Migration: "Pick a number outside the soft-reserved ranges.",
+	},
+	protobreak.RuleEnumMoved: {
+		Why: "The full name of a nested enum includes its message. Moving the enum keeps its values, but every field " +
+			"and file referring to it by the old name stops compiling, and Any type URLs and reflection lookups no longer match.",
+		Before:    "enum Status {\n  STATUS_UNKNOWN = 0;\n}\nmessage User {\n  Status status = 1;\n}",
+		After:     "message User {\n  enum Status {\n    STATUS_UNKNOWN = 0;\n  }\n  Status status = 1;\n}",
+		Migration: "Keep the enum where it is. Declare the new one next to it if the scope matters and migrate fields one by one.",
+	},
+	protobreak.RuleEnumNoDelete: {
+		Why:       "Code generated from the previous schema, and fields of other files, still refer to the enum.",
+		Before:    "enum Color {\n  COLOR_UNSPECIFIED = 0;\n}",
+		After:     "// Color removed",
+		Migration: "Keep the enum and mark it with option deprecated = true until no client uses it.",
+	},
+	protobreak.RuleEnumValueAdded: {
+		Why: "New enum values decode fine on the wire, but old clients see them as unknown values, which closed enums " +
+			"keep as unknown fields. They are listed with --verbose so that reviewers can check how clients handle them.",
+		Before:    "enum Status {\n  STATUS_UNKNOWN = 0;\n}",
+		After:     "enum Status {\n  STATUS_UNKNOWN = 0;\n  STATUS_ACTIVE = 1;\n}",
+		Migration: "None needed, as long as clients handle unknown values.",
+	},
+	protobreak.RuleEnumValueNoDelete: {
+		Why: "Clients still send the value, which then decodes as an unknown enum value, and its number could later be reused " +
+			"for a value with another meaning.",
+		Before:    "enum Status {\n  STATUS_UNSPECIFIED = 0;\n  STATUS_ACTIVE = 1;\n}",
+		After:     "enum Status {\n  STATUS_UNSPECIFIED = 0;\n}",
+		Migration: "Reserve the number and name of the value:\n  reserved 1;\n  reserved \"STATUS_ACTIVE\";",
+	},
+	protobreak.RuleEnumValueSameName: {
+		Why:       "JSON and text format encode enum values by name, and generated code refers to the name, so renaming it breaks both.",
+		Before:    "enum Status {\n  STATUS_ACTIVE = 1;\n}",
+		After:     "enum Status {\n  STATUS_ENABLED = 1;\n}",
+		Migration: "Add the new name as an alias with option allow_alias = true, then deprecate the old name.",
+	},
+	protobreak.RuleEnumValueSameNumber: {
+		Why: "Enum values are encoded by number, so data written with the old number decodes to another value " +
+			"or to an unknown value, while the name suggests nothing changed.",
+		Before:    "enum Status {\n  STATUS_UNSPECIFIED = 0;\n  ACTIVE = 1;\n}",
+		After:     "enum Status {\n  STATUS_UNSPECIFIED = 0;\n  ACTIVE = 2;\n}",
+		Migration: "Keep the number of existing values and add new values with new numbers.",
+	},
+	protobreak.RuleEnumSameZeroValue: {
+		Why: "Unset enum fields read as the first declared value, which proto3 requires to be zero, so changing its number " +
+			"changes the meaning of every message without the field. Renaming it only changes the name and is reported as a rename.",
+		Before:    "syntax = \"proto2\";\nenum Status {\n  STATUS_ACTIVE = 1;\n  STATUS_UNSPECIFIED = 0;\n}",
+		After:     "syntax = \"proto2\";\nenum Status {\n  STATUS_UNSPECIFIED = 0;\n  STATUS_ACTIVE = 1;\n}",
+		Migration: "Keep the zero value and add new values with new numbers.",
+	},
+	protobreak.RuleEnumValueSameOptions: {
+		Why: "Options such as deprecated or custom lifecycle annotations change how tools and clients treat a value, " +
+			"even though the encoding stays the same.",
+		Before:    "enum Status {\n  STATUS_OLD = 1;\n}",
+		After:     "enum Status {\n  STATUS_OLD = 1 [(my.replacement) = \"STATUS_NEW\"];\n}",
+		Migration: "Check that the tools relying on the option handle the new value.",
+	},
+	protobreak.RuleServiceAdded: {
+		Why:       "A new service does not affect existing clients. It is listed with --verbose to show the full surface of an API change.",
+		Before:    "service UserService {}",
+		After:     "service UserService {}\nservice TeamService {}",
+		Migration: "None needed.",
+	},
+	protobreak.RuleServiceNoDelete: {
+		Why:       "Clients still call the methods of the service and receive an unimplemented error.",
+		Before:    "service UserService {\n  rpc GetUser(GetUserRequest) returns (User);\n}",
+		After:     "// UserService removed",
+		Migration: "Deprecate the service and remove it once no client calls it.",
+	},
+	protobreak.RuleServiceNoRewrite: {
+		Why: "When more than half of the methods of a service change their input or output type, the service was most likely " +
+			"rewritten rather than evolved, and no existing client can keep talking to it.",
+		Before:    "service UserService {\n  rpc GetUser(GetUserRequest) returns (User);\n  rpc ListUsers(ListUsersRequest) returns (UserList);\n}",
+		After:     "service UserService {\n  rpc GetUser(UserQuery) returns (UserProfile);\n  rpc ListUsers(UserQuery) returns (UserProfiles);\n}",
+		Migration: "Publish the rewrite as a new service, or a new package version, and keep the old one until clients have moved.",
+	},
+	protobreak.RuleRPCAdded: {
+		Why:       "Existing clients never call a method they do not know. New methods are listed with --verbose for review.",
+		Before:    "service UserService {\n  rpc GetUser(GetUserRequest) returns (User);\n}",
+		After:     "service UserService {\n  rpc GetUser(GetUserRequest) returns (User);\n  rpc ListUsers(ListUsersRequest) returns (ListUsersResponse);\n}",
+		Migration: "None needed.",
+	},
+	protobreak.RuleRPCNoDelete: {
+		Why:       "Clients still call the method and receive an unimplemented error. When it has a google.api.http binding, REST clients of its endpoint break too.",
+		Before:    "service UserService {\n  rpc GetUser(GetUserRequest) returns (User);\n}",
+		After:     "service UserService {}",
+		Migration: "Mark the method with option deprecated = true and remove it once no client calls it.",
+	},
+	protobreak.RuleRPCSameRequestType: {
+		Why:       "Servers decode the requests of existing clients with the new input type, which does not match the data they send.",
+		Before:    "rpc GetUser(GetUserRequest) returns (User);",
+		After:     "rpc GetUser(UserQuery) returns (User);",
+		Migration: "Add a new method with the new input type and deprecate the old one.",
+	},
+	protobreak.RuleRPCSameResponseType: {
+		Why:       "Existing clients decode responses with the previous output type, which does not match the data servers send.",
+		Before:    "rpc GetUser(GetUserRequest) returns (User);",
+		After:     "rpc GetUser(GetUserRequest) returns (UserProfile);",
+		Migration: "Add a new method with the new output type and deprecate the old one.",
+	},
+	protobreak.RuleRPCSameClientStreaming: {
+		Why:       "Streaming changes the calling convention of the method, so existing clients and servers no longer agree on it.",
+		Before:    "rpc Upload(Chunk) returns (Result);",
+		After:     "rpc Upload(stream Chunk) returns (Result);",
+		Migration: "Add a new streaming method and deprecate the old one.",
+	},
+	protobreak.RuleRPCSameServerStreaming: {
+		Why:       "Streaming changes the calling convention of the method, so existing clients and servers no longer agree on it.",
+		Before:    "rpc Watch(WatchRequest) returns (Event);",
+		After:     "rpc Watch(WatchRequest) returns (stream Event);",
+		Migration: "Add a new streaming method and deprecate the old one.",
+	},
+	protobreak.RuleFileSameSyntax: {
+		Why: "The syntax decides field presence, default values and how enums and unknown fields are handled, " +
+			"so a migration can change behavior without any field changing.",
+		Before:    "syntax = \"proto2\";",
+		After:     "syntax = \"proto3\";",
+		Migration: "Review the presence and defaults of every field, e.g. keep optional on fields that need presence.",
+	},
+	protobreak.RuleFileSameEdition: {
+		Why: "Each edition has its own default features, such as field presence and enum openness, " +
+			"so fields without explicit features may change behavior.",
+		Before:    "edition = \"2023\";",
+		After:     "edition = \"2024\";",
+		Migration: "Set the features whose defaults changed explicitly to keep the previous behavior.",
+	},
+	protobreak.RuleFileSamePackage: {
+		Why: "The package is part of the full name of every message, enum and service in the file. Method paths, " +
+			"Any type URLs and generated code all change with it, even when the types themselves stay the same.",
+		Before:    "package acme.users.v1;",
+		After:     "package acme.accounts.v1;",
+		Migration: "Copy the types into a file with the new package and keep the old file until all clients have moved.",
+	},
+	protobreak.RuleFileNoDelete: {
+		Why: "Every file importing the removed file stops compiling, and all the types it declared disappear " +
+			"from generated code at once.",
+		Before:    "// acme/users.proto\nmessage User {}",
+		After:     "// acme/users.proto removed",
+		Migration: "Deprecate the types in the file first and remove it only once nothing imports it.",
+	},
+	protobreak.RuleFileSameOptions: {
+		Why: "File options such as go_package, java_package or csharp_namespace decide where and how code is generated. " +
+			"Changing them moves generated types, which breaks the imports of every consumer even though the wire format stays the same.",
+		Before:    "option go_package = \"example.com/acme/users/v1\";",
+		After:     "option go_package = \"example.com/acme/accounts/v1\";",
+		Migration: "List the options that matter to your consumers under file_options in the config to raise their severity.",
+	},
+	protobreak.RuleFieldDeprecated: {
+		Why: "Deprecating a field keeps it on the wire, so nothing breaks yet. Generated code starts warning its callers, " +
+			"and the field is likely to be removed later, so consumers should hear about it early.",
+		Before:    "message User {\n  string nickname = 2;\n}",
+		After:     "message User {\n  string nickname = 2 [deprecated = true];\n}",
+		Migration: "Nothing to migrate yet. Move consumers to the replacement before the field is removed.",
+	},
+	protobreak.RuleDeprecationAdded: {
+		Why: "Deprecating an element does not change the wire format, but generated code starts warning every caller " +
+			"and the element is on its way out. Some teams require a sign-off before that happens.",
+		Before:    "message User {\n  string name = 1;\n}",
+		After:     "message User {\n  string name = 1 [deprecated = true];\n}",
+		Migration: "Get the deprecation approved, then accept it with --write-suppressions or a baseline.",
+	},
+}
+
+// explainRule writes the long form documentation of a rule, including its resolved severity
+func explainRule(w io.Writer, rs protobreak.RuleSet, id string) error {
+	rule, ok := protobreak.LookupRule(strings.ToUpper(strings.TrimSpace(id)))
+	if !ok {
+		return fmt.Errorf("unknown rule %q", id)
+	}
+	explanation, ok := ruleExplanations[rule.ID]
+	if !ok {
+		return fmt.Errorf("no explanation for rule %s", rule.ID)
+	}
+
+	fmt.Fprintf(w, "%s (%s, %s)\n", rule.ID, strings.ToLower(string(rs[rule.ID])), rule.Category)
+	fmt.Fprintf(w, "%s\n\n", rule.Description)
+	fmt.Fprintf(w, "Why: %s\n\n", explanation.Why)
+	fmt.Fprintf(w, "Before:\n%s\n\n", indent(explanation.Before))
+	fmt.Fprintf(w, "After:\n%s\n\n", indent(explanation.After))
+	fmt.Fprintf(w, "Migration: %s\n", explanation.Migration)
+	return nil
+}
+
+// indent indents every line of a snippet by two spaces
+func indent(snippet string) string {
+	return "  " + strings.ReplaceAll(snippet, "\n", "\n  ")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestExplainRule tests the explanation of a known rule
+func TestExplainRule(t *testing.T) {
+	var buf bytes.Buffer
+	if err := explainRule(&buf, protobreak.DefaultRuleSet(), "field_no_delete"); err != nil {
+		t.Fatalf("Failed to explain rule: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{
+		"FIELD_NO_DELETE (error, message)",
+		"Fields must not be removed",
+		"Why: Clients compiled against the previous schema",
+		"Before:\n  message User {\n    string name = 1;\n    int32 age = 2;\n  }",
+		"After:\n  message User {\n    string name = 1;\n  }",
+		"reserved 2;",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in explanation:\n%s", expected, output)
+		}
+	}
+
+	if err := explainRule(&buf, protobreak.DefaultRuleSet(), "NOT_A_RULE"); err == nil {
+		t.Error("Expected an error for an unknown rule")
+	}
+
+	// Every rule is documented
+	for _, rule := range protobreak.Rules() {
+		if _, ok := ruleExplanations[rule.ID]; !ok {
+			t.Errorf("Expected an explanation for %s", rule.ID)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitRepo locates the repository that git commands run against
+type gitRepo struct {
+	// gitDir and workTree are forwarded as --git-dir and --work-tree when set
+	gitDir   string
+	workTree string
+	// lfsSmudge replaces Git LFS pointers with their content using git lfs smudge
+	lfsSmudge bool
+}
+
+// command builds a git command forwarding the repository location flags
+func (r gitRepo) command(args ...string) *exec.Cmd {
+	var gitArgs []string
+	if r.gitDir != "" {
+		gitArgs = append(gitArgs, "--git-dir="+r.gitDir)
+	}
+	if r.workTree != "" {
+		gitArgs = append(gitArgs, "--work-tree="+r.workTree)
+	}
+	return exec.Command("git", append(gitArgs, args...)...)
+}
+
+// path returns the on-disk location of a repository-relative file
+func (r gitRepo) path(file string) string {
+	if r.workTree == "" {
+		return file
+	}
+	return filepath.Join(r.workTree, file)
+}
+
+// resolveCommit resolves a commit, branch or tag name to the hash of the commit it points to.
+// Annotated tags are peeled to their commit.
+func resolveCommit(repo gitRepo, ref string) (string, error) {
+	output, err := repo.command("rev-parse", "--verify", "--quiet", ref+"^{commit}").Output()
+	if err != nil {
+		return "", fmt.Errorf("error: commit '%s' does not exist or is invalid", ref)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// commitBefore returns the hash of the last commit on HEAD made before the given time,
+// for comparing against the state of the repository at that time
+func commitBefore(repo gitRepo, before time.Time) (string, error) {
+	output, err := repo.command("rev-list", "-1", "--before="+before.Format(time.RFC3339), "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("error running git rev-list: %v", err)
+	}
+	commit := strings.TrimSpace(string(output))
+	if commit == "" {
+		return "", fmt.Errorf("no commit before %s", before.Format(time.RFC3339))
+	}
+	return commit, nil
+}
+
+// semverPattern matches release tags such as v1.2.3 or 1.2.3-rc.1
+var semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// semver is the version of a release tag
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+// parseSemver parses a release tag, reporting whether it is a semantic version
+func parseSemver(tag string) (semver, bool) {
+	match := semverPattern.FindStringSubmatch(tag)
+	if match == nil {
+		return semver{}, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	patch, _ := strconv.Atoi(match[3])
+	return semver{major: major, minor: minor, patch: patch, prerelease: match[4]}, true
+}
+
+// less reports whether v precedes other. Pre-releases precede the release they lead up to.
+func (v semver) less(other semver) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	if v.minor != other.minor {
+		return v.minor < other.minor
+	}
+	if v.patch != other.patch {
+		return v.patch < other.patch
+	}
+	if v.prerelease == "" || other.prerelease == "" {
+		return v.prerelease != "" && other.prerelease == ""
+	}
+	return v.prerelease < other.prerelease
+}
+
+// latestSemverTagOfMajor returns the latest semver tag with the same major version as the
+// tag git describe finds for HEAD, for enforcing no breaking changes within a major version
+func latestSemverTagOfMajor(repo gitRepo) (string, error) {
+	output, err := repo.command("describe", "--tags", "--abbrev=0", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("error running git describe: no tag reachable from HEAD")
+	}
+	described := strings.TrimSpace(string(output))
+	current, ok := parseSemver(described)
+	if !ok {
+		return "", fmt.Errorf("tag %q of HEAD is not a semantic version", described)
+	}
+
+	output, err = repo.command("tag", "--list").Output()
+	if err != nil {
+		return "", fmt.Errorf("error running git tag: %v", err)
+	}
+	latestTag, latest := described, current
+	for _, tag := range strings.Fields(string(output)) {
+		version, ok := parseSemver(tag)
+		if ok && version.major == current.major && latest.less(version) {
+			latestTag, latest = tag, version
+		}
+	}
+	return latestTag, nil
+}
+
+// getModifiedProtoFiles returns a list of proto files with changes compared to the specified commit,
+// which may also be a branch or tag name
+func getModifiedProtoFiles(repo gitRepo, compareCommit string) ([]string, error) {
+	// First resolve the commit, so that refs sharing a name with a path are not ambiguous
+	commit, err := resolveCommit(repo, compareCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get changes compared to the specified commit
+	cmd := repo.command("diff", "--name-only", commit, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running git diff: %v", err)
+	}
+
+	// Filter for .proto files
+	var protoFiles []string
+	files := strings.Split(string(output), "\n")
+	for _, file := range files {
+		if strings.TrimSpace(file) == "" {
+			continue
+		}
+		if filepath.Ext(file) == ".proto" {
+			// Check if the file exists (it might have been deleted)
+			if _, err := os.Stat(repo.path(file)); err == nil {
+				protoFiles = append(protoFiles, file)
+			}
+		}
+	}
+
+	// Files are processed in parallel but reported in this order, so keep it independent of git's output
+	sort.Strings(protoFiles)
+	return protoFiles, nil
+}
+
+// getDeletedProtoFiles returns the proto files that existed at the specified commit but are gone
+// from the working tree, sorted by path
+func getDeletedProtoFiles(repo gitRepo, compareCommit string) ([]string, error) {
+	commit, err := resolveCommit(repo, compareCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := repo.command("diff", "--name-only", "--no-renames", "--diff-filter=D", commit, "--").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running git diff: %v", err)
+	}
+
+	var protoFiles []string
+	for _, file := range strings.Split(string(output), "\n") {
+		if file = strings.TrimSpace(file); file != "" && filepath.Ext(file) == ".proto" {
+			protoFiles = append(protoFiles, file)
+		}
+	}
+	sort.Strings(protoFiles)
+	return protoFiles, nil
+}
+
+// readFileAtCommit reads the version of a repository-relative file at a commit.
+// The error wraps os.ErrNotExist when the file is not in the commit.
+func readFileAtCommit(repo gitRepo, file, commit string) ([]byte, error) {
+	object := commit + ":" + filepath.ToSlash(file)
+	if err := repo.command("cat-file", "-e", object).Run(); err != nil {
+		return nil, fmt.Errorf("%s does not exist at %s: %w", file, commit, os.ErrNotExist)
+	}
+
+	output, err := repo.command("show", object).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s from git: %v", object, err)
+	}
+
+	// Files under a content filter such as Git LFS are stored as pointers
+	if isLFSPointer(output) {
+		if !repo.lfsSmudge {
+			return nil, fmt.Errorf("%s is stored as a Git LFS pointer at %s; use --lfs-smudge to fetch its content", file, commit)
+		}
+		return smudgeLFSPointer(repo, file, output)
+	}
+	return output, nil
+}
+
+// commitOpener opens files as they were at a commit for the parser. Relative paths are read
+// from the repository, absolute ones such as the buf cache from disk.
+func commitOpener(repo gitRepo, commit string) func(path string) (io.ReadCloser, error) {
+	return func(path string) (io.ReadCloser, error) {
+		if filepath.IsAbs(path) {
+			return os.Open(path)
+		}
+		content, err := readFileAtCommit(repo, path, commit)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+}
+
+// lfsPointerPrefix starts every Git LFS pointer file
+const lfsPointerPrefix = "version https://git-lfs.github.com/spec/v1\n"
+
+// isLFSPointer reports whether file content is a Git LFS pointer rather than the file itself
+func isLFSPointer(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(lfsPointerPrefix))
+}
+
+// smudgeLFSPointer fetches the content a Git LFS pointer refers to
+func smudgeLFSPointer(repo gitRepo, file string, pointer []byte) ([]byte, error) {
+	cmd := repo.command("lfs", "smudge", "--", file)
+	cmd.Stdin = bytes.NewReader(pointer)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running git lfs smudge for %s: %v", file, err)
+	}
+	return output, nil
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// Helper function to run git against a test repository
+func runGit(t *testing.T, repo gitRepo, args ...string) string {
+	t.Helper()
+
+	cmd := repo.command(append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Helper function to write a file inside a test repository's working tree
+func writeRepoFile(t *testing.T, repo gitRepo, file, content string) {
+	t.Helper()
+
+	path := repo.path(file)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory for %s: %v", file, err)
+	}
+	if err := os.WriteFile(path, []byte(strings.TrimSpace(content)), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", file, err)
+	}
+}
+
+// TestSeparateGitDirAndWorkTree tests that --git-dir and --work-tree are forwarded to git
+func TestSeparateGitDirAndWorkTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	repo := gitRepo{
+		gitDir:   filepath.Join(root, "metadata.git"),
+		workTree: filepath.Join(root, "checkout"),
+	}
+	if err := os.MkdirAll(repo.workTree, 0755); err != nil {
+		t.Fatalf("Failed to create work tree: %v", err)
+	}
+
+	runGit(t, gitRepo{}, "init", "--quiet", "--bare", repo.gitDir)
+	writeRepoFile(t, repo, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+		}
+	`)
+	runGit(t, repo, "add", "api/test.proto")
+	runGit(t, repo, "commit", "--quiet", "-m", "initial")
+
+	writeRepoFile(t, repo, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+		}
+	`)
+
+	files, err := getModifiedProtoFiles(repo, "HEAD")
+	if err != nil {
+		t.Fatalf("Failed to get modified proto files: %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"api/test.proto"}) {
+		t.Fatalf("Expected [api/test.proto], got %v", files)
+	}
+
+	changes, err := compareProtoFile(repo, files[0], "HEAD", options{rules: protobreak.DefaultRuleSet()})
+	if err != nil {
+		t.Fatalf("Failed to compare proto file: %v", err)
+	}
+	expected := []string{
+		`Field "age" (number 2) was removed from message "TestMessage"`,
+		`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+	}
+	if !reflect.DeepEqual(changeMessages(changes), expected) {
+		t.Errorf("Expected errors %v, got %v", expected, changeMessages(changes))
+	}
+}
+
+// TestCompareAgainstRefs tests comparing against tag and branch names, including a branch named like a directory
+func TestCompareAgainstRefs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	repo := gitRepo{gitDir: filepath.Join(root, ".git"), workTree: root}
+	runGit(t, gitRepo{}, "init", "--quiet", root)
+	writeRepoFile(t, repo, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+		}
+	`)
+	runGit(t, repo, "add", "api/test.proto")
+	runGit(t, repo, "commit", "--quiet", "-m", "initial")
+	runGit(t, repo, "tag", "-a", "v1.2.0", "-m", "release")
+	runGit(t, repo, "branch", "api")
+
+	writeRepoFile(t, repo, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+		}
+	`)
+
+	for _, ref := range []string{"v1.2.0", "api"} {
+		files, err := getModifiedProtoFiles(repo, ref)
+		if err != nil {
+			t.Fatalf("Failed to get modified proto files for %s: %v", ref, err)
+		}
+		if !reflect.DeepEqual(files, []string{"api/test.proto"}) {
+			t.Fatalf("Expected [api/test.proto] for %s, got %v", ref, files)
+		}
+
+		changes, err := compareProtoFile(repo, files[0], ref, options{rules: protobreak.DefaultRuleSet()})
+		if err != nil {
+			t.Fatalf("Failed to compare proto file against %s: %v", ref, err)
+		}
+		expected := []string{
+			`Field "age" (number 2) was removed from message "TestMessage"`,
+			`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+		}
+		if !reflect.DeepEqual(changeMessages(changes), expected) {
+			t.Errorf("Expected errors %v for %s, got %v", expected, ref, changeMessages(changes))
+		}
+	}
+
+	if _, err := getModifiedProtoFiles(repo, "missing"); err == nil {
+		t.Error("Expected an error for an unknown ref")
+	}
+}
+
+// TestLFSPointer tests that a previous version stored as a Git LFS pointer is rejected clearly
+func TestProtoPathImportsAtCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	repo := gitRepo{gitDir: filepath.Join(root, ".git"), workTree: root}
+	runGit(t, gitRepo{}, "init", "--quiet", root)
+	writeRepoFile(t, repo, "proto/common/types.proto", `
+		syntax = "proto3";
+		package common;
+		message Amount {
+			int64 cents = 1;
+		}
+	`)
+	writeRepoFile(t, repo, "api/order.proto", `
+		syntax = "proto3";
+		package api;
+		import "common/types.proto";
+		message Order {
+			common.Amount total = 1;
+		}
+	`)
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "--quiet", "-m", "initial")
+
+	// Only the previous types.proto shows that the type of the field changed
+	writeRepoFile(t, repo, "proto/common/types.proto", `
+		syntax = "proto3";
+		package common;
+		enum Amount {
+			AMOUNT_UNSPECIFIED = 0;
+		}
+	`)
+
+	changes, err := compareProtoFile(repo, "api/order.proto", "HEAD", options{rules: protobreak.DefaultRuleSet(), protoPaths: []string{"proto"}})
+	if err != nil {
+		t.Fatalf("Failed to compare proto file: %v", err)
+	}
+	expected := []string{`Field "total" changed from message common.Amount to enum common.Amount in message "Order" (wire type changed from length-delimited to varint)`}
+	if !reflect.DeepEqual(changeMessages(changes), expected) {
+		t.Errorf("Expected errors %v, got %v", expected, changeMessages(changes))
+	}
+
+	// Without the proto path, the error names the import and where it was looked for
+	_, err = compareProtoFile(repo, "api/order.proto", "HEAD", options{rules: protobreak.DefaultRuleSet()})
+	if err == nil {
+		t.Fatal("Expected an error for an unresolved import")
+	}
+	if !strings.Contains(err.Error(), `import "common/types.proto" not found, searched api`) {
+		t.Errorf("Expected the missing import and searched paths in the error, got %v", err)
+	}
+}
+
+func TestLFSPointer(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	repo := gitRepo{gitDir: filepath.Join(root, ".git"), workTree: root}
+	runGit(t, gitRepo{}, "init", "--quiet", root)
+	writeRepoFile(t, repo, "api/test.proto", lfsPointerPrefix+
+		"oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n")
+	runGit(t, repo, "add", "api/test.proto")
+	runGit(t, repo, "commit", "--quiet", "-m", "initial")
+
+	writeRepoFile(t, repo, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {}
+	`)
+
+	_, err := compareProtoFile(repo, "api/test.proto", "HEAD", options{rules: protobreak.DefaultRuleSet()})
+	if err == nil || !strings.Contains(err.Error(), "Git LFS pointer") {
+		t.Errorf("Expected a Git LFS pointer error, got %v", err)
+	}
+}
+
+// TestCommitBefore tests resolving --since-duration to the last commit before a time
+func TestCommitBefore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	repo := gitRepo{gitDir: filepath.Join(root, ".git"), workTree: root}
+	runGit(t, gitRepo{}, "init", "--quiet", root)
+
+	now := time.Now()
+	commitAt := func(age time.Duration, message string) string {
+		t.Helper()
+		writeRepoFile(t, repo, "test.proto", `syntax = "proto3"; // `+message)
+		runGit(t, repo, "add", "test.proto")
+		cmd := repo.command("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", message)
+		date := now.Add(-age).Format(time.RFC3339)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git commit failed: %v\n%s", err, output)
+		}
+		return runGit(t, repo, "rev-parse", "HEAD")
+	}
+	monthOld := commitAt(30*24*time.Hour, "a month ago")
+	weekOld := commitAt(8*24*time.Hour, "last week")
+	commitAt(time.Hour, "today")
+
+	tests := []struct {
+		since    time.Duration
+		expected string
+	}{
+		{since: 168 * time.Hour, expected: weekOld},
+		{since: 10 * 24 * time.Hour, expected: monthOld},
+	}
+	for _, tt := range tests {
+		commit, err := commitBefore(repo, now.Add(-tt.since))
+		if err != nil {
+			t.Fatalf("Failed to resolve the commit %s ago: %v", tt.since, err)
+		}
+		if commit != tt.expected {
+			t.Errorf("Expected commit %s %s ago, got %s", tt.expected, tt.since, commit)
+		}
+	}
+
+	if _, err := commitBefore(repo, now.Add(-365*24*time.Hour)); err == nil {
+		t.Error("Expected an error when no commit is old enough")
+	}
+}
+
+// TestLatestSemverTagOfMajor tests that HEAD is compared against the latest tag of its own major version
+func TestLatestSemverTagOfMajor(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	repo := gitRepo{gitDir: filepath.Join(root, ".git"), workTree: root}
+	runGit(t, gitRepo{}, "init", "--quiet", root)
+
+	commit := func(message string) {
+		t.Helper()
+		writeRepoFile(t, repo, "test.proto", `syntax = "proto3"; // `+message)
+		runGit(t, repo, "add", "test.proto")
+		runGit(t, repo, "commit", "--quiet", "-m", message)
+	}
+	for _, tag := range []string{"v1.0.0", "v1.2.0", "v1.10.0", "v2.0.0"} {
+		commit(tag)
+		runGit(t, repo, "tag", tag)
+	}
+	commit("unreleased v2 work")
+
+	tag, err := latestSemverTagOfMajor(repo)
+	if err != nil {
+		t.Fatalf("Failed to resolve the latest tag: %v", err)
+	}
+	if tag != "v2.0.0" {
+		t.Errorf("Expected v2.0.0 for HEAD after v2.0.0, got %s", tag)
+	}
+
+	// A maintenance branch of v1 is compared against the latest v1 release, not v2
+	runGit(t, repo, "checkout", "--quiet", "-b", "release-1.x", "v1.0.0")
+	commit("v1 fix")
+	tag, err = latestSemverTagOfMajor(repo)
+	if err != nil {
+		t.Fatalf("Failed to resolve the latest tag: %v", err)
+	}
+	if tag != "v1.10.0" {
+		t.Errorf("Expected v1.10.0 for HEAD after v1.0.0, got %s", tag)
+	}
+}
+
+// TestDeletedProtoFile tests that a proto file deleted since the commit is reported as removed
+func TestDeletedProtoFile(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	repo := gitRepo{gitDir: filepath.Join(root, ".git"), workTree: root}
+	runGit(t, gitRepo{}, "init", "--quiet", root)
+	writeRepoFile(t, repo, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+		}
+	`)
+	writeRepoFile(t, repo, "api/other.proto", `
+		syntax = "proto3";
+		package test.experimental;
+		message OtherMessage {
+			string name = 1;
+		}
+	`)
+	writeRepoFile(t, repo, "notes.txt", "notes")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "--quiet", "-m", "initial")
+
+	for _, file := range []string{"api/test.proto", "api/other.proto", "notes.txt"} {
+		if err := os.Remove(repo.path(file)); err != nil {
+			t.Fatalf("Failed to remove %s: %v", file, err)
+		}
+	}
+
+	modified, err := getModifiedProtoFiles(repo, "HEAD")
+	if err != nil {
+		t.Fatalf("Failed to get modified proto files: %v", err)
+	}
+	if len(modified) != 0 {
+		t.Errorf("Expected no modified proto files, got %v", modified)
+	}
+
+	deleted, err := getDeletedProtoFiles(repo, "HEAD")
+	if err != nil {
+		t.Fatalf("Failed to get deleted proto files: %v", err)
+	}
+	if expected := []string{"api/other.proto", "api/test.proto"}; !reflect.DeepEqual(deleted, expected) {
+		t.Fatalf("Expected %v, got %v", expected, deleted)
+	}
+
+	opts := options{rules: protobreak.DefaultRuleSet(), excludedPackages: []string{"test.experimental"}}
+	for _, tc := range []struct {
+		file     string
+		expected []string
+	}{
+		{file: "api/test.proto", expected: []string{`File "api/test.proto" was removed`}},
+		{file: "api/other.proto", expected: []string{}},
+	} {
+		changes, err := compareDeletedFile(repo, tc.file, "HEAD", opts)
+		if err != nil {
+			t.Fatalf("Failed to compare deleted file %s: %v", tc.file, err)
+		}
+		if actual := changeMessages(changes); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("Expected %v for %s, got %v", tc.expected, tc.file, actual)
+		}
+	}
+}
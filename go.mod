@@ -7,6 +7,7 @@ toolchain go1.23.9
 require (
 	github.com/jhump/protoreflect v1.17.0
 	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+	"gopkg.in/yaml.v3"
+)
+
+// suppressions hides known breaking changes, mapping the dotted path of the changed element
+// (e.g. TestMessage.age) to the rules suppressed for it and whether they matched a change
+type suppressions map[string]map[string]bool
+
+// loadSuppressions reads a YAML or JSON ignore file mapping paths to lists of rule IDs
+func loadSuppressions(path string) (suppressions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ignore file: %v", err)
+	}
+
+	var entries map[string][]string
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing ignore file %s: %v", path, err)
+	}
+
+	s := make(suppressions, len(entries))
+	for elementPath, ids := range entries {
+		s[elementPath] = make(map[string]bool, len(ids))
+		for _, id := range ids {
+			if _, ok := protobreak.LookupRule(id); !ok {
+				return nil, fmt.Errorf("unknown rule %q for %s in ignore file %s", id, elementPath, path)
+			}
+			s[elementPath][id] = false
+		}
+	}
+	return s, nil
+}
+
+// filter returns the changes that are not suppressed, marking the suppressions they matched
+func (s suppressions) filter(changes []protobreak.BreakingChange) []protobreak.BreakingChange {
+	if len(s) == 0 {
+		return changes
+	}
+
+	var kept []protobreak.BreakingChange
+	for _, change := range changes {
+		rules, ok := s[strings.Join(change.Path, ".")]
+		if _, suppressed := rules[change.Rule]; ok && suppressed {
+			rules[change.Rule] = true
+			continue
+		}
+		kept = append(kept, change)
+	}
+	return kept
+}
+
+// unmatched returns the suppressions that did not hide any change, formatted as path: RULE
+func (s suppressions) unmatched() []string {
+	var stale []string
+	for elementPath, rules := range s {
+		for id, matched := range rules {
+			if !matched {
+				stale = append(stale, elementPath+": "+id)
+			}
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// warnUnmatchedSuppressions prints a warning for every stale suppression so that it gets cleaned up
+func warnUnmatchedSuppressions(w io.Writer, s suppressions) {
+	for _, entry := range s.unmatched() {
+		fmt.Fprintf(w, "Warning: ignored change %s did not match any breaking change\n", entry)
+	}
+}
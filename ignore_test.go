@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestSuppressions tests hiding known breaking changes and reporting stale suppressions
+func TestSuppressions(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+			string email = 3;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+		}
+	`)
+
+	path := filepath.Join(t.TempDir(), "ignore.json")
+	content := `{"TestMessage.age": ["FIELD_NO_DELETE", "FIELD_REMOVED_NOT_RESERVED"], "TestMessage.name": ["FIELD_SAME_NAME"]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+	s, err := loadSuppressions(path)
+	if err != nil {
+		t.Fatalf("Failed to load ignore file: %v", err)
+	}
+
+	changes := s.filter(compareFiles(prevFileDesc, currFileDesc, options{rules: protobreak.DefaultRuleSet()}))
+	expected := []string{
+		`Field "email" (number 3) was removed from message "TestMessage"`,
+		`Removed field "email" (number 3) is not reserved in message "TestMessage"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if stale := s.unmatched(); !reflect.DeepEqual(stale, []string{"TestMessage.name: FIELD_SAME_NAME"}) {
+		t.Errorf("Expected the name suppression to be stale, got %v", stale)
+	}
+
+	if err := os.WriteFile(path, []byte("TestMessage.age: [field_removed]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+	if _, err := loadSuppressions(path); err == nil {
+		t.Error("Expected an error for an unknown rule")
+	}
+}
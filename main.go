@@ -3,500 +3,574 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"time"
 
-	"google.golang.org/protobuf/proto"
+	"github.com/valentine-shevchenko/proto-break/protobreak"
 	"google.golang.org/protobuf/reflect/protoreflect"
-	"google.golang.org/protobuf/types/descriptorpb"
 )
 
-// parseProtoFileToReflect parses a proto file and returns a protoreflect.FileDescriptor
-func parseProtoFileToReflect(filePath string) (protoreflect.FileDescriptor, error) {
-	// Use the ParseProtoFile function from parser.go
-	fileDesc, err := ParseProtoFile(filePath)
-	if err != nil {
-		return nil, err
-	}
+// stringList is a flag.Value collecting repeated or comma separated values
+type stringList []string
 
-	// Convert to protoreflect.FileDescriptor
-	return fileDesc.UnwrapFile(), nil
+// String returns the collected values as a comma separated list
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
 }
 
-// loadFileDescriptorSet loads a FileDescriptorSet from a file
-func loadFileDescriptorSet(path string) (*descriptorpb.FileDescriptorSet, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	var fds descriptorpb.FileDescriptorSet
-	if err := proto.Unmarshal(data, &fds); err != nil {
-		return nil, err
+// Set appends one or more comma separated values
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
 	}
-	return &fds, nil
+	return nil
 }
 
-// compareFields compares fields between previous and current messages
-func compareFields(prevMsg, currMsg protoreflect.MessageDescriptor) []string {
-	msgName := string(prevMsg.Name())
-	var breakingChanges []string
-	prevFields := prevMsg.Fields()
-	currFields := currMsg.Fields()
-
-	// Check field map for quick lookup by number
-	currFieldsByNumber := make(map[protoreflect.FieldNumber]protoreflect.FieldDescriptor)
-	for i := 0; i < currFields.Len(); i++ {
-		field := currFields.Get(i)
-		currFieldsByNumber[field.Number()] = field
-	}
-
-	// Check each previous field
-	for i := 0; i < prevFields.Len(); i++ {
-		prevField := prevFields.Get(i)
-		fieldName := string(prevField.Name())
-		fieldNumber := prevField.Number()
-
-		// Check if field was removed by number
-		currField, ok := currFieldsByNumber[fieldNumber]
-		if !ok {
-			breakingChanges = append(breakingChanges,
-				fmt.Sprintf("Field %q (number %d) was removed from message %q", fieldName, fieldNumber, msgName))
-			continue
-		}
-
-		// Check if field was renamed
-		if prevField.Name() != currField.Name() {
-			breakingChanges = append(breakingChanges,
-				fmt.Sprintf("Field renamed from %q to %q in message %q", prevField.Name(), currField.Name(), msgName))
-		}
-
-		// Check field type changes
-		prevKind := prevField.Kind()
-		currKind := currField.Kind()
-		if prevKind != currKind {
-			breakingChanges = append(breakingChanges,
-				fmt.Sprintf("Field %q type changed from %s to %s in message %q", fieldName, prevKind, currKind, msgName))
-		}
+func main() {
+	// Define command-line flags
+	compareCommitFlag := flag.String("commit", "HEAD", "Git commit, branch or tag to compare against (default: HEAD)")
+	sinceDurationFlag := flag.Duration("since-duration", 0, "Compare against the last commit on HEAD older than this duration instead of --commit (e.g. 168h)")
+	againstSemverMajorFlag := flag.Bool("against-semver-major", false, "Compare against the latest tag with the same major version as the tag of HEAD instead of --commit")
+	configFlag := flag.String("config", "", "Path to the config file (default: "+defaultConfigPath+" if present)")
+	onlyRulesFlag := flag.String("only-rules", "", "Comma-separated list of rules to run, skipping all others")
+	skipRulesFlag := flag.String("skip-rules", "", "Comma-separated list of rules to skip")
+	var excludePackageFlag stringList
+	var excludeFlag stringList
+	var protoPathFlag stringList
+	flag.Var(&protoPathFlag, "proto-path", "Directory of the working tree searched for imports, relative to its root (repeatable)")
+	packageRootsFlag := flag.Bool("package-roots", false, "Also resolve imports from the root derived from each file's package, e.g. proto for proto/acme/v1/a.proto in package acme.v1")
+	flag.Var(&excludePackageFlag, "exclude-package", "Skip files in this proto package and its sub-packages (repeatable)")
+	flag.Var(&excludeFlag, "exclude", "Skip proto files matching this glob pattern, where ** matches any directories (repeatable, e.g. google/**)")
+	lfsSmudgeFlag := flag.Bool("lfs-smudge", false, "Fetch the content of previous proto files stored as Git LFS pointers with git lfs smudge")
+	gitDirFlag := flag.String("git-dir", "", "Path to the repository metadata, forwarded to git as --git-dir")
+	workTreeFlag := flag.String("work-tree", "", "Path to the working tree, forwarded to git as --work-tree")
+	serveFlag := flag.String("serve", "", "Start an HTTP server on this address exposing POST /compare (e.g. :8080)")
+	strictOneofFlag := flag.Bool("strict-oneof", false, "Report new oneofs that wrap previously standalone fields")
+	verboseFlag := flag.Bool("verbose", false, "Also list new messages, fields, enum values, services and methods, and the changes of --show-additions, as info notes")
+	failOnDeprecationFlag := flag.Bool("fail-on-deprecation", false, "Fail on fields, messages, enum values, methods and services that became deprecated")
+	showAdditionsFlag := flag.Bool("show-additions", false, "Also list safe changes worth reviewing, such as fields that became repeated, as info notes")
+	warnOnAdditionsInReservedFlag := flag.Bool("warn-on-additions-in-reserved", false, "Warn about new fields using numbers in the soft_reserved ranges of the config")
+	againstImageFlag := flag.String("against-image", "", "Compare the working tree against a FileDescriptorSet snapshot or a .tar.gz/.zip of proto files instead of git")
+	oldFlag := flag.String("old", "", "Previous version of a proto file, compared with --new without using git")
+	newFlag := flag.String("new", "", "Current version of a proto file, compared with --old without using git")
+	stdinPairFlag := flag.Bool("stdin-pair", false, "Compare two proto sources read from stdin, separated by a NUL byte or a --- line")
+	oldDescriptorSetFlag := flag.String("old-descriptor-set", "", "Previous FileDescriptorSet (e.g. from protoc --descriptor_set_out or buf build), compared with --new-descriptor-set without parsing")
+	newDescriptorSetFlag := flag.String("new-descriptor-set", "", "Current FileDescriptorSet, compared with --old-descriptor-set without parsing")
+	oldDirFlag := flag.String("old-dir", "", "Previous version of a proto tree, compared file by file with --new-dir without using git")
+	newDirFlag := flag.String("new-dir", "", "Current version of a proto tree, compared file by file with --old-dir without using git")
+	anchorTypeFlag := flag.String("anchor-type", "", "With --against-image, only compare this fully-qualified message, wherever its file is (e.g. test.SharedConfig)")
+	writeSnapshotFlag := flag.String("write-snapshot", "", "Write the parsed working tree as a FileDescriptorSet snapshot to this path")
+	ignoreFieldRenamesFlag := flag.Bool("ignore-field-renames", false, "Do not report field renames, for schemas that are never used with JSON or text format")
+	checkJSONFlag := flag.Bool("check-json", false, "Report changes to the JSON names and JSON encodings of fields, for clients using JSON or gRPC-JSON transcoding")
+	textFormatStrictFlag := flag.Bool("text-format-strict", false, "Also report field renames as text format breaking changes")
+	formatFlag := flag.String("format", formatText, "Output format: text, json, html, console-tree, slack or github")
+	bufLockFlag := flag.String("buf-lock", "", "Resolve imports of the modules pinned in this buf.lock from the buf cache")
+	bufCacheFlag := flag.String("buf-cache", "", "Path to the buf cache used with --buf-lock (default: $BUF_CACHE_DIR or the user cache dir)")
+	baselineDiffFlag := flag.String("baseline-diff", "", "Only report changes that are not recorded in this baseline file")
+	writeBaselineFlag := flag.String("write-baseline", "", "Record all current changes as accepted in this baseline file")
+	writeSuppressionsFlag := flag.Bool("write-suppressions", false, "Insert "+protobreak.IgnoreDirective+" comments above the changes you accept in the current proto files")
+	yesFlag := flag.Bool("yes", false, "Accept every change for --write-suppressions without asking")
+	ignoreFlag := flag.String("ignore", "", "Hide known breaking changes listed in this YAML or JSON file, mapping element paths to rule IDs")
+	baselineIgnoreWarningsFlag := flag.Bool("baseline-ignore-warnings", false, "Only record breaking changes with --write-baseline, leaving warnings out")
+	requireSyntaxFlag := flag.String("require-syntax", "", "Fail when an analyzed file does not use this syntax: proto2, proto3 or editions")
+	jobsFlag := flag.Int("jobs", runtime.NumCPU(), "Number of files processed in parallel, the default for --parse-jobs and --compare-jobs; 1 runs sequentially for debugging")
+	parseJobsFlag := flag.Int("parse-jobs", runtime.NumCPU(), "Number of files parsed in parallel, including reading previous versions from git")
+	compareJobsFlag := flag.Int("compare-jobs", runtime.NumCPU(), "Number of parsed files compared in parallel")
+	timeBudgetFlag := flag.Duration("time-budget", 0, "Abort with exit code 2, or bit 2 with --bit-exit, when comparing modified files takes longer than this (e.g. 30s)")
+	reportUnchangedFlag := flag.Bool("report-unchanged", false, "Also list proto files that were not modified, proving every file was checked")
+	configSchemaFlag := flag.Bool("config-schema", false, "Print the JSON Schema of the config file for editor autocompletion")
+	explainFlag := flag.String("explain", "", "Explain why a rule's changes are breaking, with an example and the recommended migration")
+	maxFileSizeFlag := flag.Int64("max-file-size", 0, "Size in bytes above which proto files are not parsed (default: no limit)")
+	oversizeActionFlag := flag.String("oversize-action", oversizeError, "What to do with files over --max-file-size: skip them with a warning, or error")
+	failOnFlag := flag.String("fail-on", failOnError, "Lowest severity of changes that fails the run: error, warning or none")
+	reportOnlyRulesFlag := flag.String("report-only-rules", "", "Comma-separated rules whose changes are printed but never fail the run")
+	bitExitFlag := flag.Bool("bit-exit", false, "Exit with bit 0 set for breaking changes, bit 1 for warnings and bit 2 for processing errors")
+	listRulesFlag := flag.Bool("list-rules", false, "List every rule with its effective severity after applying config and flags")
+	helpFlag := flag.Bool("help", false, "Show help message")
+	flag.Parse()
 
-		// Check cardinality changes
-		prevCardinality := prevField.Cardinality()
-		currCardinality := currField.Cardinality()
-		if prevCardinality != currCardinality {
-			// Changing from repeated to singular is breaking
-			if prevCardinality == protoreflect.Repeated && currCardinality != protoreflect.Repeated {
-				breakingChanges = append(breakingChanges,
-					fmt.Sprintf("Field %q cardinality changed from repeated to singular in message %q", fieldName, msgName))
-			}
-		}
+	// Show help message if requested
+	if *helpFlag {
+		fmt.Println("Proto Breaking Change Detector")
+		fmt.Println("Automatically detects breaking changes in Protocol Buffer files")
+		fmt.Println("")
+		fmt.Println("Usage:")
+		fmt.Println("  go run main.go [options]")
+		fmt.Println("")
+		fmt.Println("Options:")
+		flag.PrintDefaults()
+		fmt.Println("")
+		fmt.Println("Exit codes:")
+		fmt.Println("  0  No breaking changes")
+		fmt.Println("  1  Breaking changes found at or above the --fail-on severity")
+		fmt.Println("  2  Files could not be parsed or compared, or the time budget ran out")
+		fmt.Println("  With --bit-exit, bit 0 (1) is set for breaking changes, bit 1 (2) for warnings")
+		fmt.Println("  and bit 2 (4) for files that could not be processed or a time budget that ran out")
+		fmt.Println("")
+		fmt.Println("Examples:")
+		fmt.Println("  go run main.go                   # Compare with HEAD (current state vs. last commit)")
+		fmt.Println("  go run main.go --commit HEAD~1   # Compare with the commit before the last one")
+		fmt.Println("  go run main.go --commit abc123   # Compare with a specific commit hash")
+		fmt.Println("  go run main.go --since-duration 168h   # What broke this week")
+		fmt.Println("  go run main.go --only-rules FIELD_NO_DELETE,ENUM_VALUE_NO_DELETE,RPC_NO_DELETE")
+		fmt.Println("  go run main.go --exclude-package google.protobuf")
+		fmt.Println("  go run main.go --exclude 'google/**' --exclude '**/internal/**'")
+		fmt.Println("  go run main.go --show-additions                   # Also list safe changes as info notes")
+		fmt.Println("  go run main.go --check-json                       # Schemas served through JSON transcoding")
+		fmt.Println("  go run main.go --ignore-field-renames             # Binary-only schemas")
+		fmt.Println("  go run main.go --config protobreak.yaml --list-rules")
+		fmt.Println("  go run main.go --explain FIELD_NO_DELETE")
+		fmt.Println("  go run main.go --config-schema > protobreak.schema.json")
+		fmt.Println("  go run main.go --old old/api.proto --new new/api.proto   # Compare two files without git")
+		fmt.Println("  go run main.go --git-dir /srv/repo.git --work-tree /src/checkout")
+		fmt.Println("  go run main.go --against-image snapshot.binpb --write-snapshot snapshot.binpb")
+		fmt.Println("  go run main.go --against-image release-1.2.tar.gz   # Compare with a shipped release bundle")
+		fmt.Println("  go run main.go --against-image snapshot.binpb --anchor-type test.SharedConfig")
+		fmt.Println("  go run main.go --format json > report.json")
+		fmt.Println("  go run main.go --format html > report.html")
+		fmt.Println("  go run main.go --format console-tree             # Group changes by message and field")
+		fmt.Println("  go run main.go --report-unchanged                 # List every proto file, even unmodified ones")
+		fmt.Println("  go run main.go --require-syntax proto3            # Fail on proto2 files")
+		fmt.Println("  go run main.go --jobs 1                           # Compare files one at a time")
+		fmt.Println("  go run main.go --parse-jobs 16 --compare-jobs 4   # Overlap slow git reads, bound CPU use")
+		fmt.Println("  go run main.go --fail-on warning                  # Also fail on warnings such as renames")
+		fmt.Println("  go run main.go --bit-exit                         # Exit with 1, 2 or 3 for breaking changes, warnings or both")
+		fmt.Println("  go run main.go --time-budget 30s                  # Exit with code 2 on runaway runs")
+		fmt.Println("  go run main.go --report-only-rules FIELD_SAME_JSON_NAME  # Print the rule without failing")
+		fmt.Println("  go run main.go --write-suppressions --yes         # Accept all changes with inline comments")
+		fmt.Println("  go run main.go --ignore ignore.yaml               # Hide intentional breaking changes")
+		fmt.Println("  go run main.go --write-baseline baseline.json     # Accept the current breaking changes")
+		fmt.Println("  go run main.go --baseline-diff baseline.json      # Only show changes added since then")
+		fmt.Println("  go run main.go --proto-path proto --proto-path third_party")
+		fmt.Println("  go run main.go --package-roots                    # Resolve imports from roots derived from packages")
+		fmt.Println("  go run main.go --buf-lock buf.lock --buf-cache ~/.cache/buf")
+		fmt.Println("  go run main.go --serve :8080             # Serve POST /compare for editors and web UIs")
+		os.Exit(0)
 	}
 
-	return breakingChanges
-}
-
-// collectNestedEnums collects all nested enums from message descriptors
-func collectNestedEnums(msgs protoreflect.MessageDescriptors, prefix string, output map[string]protoreflect.EnumDescriptor) {
-	for i := 0; i < msgs.Len(); i++ {
-		msg := msgs.Get(i)
-		msgPrefix := prefix + string(msg.Name()) + "."
-
-		// Collect enums in this message
-		enums := msg.Enums()
-		for j := 0; j < enums.Len(); j++ {
-			enum := enums.Get(j)
-			name := msgPrefix + string(enum.Name())
-			output[name] = enum
+	// Print the config schema without loading any config
+	if *configSchemaFlag {
+		if err := writeConfigSchema(os.Stdout); err != nil {
+			fmt.Printf("Error writing config schema: %v\n", err)
+			os.Exit(1)
 		}
-
-		// Recursively collect enums in nested messages
-		collectNestedEnums(msg.Messages(), msgPrefix, output)
+		os.Exit(0)
 	}
-}
 
-// collectNestedMessages collects all nested messages from message descriptors
-func collectNestedMessages(msgs protoreflect.MessageDescriptors, prefix string, output map[string]protoreflect.MessageDescriptor) {
-	for i := 0; i < msgs.Len(); i++ {
-		msg := msgs.Get(i)
-		name := prefix + string(msg.Name())
-		output[name] = msg
-
-		// Recursively collect nested messages
-		collectNestedMessages(msg.Messages(), name+".", output)
+	if _, err := protobreak.LookupFormatter(*formatFlag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
-}
-
-// compareEnums compares enums between previous and current files
-func compareEnums(prevFile, currFile protoreflect.FileDescriptor) []string {
-	var breakingChanges []string
-
-	// Collect all enums (including nested ones)
-	prevEnumsByName := make(map[string]protoreflect.EnumDescriptor)
-	currEnumsByName := make(map[string]protoreflect.EnumDescriptor)
-
-	// Collect top-level enums
-	prevEnums := prevFile.Enums()
-	currEnums := currFile.Enums()
-
-	for i := 0; i < prevEnums.Len(); i++ {
-		enum := prevEnums.Get(i)
-		prevEnumsByName[string(enum.Name())] = enum
+	if err := checkRequiredSyntax(*requireSyntaxFlag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
-
-	for i := 0; i < currEnums.Len(); i++ {
-		enum := currEnums.Get(i)
-		currEnumsByName[string(enum.Name())] = enum
+	failOn, err := parseFailOn(*failOnFlag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := checkOversizeAction(*oversizeActionFlag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Collect nested enums
-	collectNestedEnums(prevFile.Messages(), "", prevEnumsByName)
-	collectNestedEnums(currFile.Messages(), "", currEnumsByName)
+	// Load the config file
+	cfg, err := loadConfig(*configFlag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	severities, err := cfg.ruleSeverities()
+	if err != nil {
+		fmt.Printf("Error in config: %v\n", err)
+		os.Exit(1)
+	}
+	configOptions, err := cfg.compareOptions()
+	if err != nil {
+		fmt.Printf("Error in config: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Check each previous enum
-	for enumName, prevEnum := range prevEnumsByName {
-		// Check if enum was removed
-		currEnum, ok := currEnumsByName[enumName]
-		if !ok {
-			breakingChanges = append(breakingChanges,
-				fmt.Sprintf("Enum %q was removed", enumName))
-			continue
+	// Resolve which rules to run
+	var optInRules []string
+	if *textFormatStrictFlag {
+		optInRules = append(optInRules, protobreak.RuleFieldSameTextName)
+	}
+	if *checkJSONFlag {
+		optInRules = append(optInRules, protobreak.RuleFieldSameJSONName, protobreak.RuleFieldSameJSONType)
+	}
+	if *strictOneofFlag {
+		optInRules = append(optInRules, protobreak.RuleOneofNoWrapExistingFields)
+	}
+	if *showAdditionsFlag || *verboseFlag {
+		optInRules = append(optInRules, protobreak.RuleFieldBecameRepeated)
+	}
+	if *verboseFlag {
+		optInRules = append(optInRules, additionRules...)
+	}
+	if *warnOnAdditionsInReservedFlag {
+		optInRules = append(optInRules, protobreak.RuleFieldNoAddInSoftReserved)
+	}
+	if *failOnDeprecationFlag {
+		optInRules = append(optInRules, deprecationRules...)
+		severities = failOnDeprecation(severities)
+	}
+	skipRules := splitRuleList(*skipRulesFlag)
+	if *ignoreFieldRenamesFlag {
+		skipRules = append(skipRules, fieldRenameRules...)
+	}
+	rules, err := protobreak.NewRuleSet(splitRuleList(*onlyRulesFlag), skipRules, optInRules, severities)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	reportOnlyRules, err := parseReportOnlyRules(*reportOnlyRulesFlag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := checkExcludePatterns(excludeFlag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	// Explain a rule instead of running
+	if *explainFlag != "" {
+		if err := explainRule(os.Stdout, rules, *explainFlag); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
 		}
-
-		// Compare enum values
-		prevValues := prevEnum.Values()
-		currValuesByNumber := make(map[protoreflect.EnumNumber]protoreflect.EnumValueDescriptor)
-
-		// Build map of current enum values by number
-		currValues := currEnum.Values()
-		for j := 0; j < currValues.Len(); j++ {
-			value := currValues.Get(j)
-			currValuesByNumber[value.Number()] = value
+		os.Exit(0)
+	}
+	// List the resolved rules instead of running
+	if *listRulesFlag {
+		if err := writeRuleList(os.Stdout, rules); err != nil {
+			fmt.Printf("Error listing rules: %v\n", err)
+			os.Exit(1)
 		}
+		os.Exit(0)
+	}
 
-		// Check each previous enum value
-		for j := 0; j < prevValues.Len(); j++ {
-			prevValue := prevValues.Get(j)
-			valueName := string(prevValue.Name())
-			valueNumber := prevValue.Number()
-
-			// Check if enum value was removed
-			currValue, ok := currValuesByNumber[valueNumber]
-			if !ok {
-				breakingChanges = append(breakingChanges,
-					fmt.Sprintf("Enum value %q (number %d) was removed from enum %q",
-						valueName, valueNumber, enumName))
-				continue
+	opts := options{
+		rules:                  rules,
+		excludedPackages:       excludePackageFlag,
+		excludeGlobs:           excludeFlag,
+		protoPaths:             protoPathFlag,
+		packageRoots:           *packageRootsFlag,
+		configOptions:          configOptions,
+		writeBaselinePath:      *writeBaselineFlag,
+		baselineIgnoreWarnings: *baselineIgnoreWarningsFlag,
+		requireSyntax:          *requireSyntaxFlag,
+		anchorType:             *anchorTypeFlag,
+		bitExit:                *bitExitFlag,
+		failOn:                 failOn,
+		reportOnlyRules:        reportOnlyRules,
+		maxFileSize:            *maxFileSizeFlag,
+		oversizeAction:         *oversizeActionFlag,
+		writeSuppressions:      *writeSuppressionsFlag,
+		acceptAll:              *yesFlag,
+	}
+	if *sinceDurationFlag > 0 {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "commit" {
+				fmt.Println("Error: --since-duration cannot be combined with --commit")
+				os.Exit(1)
 			}
-
-			// Check if enum value was renamed
-			if prevValue.Name() != currValue.Name() {
-				breakingChanges = append(breakingChanges,
-					fmt.Sprintf("Enum value renamed from %q to %q in enum %q",
-						prevValue.Name(), currValue.Name(), enumName))
+		})
+	}
+	if *againstSemverMajorFlag {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "commit" || f.Name == "since-duration" {
+				fmt.Printf("Error: --against-semver-major cannot be combined with --%s\n", f.Name)
+				os.Exit(1)
 			}
-		}
+		})
 	}
-
-	return breakingChanges
-}
-
-// compareServices compares services between previous and current files
-func compareServices(prevFile, currFile protoreflect.FileDescriptor) []string {
-	var breakingChanges []string
-
-	// Get services from both files
-	prevServices := prevFile.Services()
-	currServices := currFile.Services()
-
-	// Create maps for quick lookup
-	currServicesByName := make(map[string]protoreflect.ServiceDescriptor)
-	for i := 0; i < currServices.Len(); i++ {
-		service := currServices.Get(i)
-		currServicesByName[string(service.Name())] = service
+	if (*oldFlag == "") != (*newFlag == "") {
+		fmt.Println("Error: --old and --new must be used together")
+		os.Exit(1)
+	}
+	if (*oldDirFlag == "") != (*newDirFlag == "") {
+		fmt.Println("Error: --old-dir and --new-dir must be used together")
+		os.Exit(1)
+	}
+	if *oldDirFlag != "" && *oldFlag != "" {
+		fmt.Println("Error: --old-dir cannot be combined with --old")
+		os.Exit(1)
+	}
+	if (*oldDescriptorSetFlag == "") != (*newDescriptorSetFlag == "") {
+		fmt.Println("Error: --old-descriptor-set and --new-descriptor-set must be used together")
+		os.Exit(1)
+	}
+	if *oldDescriptorSetFlag != "" && (*oldFlag != "" || *oldDirFlag != "") {
+		fmt.Println("Error: --old-descriptor-set cannot be combined with --old or --old-dir")
+		os.Exit(1)
+	}
+	if opts.anchorType != "" && *againstImageFlag == "" {
+		fmt.Println("Error: --anchor-type requires --against-image")
+		os.Exit(1)
 	}
 
-	// Check each previous service
-	for i := 0; i < prevServices.Len(); i++ {
-		prevService := prevServices.Get(i)
-		serviceName := string(prevService.Name())
-
-		// Check if service was removed
-		currService, ok := currServicesByName[serviceName]
-		if !ok {
-			breakingChanges = append(breakingChanges,
-				fmt.Sprintf("Service %q was removed", serviceName))
-			continue
+	if *baselineDiffFlag != "" {
+		opts.baseline, err = loadBaseline(*baselineDiffFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
 		}
+	}
 
-		// Compare methods
-		prevMethods := prevService.Methods()
-		currMethodsByName := make(map[string]protoreflect.MethodDescriptor)
-
-		// Build map of current methods by name
-		currMethods := currService.Methods()
-		for j := 0; j < currMethods.Len(); j++ {
-			method := currMethods.Get(j)
-			currMethodsByName[string(method.Name())] = method
+	if *ignoreFlag != "" {
+		opts.suppressions, err = loadSuppressions(*ignoreFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
 		}
+	}
 
-		// Check each previous method
-		for j := 0; j < prevMethods.Len(); j++ {
-			prevMethod := prevMethods.Get(j)
-			methodName := string(prevMethod.Name())
-
-			// Check if method was removed
-			currMethod, ok := currMethodsByName[methodName]
-			if !ok {
-				breakingChanges = append(breakingChanges,
-					fmt.Sprintf("Method %q was removed from service %q", methodName, serviceName))
-				continue
-			}
-
-			// Check input type changes
-			prevInput := prevMethod.Input().FullName()
-			currInput := currMethod.Input().FullName()
-			if prevInput != currInput {
-				breakingChanges = append(breakingChanges,
-					fmt.Sprintf("Method %q input type changed from %s to %s in service %q",
-						methodName, prevInput, currInput, serviceName))
-			}
-
-			// Check output type changes
-			prevOutput := prevMethod.Output().FullName()
-			currOutput := currMethod.Output().FullName()
-			if prevOutput != currOutput {
-				breakingChanges = append(breakingChanges,
-					fmt.Sprintf("Method %q output type changed from %s to %s in service %q",
-						methodName, prevOutput, currOutput, serviceName))
-			}
-
-			// Check streaming changes
-			if prevMethod.IsStreamingClient() != currMethod.IsStreamingClient() {
-				breakingChanges = append(breakingChanges,
-					fmt.Sprintf("Method %q client streaming changed from %v to %v in service %q",
-						methodName, prevMethod.IsStreamingClient(), currMethod.IsStreamingClient(), serviceName))
-			}
-
-			if prevMethod.IsStreamingServer() != currMethod.IsStreamingServer() {
-				breakingChanges = append(breakingChanges,
-					fmt.Sprintf("Method %q server streaming changed from %v to %v in service %q",
-						methodName, prevMethod.IsStreamingServer(), currMethod.IsStreamingServer(), serviceName))
-			}
+	// Resolve buf module imports from the local buf cache
+	if *bufLockFlag != "" {
+		opts.importPaths, err = bufImportPaths(*bufLockFlag, *bufCacheFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
 		}
 	}
 
-	return breakingChanges
-}
-
-// compareMessages compares messages between previous and current files
-func compareMessages(prevFile, currFile protoreflect.FileDescriptor) []string {
-	var breakingChanges []string
-
-	// Collect all messages (including nested ones)
-	prevMsgsByName := make(map[string]protoreflect.MessageDescriptor)
-	currMsgsByName := make(map[string]protoreflect.MessageDescriptor)
-
-	// Collect top-level messages
-	prevMsgs := prevFile.Messages()
-	currMsgs := currFile.Messages()
-
-	for i := 0; i < prevMsgs.Len(); i++ {
-		msg := prevMsgs.Get(i)
-		prevMsgsByName[string(msg.Name())] = msg
+	// Run as an HTTP server instead of checking the working tree
+	if *serveFlag != "" {
+		if err := serve(*serveFlag, opts); err != nil {
+			fmt.Printf("Error running server: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
-	for i := 0; i < currMsgs.Len(); i++ {
-		msg := currMsgs.Get(i)
-		currMsgsByName[string(msg.Name())] = msg
+	// Progress messages go to stderr so that other formats can be redirected to a file
+	// The text format is streamed file by file as each comparison finishes
+	streaming := *formatFlag == formatText
+	var status io.Writer = os.Stdout
+	if !streaming {
+		status = os.Stderr
 	}
 
-	// Collect nested messages
-	collectNestedMessages(prevFile.Messages(), "", prevMsgsByName)
-	collectNestedMessages(currFile.Messages(), "", currMsgsByName)
-
-	// Check each previous message
-	for msgName, prevMsg := range prevMsgsByName {
-		// Check if message was removed
-		currMsg, ok := currMsgsByName[msgName]
-		if !ok {
-			breakingChanges = append(breakingChanges,
-				fmt.Sprintf("Message %q was removed", msgName))
-			continue
-		}
-
-		// Compare fields
-		fieldChanges := compareFields(prevMsg, currMsg)
-		breakingChanges = append(breakingChanges, fieldChanges...)
+	// Compare two explicit files without git
+	if *oldFlag != "" {
+		os.Exit(runExplicitFiles(*oldFlag, *newFlag, *formatFlag, os.Stdin, os.Stdout, status, opts))
 	}
 
-	return breakingChanges
-}
-
-// getModifiedProtoFiles returns a list of proto files with changes compared to the specified commit
-func getModifiedProtoFiles(compareCommit string) ([]string, error) {
-	// First check if the commit exists
-	checkCmd := exec.Command("git", "rev-parse", "--verify", compareCommit)
-	if err := checkCmd.Run(); err != nil {
-		return nil, fmt.Errorf("error: commit '%s' does not exist or is invalid", compareCommit)
+	// Compare two directory trees without git
+	if *oldDirFlag != "" {
+		os.Exit(runDirs(*oldDirFlag, *newDirFlag, *formatFlag, os.Stdout, status, opts))
 	}
 
-	// Get changes compared to the specified commit
-	cmd := exec.Command("git", "diff", "--name-only", compareCommit)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("error running git diff: %v", err)
+	// Compare two sources piped to stdin, without touching the file system
+	if *stdinPairFlag {
+		os.Exit(runStdinPair(os.Stdin, *formatFlag, os.Stdout, status, opts))
 	}
 
-	// Filter for .proto files
-	var protoFiles []string
-	files := strings.Split(string(output), "\n")
-	for _, file := range files {
-		if strings.TrimSpace(file) == "" {
-			continue
-		}
-		if filepath.Ext(file) == ".proto" {
-			// Check if the file exists (it might have been deleted)
-			if _, err := os.Stat(file); err == nil {
-				protoFiles = append(protoFiles, file)
-			}
-		}
+	// Compare two descriptor sets, which already hold their imports
+	if *oldDescriptorSetFlag != "" {
+		os.Exit(runDescriptorSets(*oldDescriptorSetFlag, *newDescriptorSetFlag, *formatFlag, os.Stdout, status, opts))
 	}
 
-	return protoFiles, nil
-}
+	// No need to check for protoc installation since we're using protoparse directly
+	repo := gitRepo{gitDir: *gitDirFlag, workTree: *workTreeFlag, lfsSmudge: *lfsSmudgeFlag}
 
-// getPreviousVersionOfFile gets the previous version of a file from git
-func getPreviousVersionOfFile(file, compareCommit string) (string, error) {
-	// Create a temporary file to store the previous version
-	tmpFile, err := ioutil.TempFile("", "prev_*.proto")
-	if err != nil {
-		return "", fmt.Errorf("error creating temporary file: %v", err)
+	// Work with descriptor snapshots instead of git history
+	if *againstImageFlag != "" || *writeSnapshotFlag != "" {
+		os.Exit(runSnapshot(repo.path("."), *againstImageFlag, *writeSnapshotFlag, *formatFlag, os.Stdout, status, opts))
 	}
-	tmpPath := tmpFile.Name()
-	tmpFile.Close()
 
-	// Get the previous version from git
-	cmd := exec.Command("git", "show", compareCommit+":"+file)
-	output, err := cmd.Output()
-	if err != nil {
-		os.Remove(tmpPath)
-		return "", fmt.Errorf("error getting previous version from git: %v", err)
+	// Resolve the commit as of the given time ago, for periodic audits
+	if *sinceDurationFlag > 0 {
+		*compareCommitFlag, err = commitBefore(repo, time.Now().Add(-*sinceDurationFlag))
+		if err != nil {
+			fmt.Fprintf(status, "Error resolving --since-duration: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	// Write the previous version to the temporary file
-	if err := ioutil.WriteFile(tmpPath, output, 0644); err != nil {
-		os.Remove(tmpPath)
-		return "", fmt.Errorf("error writing to temporary file: %v", err)
+	// Resolve the latest release of the current major version, for compatibility gating within it
+	if *againstSemverMajorFlag {
+		*compareCommitFlag, err = latestSemverTagOfMajor(repo)
+		if err != nil {
+			fmt.Fprintf(status, "Error resolving --against-semver-major: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	return tmpPath, nil
-}
-
-// compareProtoFile compares the current and previous versions of a proto file
-func compareProtoFile(protoFile, compareCommit string) ([]string, error) {
-	fmt.Printf("Analyzing changes in %s...\n", protoFile)
-
-	// Get the previous version of the file
-	prevProtoPath, err := getPreviousVersionOfFile(protoFile, compareCommit)
+	// Get modified proto files
+	modifiedProtoFiles, err := getModifiedProtoFiles(repo, *compareCommitFlag)
 	if err != nil {
-		return nil, fmt.Errorf("error getting previous version: %v", err)
+		fmt.Fprintf(status, "Error getting modified proto files: %v\n", err)
+		os.Exit(1)
 	}
-	defer os.Remove(prevProtoPath)
 
-	// Parse proto files directly using protoparse
-	prevFileDesc, err := parseProtoFileToReflect(prevProtoPath)
+	// Deleted files are reported along with the modified ones, in file name order
+	deletedProtoFiles, err := getDeletedProtoFiles(repo, *compareCommitFlag)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing previous proto file: %v", err)
+		fmt.Fprintf(status, "Error getting deleted proto files: %v\n", err)
+		os.Exit(1)
 	}
-
-	currFileDesc, err := parseProtoFileToReflect(protoFile)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing current proto file: %v", err)
+	deleted := make(map[string]bool, len(deletedProtoFiles))
+	for _, file := range deletedProtoFiles {
+		deleted[file] = true
+	}
+	modifiedProtoFiles = append(modifiedProtoFiles, deletedProtoFiles...)
+	sort.Strings(modifiedProtoFiles)
+	modifiedProtoFiles = opts.withoutExcluded(modifiedProtoFiles)
+
+	// Unmodified files cannot have breaking changes, but are listed for audits
+	var unchanged []protobreak.FileReport
+	if *reportUnchangedFlag {
+		protoFiles, err := findProtoFiles(repo.path("."))
+		if err != nil {
+			fmt.Fprintf(status, "Error finding proto files: %v\n", err)
+			os.Exit(1)
+		}
+		unchanged = unchangedReports(opts.withoutExcluded(protoFiles), modifiedProtoFiles)
 	}
 
-	// Compare the files directly
-	var allBreakingChanges []string
-
-	// Compare messages
-	msgChanges := compareMessages(prevFileDesc, currFileDesc)
-	allBreakingChanges = append(allBreakingChanges, msgChanges...)
-
-	// Compare enums
-	enumChanges := compareEnums(prevFileDesc, currFileDesc)
-	allBreakingChanges = append(allBreakingChanges, enumChanges...)
-
-	// Compare services
-	serviceChanges := compareServices(prevFileDesc, currFileDesc)
-	allBreakingChanges = append(allBreakingChanges, serviceChanges...)
-
-	return allBreakingChanges, nil
-}
-
-func main() {
-	// Define command-line flags
-	compareCommitFlag := flag.String("commit", "HEAD", "Git commit to compare against (default: HEAD)")
-	helpFlag := flag.Bool("help", false, "Show help message")
-	flag.Parse()
-
-	// Show help message if requested
-	if *helpFlag {
-		fmt.Println("Proto Breaking Change Detector")
-		fmt.Println("Automatically detects breaking changes in Protocol Buffer files")
-		fmt.Println("")
-		fmt.Println("Usage:")
-		fmt.Println("  go run main.go [options]")
-		fmt.Println("")
-		fmt.Println("Options:")
-		flag.PrintDefaults()
-		fmt.Println("")
-		fmt.Println("Examples:")
-		fmt.Println("  go run main.go                   # Compare with HEAD (current state vs. last commit)")
-		fmt.Println("  go run main.go --commit HEAD~1   # Compare with the commit before the last one")
-		fmt.Println("  go run main.go --commit abc123   # Compare with a specific commit hash")
+	if len(modifiedProtoFiles) == 0 {
+		fmt.Fprintln(status, "No modified proto files found")
+		if opts.writeBaselinePath != "" {
+			if err := writeBaseline(opts.writeBaselinePath, nil, opts.baselineIgnoreWarnings); err != nil {
+				fmt.Fprintf(status, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := writeReports(os.Stdout, *formatFlag, unchanged); err != nil {
+			fmt.Fprintf(status, "Error writing report: %v\n", err)
+			os.Exit(1)
+		}
 		os.Exit(0)
 	}
 
-	// No need to check for protoc installation since we're using protoparse directly
-
-	// Get modified proto files
-	modifiedProtoFiles, err := getModifiedProtoFiles(*compareCommitFlag)
-	if err != nil {
-		fmt.Printf("Error getting modified proto files: %v\n", err)
-		os.Exit(1)
-	}
+	fmt.Fprintf(status, "Found %d modified proto files compared to %s\n", len(modifiedProtoFiles), *compareCommitFlag)
 
-	if len(modifiedProtoFiles) == 0 {
-		fmt.Println("No modified proto files found")
-		os.Exit(0)
+	// Validate the syntax of every file before looking for breaking changes
+	if opts.requireSyntax != "" {
+		valid := true
+		for _, protoFile := range modifiedProtoFiles {
+			fileDesc, err := parseProtoFileToReflect(repo.path(protoFile), opts.importPathsUnder(repo.path("."))...)
+			if err != nil {
+				// Parse errors are reported while comparing
+				continue
+			}
+			if err := validateSyntax(fileDesc, opts.requireSyntax); err != nil {
+				fmt.Fprintf(status, "Error: %s %v\n", protoFile, err)
+				valid = false
+			}
+		}
+		if !valid {
+			os.Exit(1)
+		}
 	}
 
-	fmt.Printf("Found %d modified proto files compared to %s\n", len(modifiedProtoFiles), *compareCommitFlag)
+	// --jobs sets the parallelism of the stages that are not set on their own
+	parseJobs, compareJobs := *parseJobsFlag, *compareJobsFlag
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "jobs" {
+			parseJobs, compareJobs = *jobsFlag, *jobsFlag
+		}
+	})
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "parse-jobs":
+			parseJobs = *parseJobsFlag
+		case "compare-jobs":
+			compareJobs = *compareJobsFlag
+		}
+	})
 
 	// Process each modified proto file
-	hasBreakingChanges := false
-	for _, protoFile := range modifiedProtoFiles {
-		breakingChanges, err := compareProtoFile(protoFile, *compareCommitFlag)
-		if err != nil {
-			fmt.Printf("Error processing %s: %v\n", protoFile, err)
-			continue
+	var reports, allReports []protobreak.FileReport
+	failed := false
+	limits := newStageLimits(parseJobs, compareJobs)
+	processed, exceeded := runWithBudget(modifiedProtoFiles, limits.jobs(), *timeBudgetFlag, func(protoFile string) func() {
+		var prevFileDesc, currFileDesc protoreflect.FileDescriptor
+		var breakingChanges []protobreak.BreakingChange
+		var err error
+		if deleted[protoFile] {
+			limits.parsing(func() {
+				breakingChanges, err = compareDeletedFile(repo, protoFile, *compareCommitFlag, opts)
+			})
+		} else {
+			limits.parsing(func() {
+				prevFileDesc, currFileDesc, err = parseProtoVersions(repo, protoFile, *compareCommitFlag, opts)
+			})
+		}
+		if err == nil && !deleted[protoFile] {
+			limits.comparing(func() {
+				breakingChanges = compareFiles(prevFileDesc, currFileDesc, opts)
+			})
 		}
 
-		// Print results for this file
-		if len(breakingChanges) == 0 {
-			fmt.Printf("✅ No breaking changes detected in %s\n", protoFile)
-		} else {
-			hasBreakingChanges = true
-			fmt.Printf("🔴 Detected %d breaking changes in %s:\n", len(breakingChanges), protoFile)
-			for _, change := range breakingChanges {
-				fmt.Printf("  - %s\n", change)
+		// Results are reported in file order, as soon as the earlier files are done
+		return func() {
+			fmt.Fprintf(status, "Analyzing changes in %s...\n", protoFile)
+			if opts.skipsOversized(err) {
+				fmt.Fprintf(status, "Warning: skipping %v\n", err)
+				return
+			}
+			if err != nil {
+				fmt.Fprintf(status, "Error processing %s: %v\n", protoFile, err)
+				failed = true
+				return
+			}
+			breakingChanges = opts.suppressions.filter(breakingChanges)
+			allReports = append(allReports, protobreak.FileReport{File: protoFile, BreakingChanges: breakingChanges})
+
+			report := opts.baseline.diff(protobreak.FileReport{File: protoFile, BreakingChanges: breakingChanges})
+			reports = append(reports, report)
+			if streaming {
+				if err := writeReports(os.Stdout, *formatFlag, []protobreak.FileReport{report}); err != nil {
+					fmt.Fprintf(status, "Error writing report: %v\n", err)
+					failed = true
+				}
 			}
 		}
+	})
+	if exceeded {
+		fmt.Fprintf(status, "Error: time budget of %s exceeded after processing %d of %d files\n",
+			*timeBudgetFlag, processed, len(modifiedProtoFiles))
+		// Files left unprocessed count as processing errors, also for the bits of --bit-exit
+		os.Exit(exitCode(failingReports(reports, opts.reportOnlyRules), true, opts.bitExit, opts.failOn))
 	}
 
-	// Exit with error code if breaking changes were found
-	if hasBreakingChanges {
+	reports = append(reports, unchanged...)
+
+	// Streamed formats have already written the compared files, the rest are written in one go
+	written := reports
+	if streaming {
+		written = unchanged
+	}
+	if err := writeReports(os.Stdout, *formatFlag, written); err != nil {
+		fmt.Fprintf(status, "Error writing report: %v\n", err)
 		os.Exit(1)
 	}
+
+	if opts.writeBaselinePath != "" {
+		if err := writeBaseline(opts.writeBaselinePath, allReports, opts.baselineIgnoreWarnings); err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	warnUnmatchedSuppressions(status, opts.suppressions)
+
+	if opts.writeSuppressions {
+		if err := annotateReports(reports, repo.path, os.Stdin, status, opts.acceptAll); err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Exit with error code if breaking changes were found or files could not be processed
+	os.Exit(exitCode(failingReports(reports, opts.reportOnlyRules), failed, opts.bitExit, opts.failOn))
 }
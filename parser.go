@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ParseProtoFile parses a single proto file from disk without requiring protoc.
+// Imports are resolved from the file's directory, then from importPaths.
+func ParseProtoFile(filePath string, importPaths ...string) (*desc.FileDescriptor, error) {
+	// Resolve the file relative to its own directory so that the
+	// descriptor is keyed by its base name
+	roots := append([]string{filepath.Dir(filePath)}, importPaths...)
+	return ParseProtoFileFrom(openFile, roots, filepath.Base(filePath))
+}
+
+// ParseProtoFileFrom parses the proto file name, looking it up like its imports in each of
+// importPaths in order and reading files with open. Errors for missing imports list the paths searched,
+// and errors for import cycles the imports leading from name back into the cycle.
+func ParseProtoFileFrom(open func(path string) (io.ReadCloser, error), importPaths []string, name string) (*desc.FileDescriptor, error) {
+	accessor := func(filename string) (io.ReadCloser, error) {
+		for _, importPath := range importPaths {
+			r, err := open(filepath.Join(importPath, filename))
+			if err == nil {
+				return r, nil
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+		return nil, fmt.Errorf("import %q not found, searched %s: %w", filename, strings.Join(importPaths, ", "), os.ErrNotExist)
+	}
+	parser := protoparse.Parser{
+		Accessor:              accessor,
+		IncludeSourceCodeInfo: true,
+	}
+
+	fileDescs, err := parser.ParseFiles(name)
+	if err != nil {
+		if cycle := findImportCycle(accessor, name); cycle != nil {
+			return nil, fmt.Errorf("import cycle %s", formatImportCycle(cycle))
+		}
+		return nil, err
+	}
+	if len(fileDescs) == 0 {
+		return nil, fmt.Errorf("no file descriptor produced for %s", name)
+	}
+
+	return fileDescs[0], nil
+}
+
+// openFile opens a file on disk for the parser
+func openFile(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// ParseProtoSource parses proto source held in memory, using name as its file name
+func ParseProtoSource(name, content string) (*desc.FileDescriptor, error) {
+	parser := protoparse.Parser{
+		Accessor:              protoparse.FileContentsFromMap(map[string]string{name: content}),
+		IncludeSourceCodeInfo: true,
+	}
+
+	fileDescs, err := parser.ParseFiles(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(fileDescs) == 0 {
+		return nil, fmt.Errorf("no file descriptor produced for %s", name)
+	}
+
+	return fileDescs[0], nil
+}
+
+// ParseProtoFiles parses proto files named relative to one of the import paths
+func ParseProtoFiles(importPaths []string, files ...string) ([]*desc.FileDescriptor, error) {
+	return ParseProtoFilesWithImports(importPaths, nil, files...)
+}
+
+// ParseProtoFilesWithImports parses proto files named relative to one of the import paths.
+// Imports that are not found in the import paths are looked up as descriptors in imports.
+func ParseProtoFilesWithImports(importPaths []string, imports map[string]*descriptorpb.FileDescriptorProto, files ...string) ([]*desc.FileDescriptor, error) {
+	parser := protoparse.Parser{
+		ImportPaths:           importPaths,
+		IncludeSourceCodeInfo: true,
+	}
+	if len(imports) > 0 {
+		parser.LookupImportProto = func(name string) (*descriptorpb.FileDescriptorProto, error) {
+			if fd, ok := imports[name]; ok {
+				return fd, nil
+			}
+			return nil, os.ErrNotExist
+		}
+	}
+	return parser.ParseFiles(files...)
+}
+
+// ParseProtoSources parses proto files held in memory and keyed by name.
+// Imports that are not in sources are resolved from importPaths.
+func ParseProtoSources(sources map[string]string, importPaths []string, files ...string) ([]*desc.FileDescriptor, error) {
+	inMemory := protoparse.FileContentsFromMap(sources)
+	parser := protoparse.Parser{
+		Accessor: func(name string) (io.ReadCloser, error) {
+			if _, ok := sources[name]; ok {
+				return inMemory(name)
+			}
+			for _, importPath := range importPaths {
+				if f, err := os.Open(filepath.Join(importPath, name)); err == nil {
+					return f, nil
+				}
+			}
+			return nil, os.ErrNotExist
+		},
+		IncludeSourceCodeInfo: true,
+	}
+	return parser.ParseFiles(files...)
+}
+
+// findImportCycle follows the imports of name depth first and returns the first chain of imports
+// that leads back to a file on the chain, e.g. [a.proto b.proto a.proto], or nil without cycles.
+// Files are parsed without linking, and files that cannot be read or parsed are left to the parser.
+func findImportCycle(accessor protoparse.FileAccessor, name string) []string {
+	parser := protoparse.Parser{Accessor: accessor}
+	done := make(map[string]bool)
+	var chain []string
+
+	var visit func(file string) []string
+	visit = func(file string) []string {
+		for i, onChain := range chain {
+			if onChain == file {
+				return append(append([]string{}, chain[i:]...), file)
+			}
+		}
+		if done[file] {
+			return nil
+		}
+		fileProtos, err := parser.ParseFilesButDoNotLink(file)
+		if err != nil || len(fileProtos) == 0 {
+			done[file] = true
+			return nil
+		}
+
+		chain = append(chain, file)
+		for _, dep := range fileProtos[0].GetDependency() {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		chain = chain[:len(chain)-1]
+		done[file] = true
+		return nil
+	}
+	return visit(name)
+}
+
+// formatImportCycle formats a chain of imports returned by findImportCycle
+func formatImportCycle(cycle []string) string {
+	quoted := make([]string, len(cycle))
+	for i, file := range cycle {
+		quoted[i] = fmt.Sprintf("%q", file)
+	}
+	return strings.Join(quoted, " -> ")
+}
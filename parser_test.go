@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestImportCycle tests that files importing each other fail with the cycle instead of a parse error
+func TestImportCycle(t *testing.T) {
+	root := t.TempDir()
+	writeProtoFile(t, root, "a.proto", `
+		syntax = "proto3";
+		package test;
+		import "b.proto";
+		message A {}
+	`)
+	writeProtoFile(t, root, "b.proto", `
+		syntax = "proto3";
+		package test;
+		import "a.proto";
+		message B {}
+	`)
+	writeProtoFile(t, root, "c.proto", `
+		syntax = "proto3";
+		package test;
+		import "google/protobuf/empty.proto";
+		import "b.proto";
+		message C {}
+	`)
+
+	tests := []struct {
+		file  string
+		cycle string
+	}{
+		{file: "a.proto", cycle: `import cycle "a.proto" -> "b.proto" -> "a.proto"`},
+		{file: "c.proto", cycle: `import cycle "b.proto" -> "a.proto" -> "b.proto"`},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseProtoFileFrom(openFile, []string{root}, tt.file)
+		if err == nil {
+			t.Fatalf("Expected an error for the import cycle of %s", tt.file)
+		}
+		if !strings.Contains(err.Error(), tt.cycle) {
+			t.Errorf("Expected %s for %s, got %v", tt.cycle, tt.file, err)
+		}
+	}
+
+	// Other parse errors are left unchanged
+	writeProtoFile(t, root, "d.proto", `
+		syntax = "proto3";
+		package test;
+		import "missing.proto";
+	`)
+	if _, err := ParseProtoFileFrom(openFile, []string{root}, "d.proto"); err == nil || strings.Contains(err.Error(), "import cycle") {
+		t.Errorf("Expected the missing import error, got %v", err)
+	}
+	if cycle := findImportCycle(func(string) (io.ReadCloser, error) { return nil, os.ErrNotExist }, "a.proto"); cycle != nil {
+		t.Errorf("Expected no cycle for unreadable files, got %v", cycle)
+	}
+}
@@ -0,0 +1,100 @@
+package protobreak
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// additionRules list additive changes, which are opt-in info notes
+var additionRules = []string{RuleMessageAdded, RuleFieldAdded, RuleEnumValueAdded, RuleServiceAdded, RuleRPCAdded}
+
+// compareAdditions reports the messages, fields, enum values, services and methods added to the
+// current file, in declaration order. Elements are matched by their name relative to the package,
+// so that a package rename does not turn everything into an addition.
+func compareAdditions(prevFile, currFile protoreflect.FileDescriptor, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	prevMsgs := make(map[string]protoreflect.MessageDescriptor)
+	forEachMessage(prevFile.Messages(), func(msg protoreflect.MessageDescriptor) {
+		prevMsgs[relativeName(msg)] = msg
+	})
+	forEachMessage(currFile.Messages(), func(currMsg protoreflect.MessageDescriptor) {
+		msgName := relativeName(currMsg)
+		prevMsg, ok := prevMsgs[msgName]
+		if !ok {
+			breakingChanges = append(breakingChanges, newChange(RuleMessageAdded, "Message %q was added", msgName).at(msgName))
+			return
+		}
+
+		for i := 0; i < currMsg.Fields().Len(); i++ {
+			field := currMsg.Fields().Get(i)
+			if prevMsg.Fields().ByNumber(field.Number()) == nil {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldAdded, "Field %q (number %d) was added to message %q",
+						field.Name(), field.Number(), currMsg.Name()).at(msgName, string(field.Name())))
+			}
+		}
+	})
+
+	prevEnums := make(map[string]protoreflect.EnumDescriptor)
+	forEachEnum(prevFile, func(enum protoreflect.EnumDescriptor) {
+		prevEnums[relativeName(enum)] = enum
+	})
+	forEachEnum(currFile, func(currEnum protoreflect.EnumDescriptor) {
+		enumName := relativeName(currEnum)
+		prevEnum, ok := prevEnums[enumName]
+		if !ok {
+			return
+		}
+		for i := 0; i < currEnum.Values().Len(); i++ {
+			value := currEnum.Values().Get(i)
+			if prevEnum.Values().ByNumber(value.Number()) == nil {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleEnumValueAdded, "Enum value %q (number %d) was added to enum %q",
+						value.Name(), value.Number(), enumName).at(enumName, string(value.Name())))
+			}
+		}
+	})
+
+	for i := 0; i < currFile.Services().Len(); i++ {
+		currService := currFile.Services().Get(i)
+		serviceName := string(currService.Name())
+		prevService := prevFile.Services().ByName(currService.Name())
+		if prevService == nil {
+			breakingChanges = append(breakingChanges, newChange(RuleServiceAdded, "Service %q was added", serviceName).at(serviceName))
+			continue
+		}
+		for j := 0; j < currService.Methods().Len(); j++ {
+			method := currService.Methods().Get(j)
+			if prevService.Methods().ByName(method.Name()) == nil {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleRPCAdded, "Method %q was added to service %q", method.Name(), serviceName).at(serviceName, string(method.Name())))
+			}
+		}
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// forEachMessage calls fn for every message and nested message in declaration order, skipping map entries
+func forEachMessage(msgs protoreflect.MessageDescriptors, fn func(msg protoreflect.MessageDescriptor)) {
+	for i := 0; i < msgs.Len(); i++ {
+		msg := msgs.Get(i)
+		if msg.IsMapEntry() {
+			continue
+		}
+		fn(msg)
+		forEachMessage(msg.Messages(), fn)
+	}
+}
+
+// forEachEnum calls fn for every top-level and nested enum of a file in declaration order
+func forEachEnum(file protoreflect.FileDescriptor, fn func(enum protoreflect.EnumDescriptor)) {
+	for i := 0; i < file.Enums().Len(); i++ {
+		fn(file.Enums().Get(i))
+	}
+	forEachMessage(file.Messages(), func(msg protoreflect.MessageDescriptor) {
+		for i := 0; i < msg.Enums().Len(); i++ {
+			fn(msg.Enums().Get(i))
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package protobreak
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCompareAdditions tests that --verbose lists additive changes as info notes that never fail the run
+func TestCompareAdditions(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message User {
+			string name = 1;
+		}
+		enum Status {
+			STATUS_UNKNOWN = 0;
+		}
+		service UserService {
+			rpc GetUser(User) returns (User);
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message User {
+			string name = 1;
+			map<string, string> labels = 2;
+			message Address {
+				string city = 1;
+			}
+		}
+		message Team {}
+		enum Status {
+			STATUS_UNKNOWN = 0;
+			STATUS_ACTIVE = 1;
+		}
+		service UserService {
+			rpc GetUser(User) returns (User);
+			rpc ListUsers(User) returns (User);
+		}
+		service TeamService {}
+	`)
+
+	// Without --verbose additions are not reported at all
+	if changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()}); len(changes) != 0 {
+		t.Errorf("Expected no changes without --verbose, got %v", changeMessages(changes))
+	}
+
+	rules := rulesWith(additionRules, nil)
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: rules})
+	expected := []string{
+		`Enum value "STATUS_ACTIVE" (number 1) was added to enum "Status"`,
+		`Message "Team" was added`,
+		`Service "TeamService" was added`,
+		`Field "labels" (number 2) was added to message "User"`,
+		`Message "User.Address" was added`,
+		`Method "ListUsers" was added to service "UserService"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected notes %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Severity != SeverityInfo {
+			t.Errorf("Expected an info note for %q, got %s", change.Message, change.Severity)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package protobreak
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// IgnoreDirective starts a comment suppressing rules for the element it is attached to,
+// e.g. // proto-break:ignore FIELD_SAME_TYPE
+const IgnoreDirective = "proto-break:ignore"
+
+// changeTarget returns the deepest element of the file along a change path, such as the message
+// of a removed field. Dotted path elements name nested messages, e.g. Outer.Inner. It returns nil
+// when not even the first element exists in the file.
+func changeTarget(file protoreflect.FileDescriptor, path []string) protoreflect.Descriptor {
+	var names []string
+	for _, element := range path {
+		names = append(names, strings.Split(element, ".")...)
+	}
+
+	var target protoreflect.Descriptor
+	for _, segment := range names {
+		name := protoreflect.Name(segment)
+		var next protoreflect.Descriptor
+		switch parent := target.(type) {
+		case nil:
+			if msg := file.Messages().ByName(name); msg != nil {
+				next = msg
+			} else if enum := file.Enums().ByName(name); enum != nil {
+				next = enum
+			} else if service := file.Services().ByName(name); service != nil {
+				next = service
+			}
+		case protoreflect.MessageDescriptor:
+			if field := parent.Fields().ByName(name); field != nil {
+				next = field
+			} else if oneof := parent.Oneofs().ByName(name); oneof != nil {
+				next = oneof
+			} else if msg := parent.Messages().ByName(name); msg != nil {
+				next = msg
+			} else if enum := parent.Enums().ByName(name); enum != nil {
+				next = enum
+			}
+		case protoreflect.EnumDescriptor:
+			if value := parent.Values().ByName(name); value != nil {
+				next = value
+			}
+		case protoreflect.ServiceDescriptor:
+			if method := parent.Methods().ByName(name); method != nil {
+				next = method
+			}
+		}
+		if next == nil {
+			break
+		}
+		target = next
+	}
+	return target
+}
+
+// locateChanges sets the line and column of every change to those of its element in the current file,
+// or of the closest element enclosing it when it was removed
+func locateChanges(file protoreflect.FileDescriptor, changes []BreakingChange) []BreakingChange {
+	for i, change := range changes {
+		target := changeTarget(file, change.Path)
+		if target == nil {
+			continue
+		}
+		if loc := file.SourceLocations().ByDescriptor(target); loc.Path != nil {
+			changes[i].Line, changes[i].Column = loc.StartLine+1, loc.StartColumn+1
+		}
+	}
+	return changes
+}
+
+// inlineIgnoredRules returns the rules suppressed by ignore directives in the leading comments of an element
+func inlineIgnoredRules(desc protoreflect.Descriptor) map[string]bool {
+	rules := make(map[string]bool)
+	comments := desc.ParentFile().SourceLocations().ByDescriptor(desc).LeadingComments
+	for _, line := range strings.Split(comments, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != IgnoreDirective {
+			continue
+		}
+		for _, id := range fields[1:] {
+			rules[id] = true
+		}
+	}
+	return rules
+}
+
+// filterInlineSuppressions drops the changes suppressed by ignore directives in the current file
+func filterInlineSuppressions(file protoreflect.FileDescriptor, changes []BreakingChange) []BreakingChange {
+	var kept []BreakingChange
+	for _, change := range changes {
+		if target := changeTarget(file, change.Path); target != nil && inlineIgnoredRules(target)[change.Rule] {
+			continue
+		}
+		kept = append(kept, change)
+	}
+	return kept
+}
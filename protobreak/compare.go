@@ -0,0 +1,1168 @@
+package protobreak
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// compareFields compares fields between previous and current messages
+func compareFields(prevMsg, currMsg protoreflect.MessageDescriptor, rules RuleSet) []BreakingChange {
+	msgName := string(prevMsg.Name())
+	msgPath := relativeName(prevMsg)
+	var breakingChanges []BreakingChange
+	prevFields := prevMsg.Fields()
+	currFields := currMsg.Fields()
+
+	// Check field map for quick lookup by number. Parsers reject duplicate numbers and fields
+	// inside extension ranges, but generated descriptors may still contain them, so the first
+	// field keeps the number.
+	currFieldsByNumber := make(map[protoreflect.FieldNumber]protoreflect.FieldDescriptor)
+	for i := 0; i < currFields.Len(); i++ {
+		field := currFields.Get(i)
+		if existing, ok := currFieldsByNumber[field.Number()]; ok {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldUniqueNumber, "Field %q reuses number %d of field %q in message %q",
+					field.Name(), field.Number(), existing.Name(), msgName).at(msgPath, string(field.Name())))
+			continue
+		}
+		currFieldsByNumber[field.Number()] = field
+
+		// Extensions using the number would collide with the field on the wire
+		ranges := currMsg.ExtensionRanges()
+		for j := 0; j < ranges.Len(); j++ {
+			if r := ranges.Get(j); field.Number() >= r[0] && field.Number() < r[1] {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldNoExtensionOverlap, "Field %q (number %d) in message %q overlaps extension range %d to %d",
+						field.Name(), field.Number(), msgName, r[0], r[1]-1).at(msgPath, string(field.Name())))
+			}
+		}
+	}
+
+	// Check each previous field
+	for i := 0; i < prevFields.Len(); i++ {
+		prevField := prevFields.Get(i)
+		fieldName := string(prevField.Name())
+		fieldNumber := prevField.Number()
+
+		// Check if field was removed by number
+		currField, ok := currFieldsByNumber[fieldNumber]
+		if !ok {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldNoDelete, "Field %q (number %d) was removed from message %q", fieldName, fieldNumber, msgName).at(msgPath, fieldName))
+
+			// Reusing the number or name later would misread old data
+			if !currMsg.ReservedRanges().Has(fieldNumber) || !currMsg.ReservedNames().Has(prevField.Name()) {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldRemovedNotReserved, "Removed field %q (number %d) is not reserved in message %q",
+						fieldName, fieldNumber, msgName).at(msgPath, fieldName))
+			}
+
+			// Normalization refactors often move fields into a new nested message
+			if nested := findMovedField(prevField, prevMsg, currMsg); nested != nil {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldMovedToNested, "Field %q (number %d) moved from message %q into nested message %q",
+						fieldName, fieldNumber, msgName, nested.Name()).at(msgPath, fieldName))
+			}
+			continue
+		}
+
+		// A field with another name and type is a different field reusing the number, which the
+		// rename and type checks below would understate
+		if prevField.Name() != currField.Name() && fieldTypeName(prevField) != fieldTypeName(currField) && rules.enabled(RuleFieldNoNumberReuse) {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldNoNumberReuse, "Field number %d reused: was %q (%s), now %q (%s) in message %q",
+					fieldNumber, fieldName, fieldTypeName(prevField), currField.Name(), fieldTypeName(currField), msgName).at(msgPath, fieldName))
+			continue
+		}
+
+		// Check if field was renamed
+		if prevField.Name() != currField.Name() {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSameName, "Field renamed from %q to %q in message %q", prevField.Name(), currField.Name(), msgName).at(msgPath, fieldName))
+
+			// Text format identifies fields by name, so the rename also breaks text format data
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSameTextName, "Field rename from %q to %q in message %q breaks text format data",
+					prevField.Name(), currField.Name(), msgName).at(msgPath, fieldName))
+		}
+
+		// Check JSON name changes, unless they only follow from a rename already reported above
+		renamedOnly := prevField.Name() != currField.Name() &&
+			prevField.JSONName() == defaultJSONName(prevField.Name()) && currField.JSONName() == defaultJSONName(currField.Name())
+		if prevField.JSONName() != currField.JSONName() && !renamedOnly {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSameJSONName, "Field %q JSON name changed from %q to %q in message %q",
+					fieldName, prevField.JSONName(), currField.JSONName(), msgName).at(msgPath, fieldName))
+		}
+
+		// Check conversions between maps and other fields, whose element types are not comparable.
+		// Conversions to and from repeated entry messages are reported below.
+		if prevField.IsMap() != currField.IsMap() && !isRepeatedEntryMapChange(prevField, currField) {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSameType, "Field %q changed from %s to %s in message %q",
+					fieldName, fieldShape(prevField), fieldShape(currField), msgName).at(msgPath, fieldName))
+			continue
+		}
+
+		// Check field type changes
+		prevKind := prevField.Kind()
+		currKind := currField.Kind()
+		if prevKind != currKind {
+			if prevKind == protoreflect.Int32Kind && currKind == protoreflect.EnumKind {
+				// int32 and enums share the varint encoding, so this is usually a deliberate migration
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldIntEnumMigration, "Field %q int↔enum migration from int32 to enum %s (%s) in message %q",
+						fieldName, currField.Enum().FullName(), enumValueSet(currField.Enum()), msgName).at(msgPath, fieldName))
+			} else if prevKind == protoreflect.EnumKind && currKind == protoreflect.Int32Kind {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldIntEnumMigration, "Field %q int↔enum migration from enum %s (%s) to int32 in message %q",
+						fieldName, prevField.Enum().FullName(), enumValueSet(prevField.Enum()), msgName).at(msgPath, fieldName))
+			} else if (prevKind == protoreflect.EnumKind && currKind == protoreflect.MessageKind) ||
+				(prevKind == protoreflect.MessageKind && currKind == protoreflect.EnumKind) {
+				// Replacing a type by one of the other kind keeps the field declaration looking the same
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldSameType, "Field %q changed from %s to %s in message %q (wire type changed from %s to %s)",
+						fieldName, fieldShape(prevField), fieldShape(currField), msgName, wireTypeName(fieldWireType(prevField)), wireTypeName(fieldWireType(currField))).at(msgPath, fieldName))
+			} else if isMessageEncodingChange(prevKind, currKind) {
+				// Editions resolve features.message_encoding = DELIMITED to the group encoding
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldSameMessageEncoding, "Field %q message encoding changed from %s to %s in message %q",
+						fieldName, messageEncoding(prevField), messageEncoding(currField), msgName).at(msgPath, fieldName))
+			} else if isSignednessChange(prevKind, currKind) {
+				// Negative values silently change meaning, which a generic type change warning understates
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldSameSignedness, "Field %q changed signedness (%s→%s) in message %q",
+						fieldName, prevKind, currKind, msgName).at(msgPath, fieldName))
+			} else if isMessageKind(prevKind) != isMessageKind(currKind) {
+				// Between scalars and messages, the kind alone does not tell which message is involved
+				prevShape, currShape := fieldShape(prevField), fieldShape(currField)
+				if isWireCompatibleFieldChange(prevField, currField) && isJSONEncodingChange(prevField, currField) && rules.enabled(RuleFieldSameJSONType) {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleFieldSameJSONType, "Field %q changed from %s to %s in message %q (JSON encoding changed from %s to %s)",
+							fieldName, prevShape, currShape, msgName, kindJSONEncoding(prevKind), kindJSONEncoding(currKind)).at(msgPath, fieldName))
+				} else if isWireCompatibleFieldChange(prevField, currField) {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleFieldWireCompatibleType, "Field %q changed from %s to %s in message %q (wire type %s preserved)",
+							fieldName, prevShape, currShape, msgName, wireTypeName(fieldWireType(currField))).at(msgPath, fieldName))
+				} else {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleFieldSameType, "Field %q changed from %s to %s in message %q", fieldName, prevShape, currShape, msgName).at(msgPath, fieldName))
+				}
+			} else if isZigZagChange(prevKind, currKind) {
+				// Both kinds are varints, so old data decodes without error into different numbers
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldSameZigZag, "Field %q changed between zigzag and plain varint encoding (%s→%s) in message %q; existing values decode to different numbers",
+						fieldName, prevKind, currKind, msgName).at(msgPath, fieldName))
+			} else if isWireCompatibleFieldChange(prevField, currField) && isJSONEncodingChange(prevField, currField) && rules.enabled(RuleFieldSameJSONType) {
+				// The binary encoding survives, but JSON clients send values the new type rejects
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldSameJSONType, "Field %q type changed from %s to %s in message %q (JSON encoding changed from %s to %s)",
+						fieldName, prevKind, currKind, msgName, kindJSONEncoding(prevKind), kindJSONEncoding(currKind)).at(msgPath, fieldName))
+			} else if isWireCompatibleFieldChange(prevField, currField) {
+				// Old data still decodes, but is interpreted as a different type
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldWireCompatibleType, "Field %q type changed from %s to %s in message %q (wire type %s preserved)",
+						fieldName, prevKind, currKind, msgName, wireTypeName(fieldWireType(currField))).at(msgPath, fieldName))
+			} else {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldSameType, "Field %q type changed from %s to %s in message %q", fieldName, prevKind, currKind, msgName).at(msgPath, fieldName))
+			}
+		}
+
+		// Check the named types of message and enum fields, even when only their package changed
+		if prevKind == currKind && !prevField.IsMap() && !currField.IsMap() {
+			prevType, currType := namedType(prevField), namedType(currField)
+			if prevType != nil && currType != nil && !sameNamedType(prevType, currType, prevMsg.ParentFile().Package(), currMsg.ParentFile().Package()) {
+				if prevType.Name() == currType.Name() {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleFieldSameType, "Field %q type %s moved from package %q to %q in message %q",
+							fieldName, currType.Name(), typePackage(prevType), typePackage(currType), msgName).at(msgPath, fieldName))
+				} else {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleFieldSameType, "Field %q type changed from %s to %s in message %q",
+							fieldName, prevType.FullName(), currType.FullName(), msgName).at(msgPath, fieldName))
+				}
+			}
+		}
+
+		// Check conversions between repeated entry messages and maps
+		if isRepeatedEntryMapChange(prevField, currField) {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldNoMapConversion, "Field %q changed from %s to %s in message %q; map entries have a fixed key 1 and value 2 layout, so add a new field and deprecate the old one instead",
+					fieldName, fieldTypeName(prevField), fieldTypeName(currField), msgName).at(msgPath, fieldName))
+		}
+
+		// Check map key type changes
+		if prevField.IsMap() && currField.IsMap() {
+			prevKeyKind := prevField.MapKey().Kind()
+			currKeyKind := currField.MapKey().Kind()
+			if isNarrowingKindChange(prevKeyKind, currKeyKind) {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleMapKeyNoNarrowing, "Map field %q key type narrowed from %s to %s in message %q",
+						fieldName, prevKeyKind, currKeyKind, msgName).at(msgPath, fieldName))
+			} else if prevKeyKind != currKeyKind {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldSameType, "Map field %q key type changed from %s to %s in message %q",
+						fieldName, prevKeyKind, currKeyKind, msgName).at(msgPath, fieldName))
+			}
+
+			// Check map value type changes
+			prevValueKind := prevField.MapValue().Kind()
+			currValueKind := currField.MapValue().Kind()
+			if prevValueKind != currValueKind {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldSameType, "Map field %q value type changed from %s to %s in message %q",
+						fieldName, prevValueKind, currValueKind, msgName).at(msgPath, fieldName))
+			} else if prevType, currType := namedType(prevField.MapValue()), namedType(currField.MapValue()); prevType != nil && currType != nil &&
+				!sameNamedType(prevType, currType, prevMsg.ParentFile().Package(), currMsg.ParentFile().Package()) {
+				// Message and enum values must also keep their named type, like other fields
+				if prevType.Name() == currType.Name() {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleFieldSameType, "Map field %q value type %s moved from package %q to %q in message %q",
+							fieldName, currType.Name(), typePackage(prevType), typePackage(currType), msgName).at(msgPath, fieldName))
+				} else {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleFieldSameType, "Map field %q value type changed from %s to %s in message %q",
+							fieldName, prevType.FullName(), currType.FullName(), msgName).at(msgPath, fieldName))
+				}
+			}
+
+			// Enum map values default to the zero value, so it must stay the same
+			prevValueEnum := prevField.MapValue().Enum()
+			currValueEnum := currField.MapValue().Enum()
+			if prevValueEnum != nil && currValueEnum != nil && prevValueEnum.Values().Len() > 0 && currValueEnum.Values().Len() > 0 {
+				prevZero := prevValueEnum.Values().Get(0).Name()
+				currZero := currValueEnum.Values().Get(0).Name()
+				if prevZero != currZero {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleMapEnumValueSameZeroValue, "Map field %q in message %q has enum values of %s whose zero value changed from %q to %q",
+							fieldName, msgName, currValueEnum.FullName(), prevZero, currZero).at(msgPath, fieldName))
+				}
+			}
+		}
+
+		// Check presence lost by migrating the file to another syntax, e.g. proto2 optional
+		// fields becoming proto3 implicit fields that cannot tell unset from the default
+		prevSyntax := prevField.ParentFile().Syntax()
+		currSyntax := currField.ParentFile().Syntax()
+		if prevSyntax != currSyntax && prevField.HasPresence() && !currField.HasPresence() && !currField.IsList() && !currField.IsMap() {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSamePresence, "Field %q lost explicit presence in message %q after the syntax change from %s to %s",
+					fieldName, msgName, prevSyntax, currSyntax).at(msgPath, fieldName))
+		}
+
+		// Check lazy parsing changes, which move parse errors of message fields to their first access
+		if prevLazy, currLazy := isLazy(prevField), isLazy(currField); prevLazy != currLazy {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSameLazy, "Field %q lazy option changed from %t to %t in message %q",
+					fieldName, prevLazy, currLazy, msgName).at(msgPath, fieldName))
+		}
+
+		// Check weak option changes, which change whether builds link the message type of the field
+		if prevWeak, currWeak := isWeak(prevField), isWeak(currField); prevWeak != currWeak {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSameWeak, "Field %q weak option changed from %t to %t in message %q",
+					fieldName, prevWeak, currWeak, msgName).at(msgPath, fieldName))
+		}
+
+		// Check the effective packed encoding of repeated scalars, which proto3 and editions enable by default
+		if prevField.IsList() && currField.IsList() && isPackable(prevField.Kind()) && isPackable(currField.Kind()) &&
+			prevField.IsPacked() != currField.IsPacked() {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSamePacked, "Field %q packed encoding changed from %v to %v in message %q",
+					fieldName, prevField.IsPacked(), currField.IsPacked(), msgName).at(msgPath, fieldName))
+		}
+
+		// Note new deprecations, which do not break anything but are worth knowing about
+		if !isDeprecated(prevField) && isDeprecated(currField) {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldDeprecated, "Field %q was deprecated in message %q", fieldName, msgName).at(msgPath, fieldName))
+		}
+
+		// Check cardinality changes
+		prevCardinality := prevField.Cardinality()
+		currCardinality := currField.Cardinality()
+		if prevCardinality != currCardinality {
+			// Changing from repeated to singular is breaking, while singular to repeated
+			// is safe since parsers collect a singular value as a one-element list
+			if prevCardinality == protoreflect.Repeated && currCardinality != protoreflect.Repeated {
+				if prevField.Message() != nil && currField.Message() != nil {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleFieldSameCardinality, "Field %q of type %s cardinality changed from repeated to singular in message %q",
+							fieldName, currField.Message().FullName(), msgName).at(msgPath, fieldName))
+				} else {
+					breakingChanges = append(breakingChanges,
+						newChange(RuleFieldSameCardinality, "Field %q cardinality changed from repeated to singular in message %q",
+							fieldName, msgName).at(msgPath, fieldName))
+				}
+			} else if currCardinality == protoreflect.Repeated {
+				// Safe on the wire, but worth a look since the generated types change
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldBecameRepeated, "Field %q became repeated in message %q", fieldName, msgName).at(msgPath, fieldName))
+			} else if currCardinality == protoreflect.Required {
+				// Messages written without the field fail to parse once it is required
+				breakingChanges = append(breakingChanges,
+					newChange(RuleFieldNoNewRequired, "Field %q changed from %s to required in message %q",
+						fieldName, prevCardinality, msgName).at(msgPath, fieldName))
+			}
+		}
+	}
+
+	// Check new required fields, which messages written by existing clients and code using generated builders lack
+	for i := 0; i < currFields.Len(); i++ {
+		field := currFields.Get(i)
+		if field.Cardinality() == protoreflect.Required && prevFields.ByNumber(field.Number()) == nil {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleRequiredFieldAdded, "New required field %q (number %d) added to message %q; generated builders "+
+					"fail to build the message without it, breaking existing callers", field.Name(), field.Number(), msgName).at(msgPath, string(field.Name())))
+		}
+	}
+
+	// Check removed reservations, which let future fields reuse the numbers of deleted ones
+	for _, r := range removedReservations(prevMsg.ReservedRanges(), currMsg) {
+		if r[1]-r[0] == 1 {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleReservedNoDelete, "Reservation of number %d in message %q was removed", r[0], msgName).at(msgPath))
+		} else {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleReservedNoDelete, "Reservation of numbers %d to %d in message %q was removed", r[0], r[1]-1, msgName).at(msgPath))
+		}
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// removedReservations returns the half-open ranges of numbers reserved by prevRanges that currMsg
+// neither reserves nor uses for a field. Fields taking a reserved number are reported as additions instead.
+func removedReservations(prevRanges protoreflect.FieldRanges, currMsg protoreflect.MessageDescriptor) [][2]protoreflect.FieldNumber {
+	currRanges := currMsg.ReservedRanges()
+	var removed [][2]protoreflect.FieldNumber
+	for i := 0; i < prevRanges.Len(); i++ {
+		r := prevRanges.Get(i)
+		for n := r[0]; n < r[1]; {
+			// Skip over the current reservation or field covering the number
+			if end, ok := reservedRangeEnd(currRanges, n); ok {
+				n = end
+				continue
+			}
+			if currMsg.Fields().ByNumber(n) != nil {
+				n++
+				continue
+			}
+
+			// The removed range ends at the next reservation or field, or at the end of the previous range
+			next := r[1]
+			for j := 0; j < currRanges.Len(); j++ {
+				if start := currRanges.Get(j)[0]; start > n && start < next {
+					next = start
+				}
+			}
+			for j := 0; j < currMsg.Fields().Len(); j++ {
+				if number := currMsg.Fields().Get(j).Number(); number > n && number < next {
+					next = number
+				}
+			}
+			removed = append(removed, [2]protoreflect.FieldNumber{n, next})
+			n = next
+		}
+	}
+	return removed
+}
+
+// reservedRangeEnd returns the exclusive end of the range in ranges containing n
+func reservedRangeEnd(ranges protoreflect.FieldRanges, n protoreflect.FieldNumber) (protoreflect.FieldNumber, bool) {
+	for i := 0; i < ranges.Len(); i++ {
+		if r := ranges.Get(i); n >= r[0] && n < r[1] {
+			return r[1], true
+		}
+	}
+	return 0, false
+}
+
+// defaultJSONName returns the JSON name of a field without a json_name option, e.g. displayName
+// for display_name. Parsers always fill in json_name, so it cannot tell whether it was declared.
+func defaultJSONName(name protoreflect.Name) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range string(name) {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// findMovedField returns the nested message added to currMsg that declares a field with the
+// same name and number as a field removed from prevMsg, or nil if there is none
+func findMovedField(prevField protoreflect.FieldDescriptor, prevMsg, currMsg protoreflect.MessageDescriptor) protoreflect.MessageDescriptor {
+	for i := 0; i < currMsg.Messages().Len(); i++ {
+		nested := currMsg.Messages().Get(i)
+		if nested.IsMapEntry() || prevMsg.Messages().ByName(nested.Name()) != nil {
+			continue
+		}
+		if field := nested.Fields().ByNumber(prevField.Number()); field != nil && field.Name() == prevField.Name() {
+			return nested
+		}
+	}
+	return nil
+}
+
+// enumValueSet formats the values of an enum as NAME=number pairs
+func enumValueSet(enum protoreflect.EnumDescriptor) string {
+	values := enum.Values()
+	pairs := make([]string, 0, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		value := values.Get(i)
+		pairs = append(pairs, fmt.Sprintf("%s=%d", value.Name(), value.Number()))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// compareOneofs compares oneofs between previous and current messages
+func compareOneofs(prevMsg, currMsg protoreflect.MessageDescriptor, rules RuleSet) []BreakingChange {
+	msgName := string(prevMsg.Name())
+	var breakingChanges []BreakingChange
+
+	// Check fields moving into, out of or between oneofs, which changes the generated code and
+	// clears the other members when set. Synthetic oneofs from proto3 optional fields are ignored,
+	// and wrapping fields in a new oneof is left to the opt-in ONEOF_NO_WRAP_EXISTING_FIELDS.
+	prevFields := prevMsg.Fields()
+	for i := 0; i < prevFields.Len(); i++ {
+		prevField := prevFields.Get(i)
+		currField := currMsg.Fields().ByNumber(prevField.Number())
+		if currField == nil {
+			continue
+		}
+
+		fieldName := string(prevField.Name())
+		prevOneof := realOneof(prevField)
+		currOneof := realOneof(currField)
+		switch {
+		case prevOneof == nil && currOneof != nil && prevMsg.Oneofs().ByName(currOneof.Name()) != nil:
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSameOneof, "Field %q moved into oneof %q in message %q",
+					fieldName, currOneof.Name(), msgName).at(relativeName(prevMsg), fieldName))
+		case prevOneof != nil && currOneof == nil:
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSameOneof, "Field %q moved out of oneof %q in message %q",
+					fieldName, prevOneof.Name(), msgName).at(relativeName(prevMsg), fieldName))
+		case prevOneof != nil && currOneof != nil && prevOneof.Name() != currOneof.Name():
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFieldSameOneof, "Field %q moved from oneof %q to oneof %q in message %q",
+					fieldName, prevOneof.Name(), currOneof.Name(), msgName).at(relativeName(prevMsg), fieldName))
+		}
+	}
+
+	// Check each new oneof for fields that already existed outside of it
+	currOneofs := currMsg.Oneofs()
+	for i := 0; i < currOneofs.Len(); i++ {
+		currOneof := currOneofs.Get(i)
+		if currOneof.IsSynthetic() || prevMsg.Oneofs().ByName(currOneof.Name()) != nil {
+			continue
+		}
+
+		oneofFields := currOneof.Fields()
+		for j := 0; j < oneofFields.Len(); j++ {
+			if prevMsg.Fields().ByNumber(oneofFields.Get(j).Number()) != nil {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleOneofNoWrapExistingFields, "Oneof %q was added to message %q",
+						currOneof.Name(), msgName).at(relativeName(currMsg), string(currOneof.Name())))
+				break
+			}
+		}
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// realOneof returns the oneof declared for a field, or nil for fields outside of a oneof and
+// proto3 optional fields, whose synthetic oneof is not declared
+func realOneof(field protoreflect.FieldDescriptor) protoreflect.OneofDescriptor {
+	if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+		return oneof
+	}
+	return nil
+}
+
+// collectNestedEnums collects all nested enums from message descriptors
+func collectNestedEnums(msgs protoreflect.MessageDescriptors, prefix string, output map[string]protoreflect.EnumDescriptor) {
+	for i := 0; i < msgs.Len(); i++ {
+		msg := msgs.Get(i)
+		msgPrefix := prefix + string(msg.Name()) + "."
+
+		// Collect enums in this message
+		enums := msg.Enums()
+		for j := 0; j < enums.Len(); j++ {
+			enum := enums.Get(j)
+			name := msgPrefix + string(enum.Name())
+			output[name] = enum
+		}
+
+		// Recursively collect enums in nested messages
+		collectNestedEnums(msg.Messages(), msgPrefix, output)
+	}
+}
+
+// relativeName returns the name of a descriptor relative to the package of its file, e.g. Outer.Inner
+func relativeName(desc protoreflect.Descriptor) string {
+	return strings.TrimPrefix(string(desc.FullName()), string(desc.ParentFile().Package())+".")
+}
+
+// collectNestedMessages collects messages and all of their nested messages, keyed by full name
+func collectNestedMessages(msgs protoreflect.MessageDescriptors, output map[protoreflect.FullName]protoreflect.MessageDescriptor) {
+	for i := 0; i < msgs.Len(); i++ {
+		msg := msgs.Get(i)
+		output[msg.FullName()] = msg
+
+		// Recursively collect nested messages
+		collectNestedMessages(msg.Messages(), output)
+	}
+}
+
+// inPackage returns the full name that a descriptor named in the previous package has in the current one
+func inPackage(name, prevPkg, currPkg protoreflect.FullName) protoreflect.FullName {
+	relative := strings.TrimPrefix(string(name), string(prevPkg)+".")
+	if currPkg == "" {
+		return protoreflect.FullName(relative)
+	}
+	return protoreflect.FullName(string(currPkg) + "." + relative)
+}
+
+// namedType returns the message or enum type of a field, or nil for scalar fields
+func namedType(field protoreflect.FieldDescriptor) protoreflect.Descriptor {
+	if field.Message() != nil {
+		return field.Message()
+	}
+	if field.Enum() != nil {
+		return field.Enum()
+	}
+	return nil
+}
+
+// typePackage returns the package declaring a type
+func typePackage(d protoreflect.Descriptor) protoreflect.FullName {
+	if file := d.ParentFile(); file != nil {
+		return file.Package()
+	}
+	return d.FullName().Parent()
+}
+
+// sameNamedType reports whether two versions of a field refer to the same type. Types of the
+// file's own package are looked up in the new package, since a package rename is reported once for the file.
+func sameNamedType(prev, curr protoreflect.Descriptor, prevPkg, currPkg protoreflect.FullName) bool {
+	name := prev.FullName()
+	if typePackage(prev) == prevPkg {
+		name = inPackage(name, prevPkg, currPkg)
+	}
+	return name == curr.FullName()
+}
+
+// findMovedEnum returns the name of the enum added to the current file whose values are most similar
+// to the removed enum, or "" when none shares at least half of its values. Enums keeping their
+// short name are preferred among equally similar ones.
+func findMovedEnum(removed protoreflect.EnumDescriptor, prevEnums, currEnums map[string]protoreflect.EnumDescriptor) string {
+	type enumValue struct {
+		name   protoreflect.Name
+		number protoreflect.EnumNumber
+	}
+	valueSet := func(enum protoreflect.EnumDescriptor) map[enumValue]bool {
+		set := make(map[enumValue]bool, enum.Values().Len())
+		for i := 0; i < enum.Values().Len(); i++ {
+			value := enum.Values().Get(i)
+			set[enumValue{value.Name(), value.Number()}] = true
+		}
+		return set
+	}
+	removedValues := valueSet(removed)
+
+	type match struct {
+		name     string
+		score    float64
+		sameName bool
+	}
+	var matches []match
+	for name, candidate := range currEnums {
+		if _, existed := prevEnums[name]; existed {
+			continue
+		}
+		candidateValues := valueSet(candidate)
+		shared := 0
+		for value := range candidateValues {
+			if removedValues[value] {
+				shared++
+			}
+		}
+		score := float64(shared) / float64(len(removedValues)+len(candidateValues)-shared)
+		if score >= 0.5 {
+			matches = append(matches, match{name: name, score: score, sameName: candidate.Name() == removed.Name()})
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		if matches[i].sameName != matches[j].sameName {
+			return matches[i].sameName
+		}
+		return matches[i].name < matches[j].name
+	})
+	return matches[0].name
+}
+
+// compareEnums compares enums between previous and current files
+func compareEnums(prevFile, currFile protoreflect.FileDescriptor, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	// Collect all enums (including nested ones)
+	prevEnumsByName := make(map[string]protoreflect.EnumDescriptor)
+	currEnumsByName := make(map[string]protoreflect.EnumDescriptor)
+
+	// Collect top-level enums
+	prevEnums := prevFile.Enums()
+	currEnums := currFile.Enums()
+
+	for i := 0; i < prevEnums.Len(); i++ {
+		enum := prevEnums.Get(i)
+		prevEnumsByName[string(enum.Name())] = enum
+	}
+
+	for i := 0; i < currEnums.Len(); i++ {
+		enum := currEnums.Get(i)
+		currEnumsByName[string(enum.Name())] = enum
+	}
+
+	// Collect nested enums
+	collectNestedEnums(prevFile.Messages(), "", prevEnumsByName)
+	collectNestedEnums(currFile.Messages(), "", currEnumsByName)
+
+	// Check each previous enum
+	for enumName, prevEnum := range prevEnumsByName {
+		// Check if enum was removed
+		currEnum, ok := currEnumsByName[enumName]
+		if !ok {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleEnumNoDelete, "Enum %q was removed", enumName).at(enumName))
+
+			// Moving an enum into or out of a message changes its full name, which looks like a removal
+			if movedTo := findMovedEnum(prevEnum, prevEnumsByName, currEnumsByName); movedTo != "" {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleEnumMoved, "Enum %q moved to %q", enumName, movedTo).at(enumName))
+			}
+			continue
+		}
+
+		// Check if the default value changed. The first declared value is the default, which proto3
+		// requires to be the zero value. Values are compared by number, so renaming the default is
+		// only reported as a rename.
+		prevDefault := prevEnum.Values().Get(0)
+		currDefault := currEnum.Values().Get(0)
+		if prevDefault.Number() != currDefault.Number() {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleEnumSameZeroValue, "Default value of enum %q changed from %q (number %d) to %q (number %d)",
+					enumName, prevDefault.Name(), prevDefault.Number(), currDefault.Name(), currDefault.Number()).at(enumName))
+		} else if prevZero := prevEnum.Values().ByNumber(0); prevZero != nil && currEnum.Values().ByNumber(0) == nil {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleEnumSameZeroValue, "Zero value %q of enum %q was removed", prevZero.Name(), enumName).at(enumName))
+		}
+
+		// Compare enum values
+		prevValues := prevEnum.Values()
+		currValuesByNumber := make(map[protoreflect.EnumNumber]protoreflect.EnumValueDescriptor)
+
+		// Build map of current enum values by number
+		currValues := currEnum.Values()
+		for j := 0; j < currValues.Len(); j++ {
+			value := currValues.Get(j)
+			currValuesByNumber[value.Number()] = value
+		}
+
+		// Check each previous enum value
+		for j := 0; j < prevValues.Len(); j++ {
+			prevValue := prevValues.Get(j)
+			valueName := string(prevValue.Name())
+			valueNumber := prevValue.Number()
+
+			// Check if enum value kept its name under another number. Old data then decodes to
+			// whatever value now has its number, so it is reported once here, not as removed or renamed.
+			if moved := currValues.ByName(prevValue.Name()); moved != nil && moved.Number() != valueNumber {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleEnumValueSameNumber, "Enum value %q number changed from %d to %d in enum %q",
+						valueName, valueNumber, moved.Number(), enumName).at(enumName, valueName))
+				continue
+			}
+
+			// Check if enum value was removed. A value renumbered onto its number does not make it a rename.
+			currValue, ok := currValuesByNumber[valueNumber]
+			if ok && currValue.Name() != prevValue.Name() && prevValues.ByName(currValue.Name()) != nil {
+				ok = false
+			}
+			if !ok {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleEnumValueNoDelete, "Enum value %q (number %d) was removed from enum %q",
+						valueName, valueNumber, enumName).at(enumName, valueName))
+				continue
+			}
+
+			// Check if enum value was renamed
+			if prevValue.Name() != currValue.Name() {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleEnumValueSameName, "Enum value renamed from %q to %q in enum %q",
+						prevValue.Name(), currValue.Name(), enumName).at(enumName, valueName))
+			}
+
+			// Check option changes, e.g. custom annotations describing the lifecycle of a value
+			for _, change := range diffOptions(prevValue, currValue) {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleEnumValueSameOptions, "Enum value %q in enum %q %s", currValue.Name(), enumName, change).at(enumName, valueName))
+			}
+		}
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// compareServices compares services between previous and current files
+func compareServices(prevFile, currFile protoreflect.FileDescriptor, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	// Get services from both files
+	prevServices := prevFile.Services()
+	currServices := currFile.Services()
+
+	// Create maps for quick lookup
+	currServicesByName := make(map[string]protoreflect.ServiceDescriptor)
+	for i := 0; i < currServices.Len(); i++ {
+		service := currServices.Get(i)
+		currServicesByName[string(service.Name())] = service
+	}
+
+	// Check each previous service
+	for i := 0; i < prevServices.Len(); i++ {
+		prevService := prevServices.Get(i)
+		serviceName := string(prevService.Name())
+
+		// Check if service was removed
+		currService, ok := currServicesByName[serviceName]
+		if !ok {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleServiceNoDelete, "Service %q was removed", serviceName).at(serviceName))
+			continue
+		}
+
+		// Compare methods
+		prevMethods := prevService.Methods()
+		currMethodsByName := make(map[string]protoreflect.MethodDescriptor)
+
+		// Build map of current methods by name
+		currMethods := currService.Methods()
+		for j := 0; j < currMethods.Len(); j++ {
+			method := currMethods.Get(j)
+			currMethodsByName[string(method.Name())] = method
+		}
+
+		// Check each previous method, counting those whose input or output type changed
+		changedSignatures := 0
+		for j := 0; j < prevMethods.Len(); j++ {
+			prevMethod := prevMethods.Get(j)
+			methodName := string(prevMethod.Name())
+
+			// Check if method was removed
+			currMethod, ok := currMethodsByName[methodName]
+			if !ok {
+				removal := newChange(RuleRPCNoDelete, "Method %q was removed from service %q", methodName, serviceName)
+				if endpoint, ok := httpBinding(prevMethod); ok {
+					// REST clients going through a transcoding gateway break too
+					removal = newChange(RuleRPCNoDelete, "Method %q was removed from service %q, deleting its REST endpoint %s",
+						methodName, serviceName, endpoint)
+				}
+				breakingChanges = append(breakingChanges, removal.at(serviceName, methodName))
+				continue
+			}
+
+			// Check input type changes
+			prevInput := prevMethod.Input().FullName()
+			currInput := currMethod.Input().FullName()
+			inputChanged := !sameNamedType(prevMethod.Input(), currMethod.Input(), prevFile.Package(), currFile.Package())
+			if inputChanged {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleRPCSameRequestType, "Method %q input type changed from %s to %s in service %q",
+						methodName, prevInput, currInput, serviceName).at(serviceName, methodName))
+			}
+
+			// Check output type changes
+			prevOutput := prevMethod.Output().FullName()
+			currOutput := currMethod.Output().FullName()
+			outputChanged := !sameNamedType(prevMethod.Output(), currMethod.Output(), prevFile.Package(), currFile.Package())
+			if outputChanged {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleRPCSameResponseType, "Method %q output type changed from %s to %s in service %q",
+						methodName, prevOutput, currOutput, serviceName).at(serviceName, methodName))
+			}
+			if inputChanged || outputChanged {
+				changedSignatures++
+			}
+
+			// Check streaming changes
+			if prevMethod.IsStreamingClient() != currMethod.IsStreamingClient() {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleRPCSameClientStreaming, "Method %q client streaming changed from %v to %v in service %q",
+						methodName, prevMethod.IsStreamingClient(), currMethod.IsStreamingClient(), serviceName).at(serviceName, methodName))
+			}
+
+			if prevMethod.IsStreamingServer() != currMethod.IsStreamingServer() {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleRPCSameServerStreaming, "Method %q server streaming changed from %v to %v in service %q",
+						methodName, prevMethod.IsStreamingServer(), currMethod.IsStreamingServer(), serviceName).at(serviceName, methodName))
+			}
+		}
+
+		// Several methods changing at once points to a rewrite rather than incremental changes
+		if changedSignatures >= 2 && changedSignatures*2 > prevMethods.Len() {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleServiceNoRewrite, "Service %q appears substantially rewritten (%d/%d methods changed signature)",
+					serviceName, changedSignatures, prevMethods.Len()).at(serviceName))
+		}
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// collectRPCUsages builds a reverse index from message names to the methods using them as input or output
+func collectRPCUsages(file protoreflect.FileDescriptor) map[protoreflect.FullName][]string {
+	usages := make(map[protoreflect.FullName][]string)
+	services := file.Services()
+	for i := 0; i < services.Len(); i++ {
+		service := services.Get(i)
+		methods := service.Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			methodName := relativeName(method)
+
+			if method.Output().FullName() == method.Input().FullName() {
+				usages[method.Input().FullName()] = append(usages[method.Input().FullName()],
+					fmt.Sprintf("input/output of method %q", methodName))
+				continue
+			}
+			usages[method.Input().FullName()] = append(usages[method.Input().FullName()], fmt.Sprintf("input of method %q", methodName))
+			usages[method.Output().FullName()] = append(usages[method.Output().FullName()], fmt.Sprintf("output of method %q", methodName))
+		}
+	}
+	return usages
+}
+
+// compareMessages compares messages between previous and current files
+func compareMessages(prevFile, currFile protoreflect.FileDescriptor, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	// Collect all messages (including nested ones)
+	prevMsgsByName := make(map[protoreflect.FullName]protoreflect.MessageDescriptor)
+	currMsgsByName := make(map[protoreflect.FullName]protoreflect.MessageDescriptor)
+	collectNestedMessages(prevFile.Messages(), prevMsgsByName)
+	collectNestedMessages(currFile.Messages(), currMsgsByName)
+
+	// Index which methods use each message as input or output
+	rpcUsages := collectRPCUsages(prevFile)
+
+	// Check each previous message. A package rename is reported once for the file, so messages
+	// are looked up under the same name in the new package.
+	for fullName, prevMsg := range prevMsgsByName {
+		msgName := relativeName(prevMsg)
+
+		// Check if message was removed
+		currMsg, ok := currMsgsByName[inPackage(fullName, prevFile.Package(), currFile.Package())]
+		if !ok {
+			// Synthetic map entries go away with their map field, which is reported instead
+			if prevMsg.IsMapEntry() {
+				continue
+			}
+			if usages, used := rpcUsages[prevMsg.FullName()]; used {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleRPCMessageNoDelete, "Message %q was removed and is used as %s", msgName, strings.Join(usages, ", ")).at(msgName))
+			} else {
+				breakingChanges = append(breakingChanges,
+					newChange(RuleMessageNoDelete, "Message %q was removed", msgName).at(msgName))
+			}
+			continue
+		}
+
+		breakingChanges = append(breakingChanges, compareMessage(msgName, prevMsg, currMsg, rules)...)
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// compareMessage compares two versions of a single message, without its nested messages
+func compareMessage(msgName string, prevMsg, currMsg protoreflect.MessageDescriptor, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	// Check the legacy MessageSet encoding, which replaces the encoding of the whole message
+	prevMessageSet := isMessageSet(prevMsg)
+	currMessageSet := isMessageSet(currMsg)
+	if prevMessageSet != currMessageSet {
+		breakingChanges = append(breakingChanges,
+			newChange(RuleMessageSameWireFormat, "Message %q changed message_set_wire_format from %t to %t",
+				msgName, prevMessageSet, currMessageSet).at(msgName))
+	}
+
+	// Check the map_entry option, which turns a message into the entries of a map field or back
+	prevMapEntry := hasMapEntryOption(prevMsg)
+	currMapEntry := hasMapEntryOption(currMsg)
+	if prevMapEntry != currMapEntry {
+		breakingChanges = append(breakingChanges,
+			newChange(RuleMessageSameMapEntry, "Message %q changed map_entry from %t to %t",
+				msgName, prevMapEntry, currMapEntry).at(msgName))
+	}
+
+	// Key and value changes of map entries are reported on their map field
+	if prevMsg.IsMapEntry() || currMsg.IsMapEntry() {
+		return breakingChanges
+	}
+
+	// Compare fields
+	fieldChanges := compareFields(prevMsg, currMsg, rules)
+	breakingChanges = append(breakingChanges, fieldChanges...)
+
+	// Compare oneofs
+	oneofChanges := compareOneofs(prevMsg, currMsg, rules)
+	breakingChanges = append(breakingChanges, oneofChanges...)
+
+	return breakingChanges
+}
+
+// softReservedRange is an inclusive range of field numbers documented as "do not use".
+// An empty Message applies the range to every message.
+type softReservedRange struct {
+	Message string
+	Start   int32
+	End     int32
+	Reason  string
+}
+
+// contains reports whether the range applies to a field number of the given message
+func (r softReservedRange) contains(msg protoreflect.FullName, number protoreflect.FieldNumber) bool {
+	if r.Message != "" && protoreflect.FullName(r.Message) != msg {
+		return false
+	}
+	return int32(number) >= r.Start && int32(number) <= r.End
+}
+
+// compareSoftReservedAdditions warns about fields added with a number inside a soft-reserved range.
+// Fields of newly added messages count as additions too.
+func compareSoftReservedAdditions(prevFile, currFile protoreflect.FileDescriptor, ranges []softReservedRange, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	prevMsgsByName := make(map[protoreflect.FullName]protoreflect.MessageDescriptor)
+	currMsgsByName := make(map[protoreflect.FullName]protoreflect.MessageDescriptor)
+	collectNestedMessages(prevFile.Messages(), prevMsgsByName)
+	collectNestedMessages(currFile.Messages(), currMsgsByName)
+
+	for fullName, currMsg := range currMsgsByName {
+		// The key and value of map entries are not fields added by the user
+		if currMsg.IsMapEntry() {
+			continue
+		}
+
+		msgName := relativeName(currMsg)
+		prevFields := make(map[protoreflect.FieldNumber]bool)
+		if prevMsg, ok := prevMsgsByName[inPackage(fullName, currFile.Package(), prevFile.Package())]; ok {
+			for i := 0; i < prevMsg.Fields().Len(); i++ {
+				prevFields[prevMsg.Fields().Get(i).Number()] = true
+			}
+		}
+
+		for i := 0; i < currMsg.Fields().Len(); i++ {
+			field := currMsg.Fields().Get(i)
+			if prevFields[field.Number()] {
+				continue
+			}
+			for _, r := range ranges {
+				if !r.contains(currMsg.FullName(), field.Number()) {
+					continue
+				}
+				message := fmt.Sprintf("Field %q (number %d) added to message %q uses soft-reserved range %d-%d",
+					field.Name(), field.Number(), msgName, r.Start, r.End)
+				if r.Reason != "" {
+					message += ": " + r.Reason
+				}
+				breakingChanges = append(breakingChanges, newChange(RuleFieldNoAddInSoftReserved, "%s", message).at(msgName, string(field.Name())))
+				break
+			}
+		}
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// compareFileSyntax compares the syntax declared by two versions of a file
+func compareFileSyntax(prevFile, currFile protoreflect.FileDescriptor, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	if prevFile.Syntax() != currFile.Syntax() {
+		breakingChanges = append(breakingChanges,
+			newChange(RuleFileSameSyntax, "Syntax changed from %s to %s in file %q", prevFile.Syntax(), currFile.Syntax(), currFile.Path()))
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// compareFilePackage compares the package declared by two versions of a file. A rename changes the
+// full name of every type in the file, but is reported once instead of as the removal of each type.
+func compareFilePackage(prevFile, currFile protoreflect.FileDescriptor, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	if prevFile.Package() != currFile.Package() {
+		breakingChanges = append(breakingChanges,
+			newChange(RuleFileSamePackage, "Package renamed from %q to %q", prevFile.Package(), currFile.Package()))
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// compareFileOptions reports every file option that was added, removed or changed, including options
+// the tool does not know about. severities overrides the severity of the rule by option name.
+func compareFileOptions(prevFile, currFile protoreflect.FileDescriptor, rules RuleSet, severities map[string]Severity) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	for _, change := range diffOptions(prevFile, currFile) {
+		filtered := rules.Filter([]BreakingChange{
+			newChange(RuleFileSameOptions, "File %s", change)})
+		if severity, ok := severities[change.Name]; ok && len(filtered) > 0 {
+			if severity == SeverityOff {
+				continue
+			}
+			filtered[0].Severity = severity
+		}
+		breakingChanges = append(breakingChanges, filtered...)
+	}
+
+	return breakingChanges
+}
+
+// fileEdition returns the edition of an editions file without its EDITION_ prefix, e.g. "2023".
+// Descriptors expose the edition the same way protodesc reads it, through an Edition method.
+func fileEdition(file protoreflect.FileDescriptor) string {
+	if imported, ok := file.(protoreflect.FileImport); ok {
+		file = imported.FileDescriptor
+	}
+	edition := descriptorpb.Edition_EDITION_UNKNOWN
+	if withEdition, ok := file.(interface{ Edition() int32 }); ok {
+		edition = descriptorpb.Edition(withEdition.Edition())
+	}
+	return strings.TrimPrefix(edition.String(), "EDITION_")
+}
+
+// compareFileEdition compares the edition declared by two versions of an editions file
+func compareFileEdition(prevFile, currFile protoreflect.FileDescriptor, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	if prevFile.Syntax() == protoreflect.Editions && currFile.Syntax() == protoreflect.Editions {
+		if prevEdition, currEdition := fileEdition(prevFile), fileEdition(currFile); prevEdition != currEdition {
+			breakingChanges = append(breakingChanges,
+				newChange(RuleFileSameEdition, "Edition changed from %s to %s in file %q; default features may have shifted",
+					prevEdition, currEdition, currFile.Path()))
+		}
+	}
+
+	return rules.Filter(breakingChanges)
+}
+
+// compareFiles runs every enabled comparison between two versions of a file.
+// Whole comparison passes are skipped when none of their rules are enabled.
+func compareFiles(prevFileDesc, currFileDesc protoreflect.FileDescriptor, opts options) []BreakingChange {
+	var allBreakingChanges []BreakingChange
+	rules := opts.rules
+
+	// Compare file-level declarations
+	if rules.categoryEnabled(categoryFile) {
+		fileChanges := compareFileSyntax(prevFileDesc, currFileDesc, rules)
+		allBreakingChanges = append(allBreakingChanges, fileChanges...)
+		packageChanges := compareFilePackage(prevFileDesc, currFileDesc, rules)
+		allBreakingChanges = append(allBreakingChanges, packageChanges...)
+		editionChanges := compareFileEdition(prevFileDesc, currFileDesc, rules)
+		allBreakingChanges = append(allBreakingChanges, editionChanges...)
+		optionChanges := compareFileOptions(prevFileDesc, currFileDesc, rules, opts.fileOptionSeverities)
+		allBreakingChanges = append(allBreakingChanges, optionChanges...)
+		if rules.enabled(RuleDeprecationAdded) {
+			deprecationChanges := compareDeprecations(prevFileDesc, currFileDesc, rules)
+			allBreakingChanges = append(allBreakingChanges, deprecationChanges...)
+		}
+	}
+
+	// Compare messages
+	if rules.categoryEnabled(categoryMessage) {
+		msgChanges := compareMessages(prevFileDesc, currFileDesc, rules)
+		allBreakingChanges = append(allBreakingChanges, msgChanges...)
+
+		if len(opts.softReserved) > 0 && rules.enabled(RuleFieldNoAddInSoftReserved) {
+			reservedChanges := compareSoftReservedAdditions(prevFileDesc, currFileDesc, opts.softReserved, rules)
+			allBreakingChanges = append(allBreakingChanges, reservedChanges...)
+		}
+	}
+
+	// Compare enums
+	if rules.categoryEnabled(categoryEnum) {
+		enumChanges := compareEnums(prevFileDesc, currFileDesc, rules)
+		allBreakingChanges = append(allBreakingChanges, enumChanges...)
+	}
+
+	// Compare services
+	if rules.categoryEnabled(categoryService) {
+		serviceChanges := compareServices(prevFileDesc, currFileDesc, rules)
+		allBreakingChanges = append(allBreakingChanges, serviceChanges...)
+	}
+
+	// List additive changes for review
+	if rules.enabled(additionRules...) {
+		additionChanges := compareAdditions(prevFileDesc, currFileDesc, rules)
+		allBreakingChanges = append(allBreakingChanges, additionChanges...)
+	}
+
+	// Types are compared by iterating over maps, so the order is fixed before reporting
+	sortChanges(allBreakingChanges)
+
+	// Drop the changes suppressed by comments in the current file, and locate the others in it
+	return locateChanges(currFileDesc, filterInlineSuppressions(currFileDesc, allBreakingChanges))
+}
+
+// sortChanges orders changes by the path of the changed element, then by rule. Changes with the
+// same path and rule keep their order.
+func sortChanges(changes []BreakingChange) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		if c := comparePaths(changes[i].Path, changes[j].Path); c != 0 {
+			return c < 0
+		}
+		return changes[i].Rule < changes[j].Rule
+	})
+}
+
+// comparePaths compares two element paths component by component, returning -1, 0 or 1.
+// A path sorts before the paths of the elements it contains.
+func comparePaths(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	default:
+		return 0
+	}
+}
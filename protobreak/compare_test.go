@@ -0,0 +1,3182 @@
+package protobreak
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestCompareFields tests the compareFields function
+func TestCompareFields(t *testing.T) {
+	// Create test cases for breaking changes
+	tests := []struct {
+		name           string
+		prevProto      string
+		currProto      string
+		expectedErrors []string
+	}{
+		{
+			name: "Field type change",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string name = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					int64 name = 1;
+				}
+			`,
+			expectedErrors: []string{
+				`Field "name" type changed from string to int64 in message "TestMessage"`,
+			},
+		},
+		{
+			name: "Field removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string name = 1;
+					int32 age = 2;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string name = 1;
+				}
+			`,
+			expectedErrors: []string{
+				`Field "age" (number 2) was removed from message "TestMessage"`,
+				`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+			},
+		},
+		{
+			name: "Field rename",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string name = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string full_name = 1;
+				}
+			`,
+			expectedErrors: []string{
+				`Field renamed from "name" to "full_name" in message "TestMessage"`,
+			},
+		},
+		{
+			name: "Cardinality change (repeated to singular)",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					repeated string names = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string names = 1;
+				}
+			`,
+			expectedErrors: []string{
+				`Field "names" cardinality changed from repeated to singular in message "TestMessage"`,
+			},
+		},
+		{
+			name: "Cardinality change of message field (repeated to singular)",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Address {
+					string city = 1;
+				}
+				message TestMessage {
+					repeated Address addresses = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Address {
+					string city = 1;
+				}
+				message TestMessage {
+					Address addresses = 1;
+				}
+			`,
+			expectedErrors: []string{
+				`Field "addresses" of type test.Address cardinality changed from repeated to singular in message "TestMessage"`,
+			},
+		},
+		{
+			name: "Cardinality change of message field (singular to repeated, non-breaking)",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Address {
+					string city = 1;
+				}
+				message TestMessage {
+					Address address = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Address {
+					string city = 1;
+				}
+				message TestMessage {
+					repeated Address address = 1;
+				}
+			`,
+			expectedErrors: []string{},
+		},
+		{
+			name: "Multiple breaking changes",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string name = 1;
+					int32 age = 2;
+					repeated string hobbies = 3;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					int64 name = 1;
+					string hobbies = 3;
+				}
+			`,
+			expectedErrors: []string{
+				`Field "name" type changed from string to int64 in message "TestMessage"`,
+				`Field "age" (number 2) was removed from message "TestMessage"`,
+				`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+				`Field "hobbies" cardinality changed from repeated to singular in message "TestMessage"`,
+			},
+		},
+		{
+			name: "Map key narrowing",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					map<int64, string> labels = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					map<int32, string> labels = 1;
+				}
+			`,
+			expectedErrors: []string{
+				`Map field "labels" key type narrowed from int64 to int32 in message "TestMessage"`,
+			},
+		},
+		{
+			name: "Map key type change",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					map<int32, string> labels = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					map<string, string> labels = 1;
+				}
+			`,
+			expectedErrors: []string{
+				`Map field "labels" key type changed from int32 to string in message "TestMessage"`,
+			},
+		},
+		{
+			name: "Map enum value loses its zero value",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					STATUS_UNSPECIFIED = 0;
+					STATUS_ACTIVE = 1;
+				}
+				message TestMessage {
+					map<string, Status> statuses = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					STATUS_ACTIVE = 0;
+				}
+				message TestMessage {
+					map<string, Status> statuses = 1;
+				}
+			`,
+			expectedErrors: []string{
+				`Map field "statuses" in message "TestMessage" has enum values of test.Status whose zero value changed from "STATUS_UNSPECIFIED" to "STATUS_ACTIVE"`,
+			},
+		},
+		// Non-breaking changes
+		{
+			name: "Adding new field (non-breaking)",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string name = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string name = 1;
+					int32 age = 2;
+				}
+			`,
+			expectedErrors: []string{},
+		},
+		{
+			name: "Changing field from singular to repeated (non-breaking)",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string name = 1;
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					repeated string name = 1;
+				}
+			`,
+			expectedErrors: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temporary proto files
+			prevFile, err := createTempProtoFile(tt.prevProto)
+			if err != nil {
+				t.Fatalf("Failed to create previous proto file: %v", err)
+			}
+			defer os.Remove(prevFile)
+
+			currFile, err := createTempProtoFile(tt.currProto)
+			if err != nil {
+				t.Fatalf("Failed to create current proto file: %v", err)
+			}
+			defer os.Remove(currFile)
+
+			// Parse proto files directly using protoparse
+			prevFileDesc, err := parseProtoFileToReflect(prevFile)
+			if err != nil {
+				t.Fatalf("Failed to parse previous proto file: %v", err)
+			}
+
+			currFileDesc, err := parseProtoFileToReflect(currFile)
+			if err != nil {
+				t.Fatalf("Failed to parse current proto file: %v", err)
+			}
+
+			// Get file descriptors
+			prevFile1 := prevFileDesc
+			currFile1 := currFileDesc
+
+			// Compare messages
+			var actualErrors []string
+			prevMsgs := prevFile1.Messages()
+			currMsgs := currFile1.Messages()
+
+			for i := 0; i < prevMsgs.Len(); i++ {
+				prevMsg := prevMsgs.Get(i)
+				msgName := string(prevMsg.Name())
+
+				// Find corresponding message in current file
+				var currMsg protoreflect.MessageDescriptor
+				for j := 0; j < currMsgs.Len(); j++ {
+					if string(currMsgs.Get(j).Name()) == msgName {
+						currMsg = currMsgs.Get(j)
+						break
+					}
+				}
+
+				if currMsg != nil {
+					errors := compareFields(prevMsg, currMsg, DefaultRuleSet())
+					actualErrors = append(actualErrors, changeMessages(errors)...)
+				}
+			}
+
+			// Sort errors for consistent comparison
+			sort.Strings(actualErrors)
+			sort.Strings(tt.expectedErrors)
+
+			// Compare results
+			if len(actualErrors) == 0 && len(tt.expectedErrors) == 0 {
+				// Both are empty, test passes
+			} else if !reflect.DeepEqual(actualErrors, tt.expectedErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.expectedErrors, actualErrors)
+			}
+		})
+	}
+}
+
+// TestCompareEnums tests the compareEnums function
+func TestCompareEnums(t *testing.T) {
+	tests := []struct {
+		name           string
+		prevProto      string
+		currProto      string
+		expectedErrors []string
+	}{
+		{
+			name: "Enum removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+					INACTIVE = 2;
+				}
+				message TestMessage {}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {}
+			`,
+			expectedErrors: []string{
+				`Enum "Status" was removed`,
+			},
+		},
+		{
+			name: "Enum value removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+					INACTIVE = 2;
+				}
+				message TestMessage {}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+				}
+				message TestMessage {}
+			`,
+			expectedErrors: []string{
+				`Enum value "INACTIVE" (number 2) was removed from enum "Status"`,
+			},
+		},
+		{
+			name: "Enum value rename",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+				}
+				message TestMessage {}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ENABLED = 1;
+				}
+				message TestMessage {}
+			`,
+			expectedErrors: []string{
+				`Enum value renamed from "ACTIVE" to "ENABLED" in enum "Status"`,
+			},
+		},
+		{
+			name: "Enum zero value replaced",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+				}
+				message TestMessage {}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					ACTIVE = 0;
+					INACTIVE = 1;
+				}
+				message TestMessage {}
+			`,
+			expectedErrors: []string{
+				`Enum value "UNKNOWN" (number 0) was removed from enum "Status"`,
+				`Enum value "ACTIVE" number changed from 1 to 0 in enum "Status"`,
+			},
+		},
+		{
+			name: "Enum zero value renamed",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+				}
+				message TestMessage {}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					STATUS_UNSPECIFIED = 0;
+					ACTIVE = 1;
+				}
+				message TestMessage {}
+			`,
+			expectedErrors: []string{
+				`Enum value renamed from "UNKNOWN" to "STATUS_UNSPECIFIED" in enum "Status"`,
+			},
+		},
+		{
+			name: "Proto2 enum default reordered",
+			prevProto: `
+				syntax = "proto2";
+				package test;
+				enum Status {
+					ACTIVE = 1;
+					INACTIVE = 2;
+				}
+				message TestMessage {}
+			`,
+			currProto: `
+				syntax = "proto2";
+				package test;
+				enum Status {
+					INACTIVE = 2;
+					ACTIVE = 1;
+				}
+				message TestMessage {}
+			`,
+			expectedErrors: []string{
+				`Default value of enum "Status" changed from "ACTIVE" (number 1) to "INACTIVE" (number 2)`,
+			},
+		},
+		{
+			name: "Proto2 enum zero value removed",
+			prevProto: `
+				syntax = "proto2";
+				package test;
+				enum Status {
+					ACTIVE = 1;
+					NONE = 0;
+				}
+				message TestMessage {}
+			`,
+			currProto: `
+				syntax = "proto2";
+				package test;
+				enum Status {
+					ACTIVE = 1;
+				}
+				message TestMessage {}
+			`,
+			expectedErrors: []string{
+				`Zero value "NONE" of enum "Status" was removed`,
+				`Enum value "NONE" (number 0) was removed from enum "Status"`,
+			},
+		},
+		// Non-breaking changes
+		{
+			name: "Adding new enum value (non-breaking)",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+				}
+				message TestMessage {}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+					INACTIVE = 2;
+				}
+				message TestMessage {}
+			`,
+			expectedErrors: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temporary proto files
+			prevFile, err := createTempProtoFile(tt.prevProto)
+			if err != nil {
+				t.Fatalf("Failed to create previous proto file: %v", err)
+			}
+			defer os.Remove(prevFile)
+
+			currFile, err := createTempProtoFile(tt.currProto)
+			if err != nil {
+				t.Fatalf("Failed to create current proto file: %v", err)
+			}
+			defer os.Remove(currFile)
+
+			// Parse proto files directly using protoparse
+			prevFileDesc, err := parseProtoFileToReflect(prevFile)
+			if err != nil {
+				t.Fatalf("Failed to parse previous proto file: %v", err)
+			}
+
+			currFileDesc, err := parseProtoFileToReflect(currFile)
+			if err != nil {
+				t.Fatalf("Failed to parse current proto file: %v", err)
+			}
+
+			// Get file descriptors
+			prevFile1 := prevFileDesc
+			currFile1 := currFileDesc
+
+			// Compare enums
+			actualErrors := changeMessages(compareEnums(prevFile1, currFile1, DefaultRuleSet()))
+
+			// Sort errors for consistent comparison
+			sort.Strings(actualErrors)
+			sort.Strings(tt.expectedErrors)
+
+			// Compare results
+			if len(actualErrors) == 0 && len(tt.expectedErrors) == 0 {
+				// Both are empty, test passes
+			} else if !reflect.DeepEqual(actualErrors, tt.expectedErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.expectedErrors, actualErrors)
+			}
+		})
+	}
+}
+
+// TestServiceRewrite tests that a service whose methods mostly changed signature is reported as rewritten
+func TestServiceRewrite(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message Request {}
+		message Response {}
+		service TestService {
+			rpc Get(Request) returns (Response);
+			rpc List(Request) returns (Response);
+			rpc Delete(Request) returns (Response);
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message Request {}
+		message Response {}
+		message Query {}
+		message Result {}
+		service TestService {
+			rpc Get(Query) returns (Result);
+			rpc List(Query) returns (Response);
+			rpc Delete(Request) returns (Response);
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	warnings := FilterSeverity(changes, SeverityWarning)
+	expected := []string{`Service "TestService" appears substantially rewritten (2/3 methods changed signature)`}
+	if actual := changeMessages(warnings); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, actual)
+	}
+	if errors := FilterSeverity(changes, SeverityError); len(errors) != 3 {
+		t.Errorf("Expected the 3 signature changes to be reported too, got %v", errors)
+	}
+}
+
+// TestEnumValueOptions tests that changes to enum value options are reported as warnings
+func TestEnumValueOptions(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package my;
+		import "google/protobuf/descriptor.proto";
+		extend google.protobuf.EnumValueOptions {
+			string replacement = 50001;
+		}
+		enum Status {
+			STATUS_UNSPECIFIED = 0;
+			STATUS_OLD = 1 [(my.replacement) = "STATUS_NEW"];
+			STATUS_NEW = 2;
+		}
+	`, `
+		syntax = "proto3";
+		package my;
+		import "google/protobuf/descriptor.proto";
+		extend google.protobuf.EnumValueOptions {
+			string replacement = 50001;
+		}
+		enum Status {
+			STATUS_UNSPECIFIED = 0;
+			STATUS_OLD = 1 [(my.replacement) = "STATUS_NEWER", deprecated = true];
+			STATUS_NEW = 2 [(my.replacement) = "STATUS_NEWER"];
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Enum value "STATUS_NEW" in enum "Status" added option (my.replacement) = "STATUS_NEWER"`,
+		`Enum value "STATUS_OLD" in enum "Status" changed option (my.replacement) from "STATUS_NEW" to "STATUS_NEWER"`,
+		`Enum value "STATUS_OLD" in enum "Status" added option deprecated = true`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule != RuleEnumValueSameOptions || change.Severity != SeverityWarning {
+			t.Errorf("Expected a %s warning, got %s (%s)", RuleEnumValueSameOptions, change.Rule, change.Severity)
+		}
+	}
+}
+
+// TestCompareServices tests the compareServices function
+func TestCompareServices(t *testing.T) {
+	tests := []struct {
+		name           string
+		prevProto      string
+		currProto      string
+		expectedErrors []string
+	}{
+		{
+			name: "Service removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Empty {}
+				service TestService {
+					rpc DoSomething(Empty) returns (Empty);
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Empty {}
+			`,
+			expectedErrors: []string{
+				`Service "TestService" was removed`,
+			},
+		},
+		{
+			name: "Method removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Empty {}
+				service TestService {
+					rpc DoSomething(Empty) returns (Empty);
+					rpc DoSomethingElse(Empty) returns (Empty);
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Empty {}
+				service TestService {
+					rpc DoSomething(Empty) returns (Empty);
+				}
+			`,
+			expectedErrors: []string{
+				`Method "DoSomethingElse" was removed from service "TestService"`,
+			},
+		},
+		{
+			name: "Method with an HTTP binding removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				import "google/protobuf/descriptor.proto";
+				message HttpRule {
+					string get = 2;
+					string delete = 5;
+				}
+				extend google.protobuf.MethodOptions {
+					HttpRule http = 72295728;
+				}
+				message Empty {}
+				service TestService {
+					rpc GetThing(Empty) returns (Empty) {
+						option (test.http) = { get: "/v1/things/{id}" };
+					}
+					rpc DeleteThing(Empty) returns (Empty) {
+						option (test.http).delete = "/v1/things/{id}";
+					}
+					rpc DoSomething(Empty) returns (Empty);
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				import "google/protobuf/descriptor.proto";
+				message HttpRule {
+					string get = 2;
+					string delete = 5;
+				}
+				extend google.protobuf.MethodOptions {
+					HttpRule http = 72295728;
+				}
+				message Empty {}
+				service TestService {
+					rpc DoSomething(Empty) returns (Empty);
+				}
+			`,
+			expectedErrors: []string{
+				`Method "GetThing" was removed from service "TestService", deleting its REST endpoint GET /v1/things/{id}`,
+				`Method "DeleteThing" was removed from service "TestService", deleting its REST endpoint DELETE /v1/things/{id}`,
+			},
+		},
+		{
+			name: "Method input type change",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Request1 {}
+				message Request2 {}
+				message Response {}
+				service TestService {
+					rpc DoSomething(Request1) returns (Response);
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Request1 {}
+				message Request2 {}
+				message Response {}
+				service TestService {
+					rpc DoSomething(Request2) returns (Response);
+				}
+			`,
+			expectedErrors: []string{
+				`Method "DoSomething" input type changed from test.Request1 to test.Request2 in service "TestService"`,
+			},
+		},
+		{
+			name: "Method output type change",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Request {}
+				message Response1 {}
+				message Response2 {}
+				service TestService {
+					rpc DoSomething(Request) returns (Response1);
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Request {}
+				message Response1 {}
+				message Response2 {}
+				service TestService {
+					rpc DoSomething(Request) returns (Response2);
+				}
+			`,
+			expectedErrors: []string{
+				`Method "DoSomething" output type changed from test.Response1 to test.Response2 in service "TestService"`,
+			},
+		},
+		{
+			name: "Method streaming change",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Request {}
+				message Response {}
+				service TestService {
+					rpc DoSomething(stream Request) returns (Response);
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Request {}
+				message Response {}
+				service TestService {
+					rpc DoSomething(Request) returns (Response);
+				}
+			`,
+			expectedErrors: []string{
+				`Method "DoSomething" client streaming changed from true to false in service "TestService"`,
+			},
+		},
+		// Non-breaking changes
+		{
+			name: "Adding new method (non-breaking)",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Empty {}
+				service TestService {
+					rpc DoSomething(Empty) returns (Empty);
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Empty {}
+				service TestService {
+					rpc DoSomething(Empty) returns (Empty);
+					rpc DoSomethingElse(Empty) returns (Empty);
+				}
+			`,
+			expectedErrors: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temporary proto files
+			prevFile, err := createTempProtoFile(tt.prevProto)
+			if err != nil {
+				t.Fatalf("Failed to create previous proto file: %v", err)
+			}
+			defer os.Remove(prevFile)
+
+			currFile, err := createTempProtoFile(tt.currProto)
+			if err != nil {
+				t.Fatalf("Failed to create current proto file: %v", err)
+			}
+			defer os.Remove(currFile)
+
+			// Parse proto files directly using protoparse
+			prevFileDesc, err := parseProtoFileToReflect(prevFile)
+			if err != nil {
+				t.Fatalf("Failed to parse previous proto file: %v", err)
+			}
+
+			currFileDesc, err := parseProtoFileToReflect(currFile)
+			if err != nil {
+				t.Fatalf("Failed to parse current proto file: %v", err)
+			}
+
+			// Get file descriptors
+			prevFile1 := prevFileDesc
+			currFile1 := currFileDesc
+
+			// Compare services
+			actualErrors := changeMessages(compareServices(prevFile1, currFile1, DefaultRuleSet()))
+
+			// Sort errors for consistent comparison
+			sort.Strings(actualErrors)
+			sort.Strings(tt.expectedErrors)
+
+			// Compare results
+			if len(actualErrors) == 0 && len(tt.expectedErrors) == 0 {
+				// Both are empty, test passes
+			} else if !reflect.DeepEqual(actualErrors, tt.expectedErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.expectedErrors, actualErrors)
+			}
+		})
+	}
+}
+
+// TestCompareMessages tests the compareMessages function
+func TestCompareMessages(t *testing.T) {
+	tests := []struct {
+		name           string
+		prevProto      string
+		currProto      string
+		expectedErrors []string
+	}{
+		{
+			name: "Message removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Message1 {}
+				message Message2 {}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Message1 {}
+			`,
+			expectedErrors: []string{
+				`Message "Message2" was removed`,
+			},
+		},
+		{
+			name: "Nested message removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Outer {
+					message Inner1 {}
+					message Inner2 {}
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Outer {
+					message Inner1 {}
+				}
+			`,
+			expectedErrors: []string{
+				`Message "Outer.Inner2" was removed`,
+			},
+		},
+		{
+			name: "RPC request message removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message GetRequest {}
+				message Response {}
+				service TestService {
+					rpc Get(GetRequest) returns (Response);
+					rpc Watch(GetRequest) returns (stream Response);
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Request {}
+				message Response {}
+				service TestService {
+					rpc Get(Request) returns (Response);
+					rpc Watch(Request) returns (stream Response);
+				}
+			`,
+			expectedErrors: []string{
+				`Message "GetRequest" was removed and is used as input of method "TestService.Get", input of method "TestService.Watch"`,
+			},
+		},
+		{
+			name: "RPC response and echo message removal",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Request {}
+				message Response {}
+				message Ping {}
+				service TestService {
+					rpc Get(Request) returns (Response);
+					rpc Echo(Ping) returns (Ping);
+				}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Request {}
+				service TestService {
+					rpc Get(Request) returns (Request);
+				}
+			`,
+			expectedErrors: []string{
+				`Message "Ping" was removed and is used as input/output of method "TestService.Echo"`,
+				`Message "Response" was removed and is used as output of method "TestService.Get"`,
+			},
+		},
+		// Non-breaking changes
+		{
+			name: "Adding new message (non-breaking)",
+			prevProto: `
+				syntax = "proto3";
+				package test;
+				message Message1 {}
+			`,
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message Message1 {}
+				message Message2 {}
+			`,
+			expectedErrors: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temporary proto files
+			prevFile, err := createTempProtoFile(tt.prevProto)
+			if err != nil {
+				t.Fatalf("Failed to create previous proto file: %v", err)
+			}
+			defer os.Remove(prevFile)
+
+			currFile, err := createTempProtoFile(tt.currProto)
+			if err != nil {
+				t.Fatalf("Failed to create current proto file: %v", err)
+			}
+			defer os.Remove(currFile)
+
+			// Parse proto files directly using protoparse
+			prevFileDesc, err := parseProtoFileToReflect(prevFile)
+			if err != nil {
+				t.Fatalf("Failed to parse previous proto file: %v", err)
+			}
+
+			currFileDesc, err := parseProtoFileToReflect(currFile)
+			if err != nil {
+				t.Fatalf("Failed to parse current proto file: %v", err)
+			}
+
+			// Get file descriptors
+			prevFile1 := prevFileDesc
+			currFile1 := currFileDesc
+
+			// Compare messages
+			actualErrors := changeMessages(compareMessages(prevFile1, currFile1, DefaultRuleSet()))
+
+			// Sort errors for consistent comparison
+			sort.Strings(actualErrors)
+			sort.Strings(tt.expectedErrors)
+
+			// Compare results
+			if len(actualErrors) == 0 && len(tt.expectedErrors) == 0 {
+				// Both are empty, test passes
+			} else if !reflect.DeepEqual(actualErrors, tt.expectedErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.expectedErrors, actualErrors)
+			}
+		})
+	}
+}
+
+// TestTextFormatStrict tests that field renames are classified as text format breaks when requested,
+// and suppressed by --ignore-field-renames while other changes still fire
+func TestTextFormatStrict(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			string age = 2;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string full_name = 1;
+			int64 age = 2;
+		}
+	`)
+
+	tests := []struct {
+		name           string
+		optIn          []string
+		skip           []string
+		expectedErrors []string
+	}{
+		{
+			name: "Default rules",
+			expectedErrors: []string{
+				`Field "age" type changed from string to int64 in message "TestMessage"`,
+				`Field renamed from "name" to "full_name" in message "TestMessage"`,
+			},
+		},
+		{
+			name:  "Text format strict",
+			optIn: []string{RuleFieldSameTextName},
+			expectedErrors: []string{
+				`Field "age" type changed from string to int64 in message "TestMessage"`,
+				`Field rename from "name" to "full_name" in message "TestMessage" breaks text format data`,
+				`Field renamed from "name" to "full_name" in message "TestMessage"`,
+			},
+		},
+		{
+			name:  "Ignore field renames",
+			optIn: []string{RuleFieldSameTextName},
+			skip:  []string{RuleFieldSameName, RuleFieldSameTextName},
+			expectedErrors: []string{
+				`Field "age" type changed from string to int64 in message "TestMessage"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := rulesWith(tt.optIn, tt.skip)
+
+			actualErrors := changeMessages(compareFiles(prevFileDesc, currFileDesc, options{rules: rules}))
+			sort.Strings(actualErrors)
+
+			if !reflect.DeepEqual(actualErrors, tt.expectedErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.expectedErrors, actualErrors)
+			}
+		})
+	}
+}
+
+// TestFieldWireType tests that type changes preserving the wire type are reported as warnings
+func TestFieldWireType(t *testing.T) {
+	tests := []struct {
+		name             string
+		prevField        string
+		currField        string
+		expectedRule     string
+		expectedSeverity Severity
+		expectedMessage  string
+	}{
+		{
+			name:             "String to bytes",
+			prevField:        "string value = 1;",
+			currField:        "bytes value = 1;",
+			expectedRule:     RuleFieldWireCompatibleType,
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "value" type changed from string to bytes in message "TestMessage" (wire type length-delimited preserved)`,
+		},
+		{
+			name:             "Bytes to string",
+			prevField:        "bytes value = 1;",
+			currField:        "string value = 1;",
+			expectedRule:     RuleFieldWireCompatibleType,
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "value" type changed from bytes to string in message "TestMessage" (wire type length-delimited preserved)`,
+		},
+		{
+			name:             "String to message",
+			prevField:        "string value = 1;",
+			currField:        "Other value = 1;",
+			expectedRule:     RuleFieldWireCompatibleType,
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "value" changed from scalar string to message test.Other in message "TestMessage" (wire type length-delimited preserved)`,
+		},
+		{
+			name:             "Message to bytes",
+			prevField:        "Other value = 1;",
+			currField:        "bytes value = 1;",
+			expectedRule:     RuleFieldWireCompatibleType,
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "value" changed from message test.Other to scalar bytes in message "TestMessage" (wire type length-delimited preserved)`,
+		},
+		{
+			name:             "Packed repeated scalar to repeated string",
+			prevField:        "repeated int32 value = 1;",
+			currField:        "repeated string value = 1;",
+			expectedRule:     RuleFieldWireCompatibleType,
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "value" type changed from int32 to string in message "TestMessage" (wire type length-delimited preserved)`,
+		},
+		{
+			name:             "Unpacked repeated scalar to repeated string",
+			prevField:        "repeated int32 value = 1 [packed = false];",
+			currField:        "repeated string value = 1;",
+			expectedRule:     RuleFieldSameType,
+			expectedSeverity: SeverityError,
+			expectedMessage:  `Field "value" type changed from int32 to string in message "TestMessage"`,
+		},
+		{
+			name:             "Int32 to uint64",
+			prevField:        "int32 value = 1;",
+			currField:        "uint64 value = 1;",
+			expectedRule:     RuleFieldWireCompatibleType,
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "value" type changed from int32 to uint64 in message "TestMessage" (wire type varint preserved)`,
+		},
+		{
+			name:             "Sint32 to sint64",
+			prevField:        "sint32 value = 1;",
+			currField:        "sint64 value = 1;",
+			expectedRule:     RuleFieldWireCompatibleType,
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "value" type changed from sint32 to sint64 in message "TestMessage" (wire type varint preserved)`,
+		},
+		{
+			name:             "Sfixed32 to fixed32",
+			prevField:        "sfixed32 value = 1;",
+			currField:        "fixed32 value = 1;",
+			expectedRule:     RuleFieldWireCompatibleType,
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "value" type changed from sfixed32 to fixed32 in message "TestMessage" (wire type fixed32 preserved)`,
+		},
+		{
+			name:             "Int32 to sint32",
+			prevField:        "int32 value = 1;",
+			currField:        "sint32 value = 1;",
+			expectedRule:     RuleFieldSameZigZag,
+			expectedSeverity: SeverityError,
+			expectedMessage:  `Field "value" changed between zigzag and plain varint encoding (int32→sint32) in message "TestMessage"; existing values decode to different numbers`,
+		},
+		{
+			name:             "Packed sint64 to packed int64",
+			prevField:        "repeated sint64 value = 1;",
+			currField:        "repeated int64 value = 1;",
+			expectedRule:     RuleFieldSameZigZag,
+			expectedSeverity: SeverityError,
+			expectedMessage:  `Field "value" changed between zigzag and plain varint encoding (sint64→int64) in message "TestMessage"; existing values decode to different numbers`,
+		},
+		{
+			name:             "String to scalar",
+			prevField:        "string value = 1;",
+			currField:        "int32 value = 1;",
+			expectedRule:     RuleFieldSameType,
+			expectedSeverity: SeverityError,
+			expectedMessage:  `Field "value" type changed from string to int32 in message "TestMessage"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, `
+				syntax = "proto3";
+				package test;
+				message Other {}
+				message TestMessage {
+					`+tt.prevField+`
+				}
+			`, `
+				syntax = "proto3";
+				package test;
+				message Other {}
+				message TestMessage {
+					`+tt.currField+`
+				}
+			`)
+
+			changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+			if len(changes) != 1 {
+				t.Fatalf("Expected 1 change, got %v", changes)
+			}
+			if changes[0].Rule != tt.expectedRule || changes[0].Severity != tt.expectedSeverity {
+				t.Errorf("Expected %s (%s), got %s (%s)", tt.expectedRule, tt.expectedSeverity, changes[0].Rule, changes[0].Severity)
+			}
+			if changes[0].Message != tt.expectedMessage {
+				t.Errorf("Expected message %q, got %q", tt.expectedMessage, changes[0].Message)
+			}
+		})
+	}
+}
+
+// TestEnumReplacedByMessage tests replacing a field's enum type by a message of the same name and back
+func TestEnumReplacedByMessage(t *testing.T) {
+	enumProto := `
+		syntax = "proto3";
+		package test;
+		enum Color {
+			COLOR_UNSPECIFIED = 0;
+		}
+		message TestMessage {
+			Color color = 1;
+		}
+	`
+	messageProto := `
+		syntax = "proto3";
+		package test;
+		message Color {
+			string name = 1;
+		}
+		message TestMessage {
+			Color color = 1;
+		}
+	`
+
+	prevFileDesc, currFileDesc := parseTestProtos(t, enumProto, messageProto)
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Enum "Color" was removed`,
+		`Field "color" changed from enum test.Color to message test.Color in message "TestMessage" (wire type changed from varint to length-delimited)`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(changes) > 1 && changes[1].Rule != RuleFieldSameType {
+		t.Errorf("Expected %s, got %s", RuleFieldSameType, changes[1].Rule)
+	}
+
+	prevFileDesc, currFileDesc = parseTestProtos(t, messageProto, enumProto)
+	changes = compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected = []string{
+		`Message "Color" was removed`,
+		`Field "color" changed from message test.Color to enum test.Color in message "TestMessage" (wire type changed from length-delimited to varint)`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+}
+
+// duplicateNumberMessage is a message whose last field reuses the number of its first field
+type duplicateNumberMessage struct {
+	protoreflect.MessageDescriptor
+}
+
+// Fields returns the fields of the message with the duplicated number
+func (m duplicateNumberMessage) Fields() protoreflect.FieldDescriptors {
+	return duplicateNumberFields{m.MessageDescriptor.Fields()}
+}
+
+// duplicateNumberFields lists fields where the last one reuses the number of the first one
+type duplicateNumberFields struct {
+	protoreflect.FieldDescriptors
+}
+
+// Get returns the field at index i
+func (f duplicateNumberFields) Get(i int) protoreflect.FieldDescriptor {
+	field := f.FieldDescriptors.Get(i)
+	if i == f.Len()-1 {
+		return renumberedField{field, f.FieldDescriptors.Get(0).Number()}
+	}
+	return field
+}
+
+// renumberedField is a field declared with another number
+type renumberedField struct {
+	protoreflect.FieldDescriptor
+	number protoreflect.FieldNumber
+}
+
+// Number returns the replaced field number
+func (f renumberedField) Number() protoreflect.FieldNumber {
+	return f.number
+}
+
+// extensionRangeMessage is a message declaring an extension range that the parser would reject
+type extensionRangeMessage struct {
+	protoreflect.MessageDescriptor
+	ranges extensionRanges
+}
+
+// ExtensionRanges returns the injected extension ranges
+func (m extensionRangeMessage) ExtensionRanges() protoreflect.FieldRanges {
+	return m.ranges
+}
+
+// extensionRanges lists [start, end) field number ranges
+type extensionRanges struct {
+	protoreflect.FieldRanges
+	ranges [][2]protoreflect.FieldNumber
+}
+
+// Len returns the number of ranges
+func (r extensionRanges) Len() int {
+	return len(r.ranges)
+}
+
+// Get returns the range at index i
+func (r extensionRanges) Get(i int) [2]protoreflect.FieldNumber {
+	return r.ranges[i]
+}
+
+// TestFieldInExtensionRange tests that fields using a number of an extension range are reported
+func TestFieldInExtensionRange(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto2";
+		package test;
+		message TestMessage {
+			optional string name = 1;
+		}
+	`, `
+		syntax = "proto2";
+		package test;
+		message TestMessage {
+			optional string name = 1;
+			optional string source = 150;
+		}
+	`)
+
+	// Parsers reject fields inside extension ranges, so the range is injected into the parsed message
+	prevMsg := prevFileDesc.Messages().Get(0)
+	currMsg := extensionRangeMessage{currFileDesc.Messages().Get(0),
+		extensionRanges{ranges: [][2]protoreflect.FieldNumber{{100, 201}}}}
+	changes := compareFields(prevMsg, currMsg, DefaultRuleSet())
+
+	expected := []string{`Field "source" (number 150) in message "TestMessage" overlaps extension range 100 to 200`}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+}
+
+// TestFieldMovedToNestedMessage tests that a field moved into a new nested message is connected to its removal
+func TestFieldMovedToNestedMessage(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message Address {
+			string street = 1;
+			string zip = 7;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message Address {
+			message Geo {
+				string zip = 7;
+			}
+			string street = 1;
+			Geo geo = 8;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Field "zip" (number 7) moved from message "Address" into nested message "Geo"`,
+		`Field "zip" (number 7) was removed from message "Address"`,
+		`Removed field "zip" (number 7) is not reserved in message "Address"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(changes) == 2 && (changes[1].Rule != RuleFieldMovedToNested || changes[1].Severity != SeverityWarning) {
+		t.Errorf("Expected a %s warning, got %s (%s)", RuleFieldMovedToNested, changes[1].Rule, changes[1].Severity)
+	}
+}
+
+// TestFieldLazy tests that toggling the lazy option of a message field is reported as a warning
+func TestFieldLazy(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto2";
+		package test;
+		message Other {}
+		message TestMessage {
+			optional Other other = 1;
+			optional Other eager = 2 [lazy = true];
+		}
+	`, `
+		syntax = "proto2";
+		package test;
+		message Other {}
+		message TestMessage {
+			optional Other other = 1 [lazy = true];
+			optional Other eager = 2;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Field "eager" lazy option changed from true to false in message "TestMessage"`,
+		`Field "other" lazy option changed from false to true in message "TestMessage"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule != RuleFieldSameLazy || change.Severity != SeverityWarning {
+			t.Errorf("Expected a %s warning, got %s (%s)", RuleFieldSameLazy, change.Rule, change.Severity)
+		}
+	}
+}
+
+// TestFieldWeak tests that toggling the weak option of a proto2 message field is reported as a warning
+func TestFieldWeak(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto2";
+		package test;
+		message Other {}
+		message TestMessage {
+			optional Other other = 1;
+			optional Other linked = 2 [weak = true];
+		}
+	`, `
+		syntax = "proto2";
+		package test;
+		message Other {}
+		message TestMessage {
+			optional Other other = 1 [weak = true];
+			optional Other linked = 2;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Field "linked" weak option changed from true to false in message "TestMessage"`,
+		`Field "other" weak option changed from false to true in message "TestMessage"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule != RuleFieldSameWeak || change.Severity != SeverityWarning {
+			t.Errorf("Expected a %s warning, got %s (%s)", RuleFieldSameWeak, change.Rule, change.Severity)
+		}
+	}
+}
+
+// TestFieldPacked tests that changes of the effective packed encoding of repeated scalars are warnings
+func TestFieldPacked(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     string
+		curr     string
+		expected []string
+	}{
+		{
+			name:     "proto3 default to unpacked",
+			prev:     "syntax = \"proto3\"; message TestMessage { repeated int32 ids = 1; repeated string tags = 2; }",
+			curr:     "syntax = \"proto3\"; message TestMessage { repeated int32 ids = 1 [packed = false]; repeated string tags = 2; }",
+			expected: []string{`Field "ids" packed encoding changed from true to false in message "TestMessage"`},
+		},
+		{
+			name:     "proto2 unpacked to packed",
+			prev:     "syntax = \"proto2\"; message TestMessage { repeated double values = 1; }",
+			curr:     "syntax = \"proto2\"; message TestMessage { repeated double values = 1 [packed = true]; }",
+			expected: []string{`Field "values" packed encoding changed from false to true in message "TestMessage"`},
+		},
+		{
+			name:     "explicit proto3 default",
+			prev:     "syntax = \"proto3\"; message TestMessage { repeated int32 ids = 1; }",
+			curr:     "syntax = \"proto3\"; message TestMessage { repeated int32 ids = 1 [packed = true]; }",
+			expected: []string{},
+		},
+		{
+			name:     "proto2 to proto3 default",
+			prev:     "syntax = \"proto2\"; message TestMessage { repeated int64 ids = 1; }",
+			curr:     "syntax = \"proto3\"; message TestMessage { repeated int64 ids = 1; }",
+			expected: []string{`Field "ids" packed encoding changed from false to true in message "TestMessage"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, tt.prev, tt.curr)
+
+			var changes []BreakingChange
+			for _, change := range compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()}) {
+				if change.Rule == RuleFieldSamePacked {
+					changes = append(changes, change)
+				}
+			}
+			if actual := changeMessages(changes); !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Expected warnings %v, got %v", tt.expected, actual)
+			}
+			for _, change := range changes {
+				if change.Severity != SeverityWarning {
+					t.Errorf("Expected a warning, got %s", change.Severity)
+				}
+			}
+		})
+	}
+}
+
+// TestMessageSetWireFormat tests that toggling message_set_wire_format is breaking
+func TestMessageSetWireFormat(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto2";
+		package test;
+		message TestMessage {
+			extensions 4 to max;
+		}
+	`, `
+		syntax = "proto2";
+		package test;
+		message TestMessage {
+			option message_set_wire_format = true;
+			extensions 4 to max;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{`Message "TestMessage" changed message_set_wire_format from false to true`}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+
+	changes = compareFiles(currFileDesc, prevFileDesc, options{rules: DefaultRuleSet()})
+	expected = []string{`Message "TestMessage" changed message_set_wire_format from true to false`}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(changes) == 1 && changes[0].Severity != SeverityError {
+		t.Errorf("Expected an error, got %s", changes[0].Severity)
+	}
+}
+
+// TestDuplicateFieldNumber tests that fields sharing a number in the current version are reported
+func TestDuplicateFieldNumber(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+			string nickname = 3;
+		}
+	`)
+
+	// Parsers reject duplicate numbers, so the duplicate is injected into the parsed message
+	prevMsg := prevFileDesc.Messages().Get(0)
+	currMsg := duplicateNumberMessage{currFileDesc.Messages().Get(0)}
+	changes := compareFields(prevMsg, currMsg, DefaultRuleSet())
+
+	expected := []string{`Field "nickname" reuses number 1 of field "name" in message "TestMessage"`}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(changes) == 1 && (changes[0].Rule != RuleFieldUniqueNumber || changes[0].Severity != SeverityError) {
+		t.Errorf("Expected a %s error, got %s (%s)", RuleFieldUniqueNumber, changes[0].Rule, changes[0].Severity)
+	}
+}
+
+// TestFixedFamilyWireType tests that fixed-width types only share a wire type within the same width
+func TestFixedFamilyWireType(t *testing.T) {
+	tests := []struct {
+		prevType     string
+		currType     string
+		expectedRule string
+	}{
+		{prevType: "fixed32", currType: "sfixed32", expectedRule: RuleFieldWireCompatibleType},
+		{prevType: "sfixed32", currType: "fixed32", expectedRule: RuleFieldWireCompatibleType},
+		{prevType: "fixed64", currType: "sfixed64", expectedRule: RuleFieldWireCompatibleType},
+		{prevType: "sfixed64", currType: "fixed64", expectedRule: RuleFieldWireCompatibleType},
+		{prevType: "fixed32", currType: "float", expectedRule: RuleFieldWireCompatibleType},
+		{prevType: "fixed64", currType: "double", expectedRule: RuleFieldWireCompatibleType},
+		{prevType: "fixed32", currType: "fixed64", expectedRule: RuleFieldSameType},
+		{prevType: "fixed64", currType: "fixed32", expectedRule: RuleFieldSameType},
+		{prevType: "sfixed32", currType: "sfixed64", expectedRule: RuleFieldSameType},
+		{prevType: "fixed32", currType: "sfixed64", expectedRule: RuleFieldSameType},
+		{prevType: "fixed32", currType: "uint32", expectedRule: RuleFieldSameType},
+		{prevType: "sfixed64", currType: "int64", expectedRule: RuleFieldSameType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prevType+" to "+tt.currType, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					`+tt.prevType+` value = 1;
+				}
+			`, `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					`+tt.currType+` value = 1;
+				}
+			`)
+
+			changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+			if len(changes) != 1 {
+				t.Fatalf("Expected 1 change, got %v", changes)
+			}
+			if changes[0].Rule != tt.expectedRule {
+				t.Errorf("Expected %s, got %s", tt.expectedRule, changes[0].Rule)
+			}
+		})
+	}
+}
+
+// TestIntEnumMigration tests that int32 and enum migrations are classified separately
+func TestIntEnumMigration(t *testing.T) {
+	tests := []struct {
+		name             string
+		prevField        string
+		currField        string
+		severities       map[string]Severity
+		expectedSeverity Severity
+		expectedMessage  string
+	}{
+		{
+			name:             "int32 to enum",
+			prevField:        "int32 status = 1;",
+			currField:        "Status status = 1;",
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "status" int↔enum migration from int32 to enum test.Status (UNKNOWN=0, ACTIVE=1) in message "TestMessage"`,
+		},
+		{
+			name:             "enum to int32",
+			prevField:        "Status status = 1;",
+			currField:        "int32 status = 1;",
+			expectedSeverity: SeverityWarning,
+			expectedMessage:  `Field "status" int↔enum migration from enum test.Status (UNKNOWN=0, ACTIVE=1) to int32 in message "TestMessage"`,
+		},
+		{
+			name:             "Configured as breaking",
+			prevField:        "int32 status = 1;",
+			currField:        "Status status = 1;",
+			severities:       map[string]Severity{RuleFieldIntEnumMigration: SeverityError},
+			expectedSeverity: SeverityError,
+			expectedMessage:  `Field "status" int↔enum migration from int32 to enum test.Status (UNKNOWN=0, ACTIVE=1) in message "TestMessage"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+				}
+				message TestMessage {
+					`+tt.prevField+`
+				}
+			`, `
+				syntax = "proto3";
+				package test;
+				enum Status {
+					UNKNOWN = 0;
+					ACTIVE = 1;
+				}
+				message TestMessage {
+					`+tt.currField+`
+				}
+			`)
+
+			rules := newOptions([]Option{WithSeverities(tt.severities)}).rules
+
+			changes := compareFiles(prevFileDesc, currFileDesc, options{rules: rules})
+			if len(changes) != 1 {
+				t.Fatalf("Expected 1 change, got %v", changes)
+			}
+			if changes[0].Rule != RuleFieldIntEnumMigration || changes[0].Severity != tt.expectedSeverity {
+				t.Errorf("Expected %s (%s), got %s (%s)", RuleFieldIntEnumMigration, tt.expectedSeverity, changes[0].Rule, changes[0].Severity)
+			}
+			if changes[0].Message != tt.expectedMessage {
+				t.Errorf("Expected message %q, got %q", tt.expectedMessage, changes[0].Message)
+			}
+		})
+	}
+}
+
+// TestStrictOneof tests that wrapping existing fields in a new oneof is reported when requested
+func TestStrictOneof(t *testing.T) {
+	prevProto := `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string email = 1;
+			string phone = 2;
+			optional string name = 3;
+		}
+	`
+
+	tests := []struct {
+		name           string
+		currProto      string
+		optIn          []string
+		expectedErrors []string
+	}{
+		{
+			name: "Existing fields wrapped in a new oneof",
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					oneof contact {
+						string email = 1;
+						string phone = 2;
+					}
+					optional string name = 3;
+				}
+			`,
+			optIn:          []string{RuleOneofNoWrapExistingFields},
+			expectedErrors: []string{`Oneof "contact" was added to message "TestMessage"`},
+		},
+		{
+			name: "Existing fields wrapped without --strict-oneof",
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					oneof contact {
+						string email = 1;
+						string phone = 2;
+					}
+					optional string name = 3;
+				}
+			`,
+			expectedErrors: []string{},
+		},
+		{
+			name: "New fields in a new oneof (non-breaking)",
+			currProto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string email = 1;
+					string phone = 2;
+					optional string name = 3;
+					oneof address {
+						string street = 4;
+						string po_box = 5;
+					}
+				}
+			`,
+			optIn:          []string{RuleOneofNoWrapExistingFields},
+			expectedErrors: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, prevProto, tt.currProto)
+
+			rules := rulesWith(tt.optIn, nil)
+
+			actualErrors := changeMessages(compareFiles(prevFileDesc, currFileDesc, options{rules: rules}))
+			if !reflect.DeepEqual(actualErrors, tt.expectedErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.expectedErrors, actualErrors)
+			}
+		})
+	}
+}
+
+// TestSoftReservedAdditions tests warnings for new fields in soft-reserved ranges
+func TestSoftReservedAdditions(t *testing.T) {
+	prevProto := `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			string legacy = 100;
+		}
+	`
+	currProto := `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			string legacy = 100;
+			string email = 2;
+			string nickname = 101;
+		}
+	`
+
+	tests := []struct {
+		name           string
+		ranges         []softReservedRange
+		optIn          []string
+		expectedErrors []string
+	}{
+		{
+			name:           "Addition in a soft-reserved range",
+			ranges:         []softReservedRange{{Start: 100, End: 199, Reason: "reserved for the billing team"}},
+			optIn:          []string{RuleFieldNoAddInSoftReserved},
+			expectedErrors: []string{`Field "nickname" (number 101) added to message "TestMessage" uses soft-reserved range 100-199: reserved for the billing team`},
+		},
+		{
+			name:           "Range of another message",
+			ranges:         []softReservedRange{{Message: "test.Other", Start: 100, End: 199}},
+			optIn:          []string{RuleFieldNoAddInSoftReserved},
+			expectedErrors: []string{},
+		},
+		{
+			name:           "Without --warn-on-additions-in-reserved",
+			ranges:         []softReservedRange{{Start: 100, End: 199}},
+			expectedErrors: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, prevProto, currProto)
+
+			rules := rulesWith(tt.optIn, nil)
+
+			changes := compareFiles(prevFileDesc, currFileDesc, options{rules: rules, softReserved: tt.ranges})
+			if actualErrors := changeMessages(changes); !reflect.DeepEqual(actualErrors, tt.expectedErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.expectedErrors, actualErrors)
+			}
+			if len(FilterSeverity(changes, SeverityError)) > 0 {
+				t.Errorf("Expected only warnings, got %v", changes)
+			}
+		})
+	}
+}
+
+// TestSyntaxMigration tests that a proto2 to proto3 migration reports the fields losing presence
+func TestSyntaxMigration(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto2";
+		package test;
+		message Other {}
+		message TestMessage {
+			optional string name = 1;
+			optional int32 age = 2;
+			repeated string tags = 3;
+			optional Other other = 4;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message Other {}
+		message TestMessage {
+			string name = 1;
+			optional int32 age = 2;
+			repeated string tags = 3;
+			Other other = 4;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+
+	expected := []string{
+		fmt.Sprintf("Syntax changed from proto2 to proto3 in file %q", currFileDesc.Path()),
+		`Field "name" lost explicit presence in message "TestMessage" after the syntax change from proto2 to proto3`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if changes[0].Severity != SeverityWarning || changes[1].Severity != SeverityError {
+		t.Errorf("Expected a syntax warning and a presence error, got %v", changes)
+	}
+}
+
+// Helper function to parse a previous and current proto source into file descriptors
+func parseTestProtos(t *testing.T, prevProto, currProto string) (protoreflect.FileDescriptor, protoreflect.FileDescriptor) {
+	t.Helper()
+
+	prevFile, err := createTempProtoFile(prevProto)
+	if err != nil {
+		t.Fatalf("Failed to create previous proto file: %v", err)
+	}
+	defer os.Remove(prevFile)
+
+	currFile, err := createTempProtoFile(currProto)
+	if err != nil {
+		t.Fatalf("Failed to create current proto file: %v", err)
+	}
+	defer os.Remove(currFile)
+
+	prevFileDesc, err := parseProtoFileToReflect(prevFile)
+	if err != nil {
+		t.Fatalf("Failed to parse previous proto file: %v", err)
+	}
+
+	currFileDesc, err := parseProtoFileToReflect(currFile)
+	if err != nil {
+		t.Fatalf("Failed to parse current proto file: %v", err)
+	}
+
+	return prevFileDesc, currFileDesc
+}
+
+// Helper function to extract the messages of breaking changes
+func changeMessages(changes []BreakingChange) []string {
+	messages := []string{}
+	for _, change := range changes {
+		messages = append(messages, change.Message)
+	}
+	return messages
+}
+
+// Helper function to create a temporary proto file
+func createTempProtoFile(content string) (string, error) {
+	// Create a temporary file
+	tmpFile, err := ioutil.TempFile("", "test_*.proto")
+	if err != nil {
+		return "", err
+	}
+	defer tmpFile.Close()
+
+	// Write the content to the file
+	content = strings.TrimSpace(content)
+	if _, err := tmpFile.WriteString(content); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	// Get the absolute path
+	absPath, err := filepath.Abs(tmpFile.Name())
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	return absPath, nil
+}
+
+// parseProtoFileToReflect parses a proto file on disk, resolving imports from its directory and then importPaths
+func parseProtoFileToReflect(filePath string, importPaths ...string) (protoreflect.FileDescriptor, error) {
+	parser := protoparse.Parser{
+		ImportPaths:           append([]string{filepath.Dir(filePath)}, importPaths...),
+		IncludeSourceCodeInfo: true,
+	}
+	fileDescs, err := parser.ParseFiles(filepath.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	return fileDescs[0].UnwrapFile(), nil
+}
+
+// parseProtoSourceToReflect parses proto source held in memory, using name as its file name
+func parseProtoSourceToReflect(name, content string) (protoreflect.FileDescriptor, error) {
+	parser := protoparse.Parser{
+		Accessor:              protoparse.FileContentsFromMap(map[string]string{name: content}),
+		IncludeSourceCodeInfo: true,
+	}
+	fileDescs, err := parser.ParseFiles(name)
+	if err != nil {
+		return nil, err
+	}
+	return fileDescs[0].UnwrapFile(), nil
+}
+
+// writeProtoFile writes a proto file under root, creating its directories
+func writeProtoFile(t *testing.T, root, file, content string) {
+	t.Helper()
+
+	path := filepath.Join(root, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory for %s: %v", file, err)
+	}
+	if err := os.WriteFile(path, []byte(strings.TrimSpace(content)), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", file, err)
+	}
+}
+
+// TestRepeatedEntryToMap tests that converting a repeated entry message to a map is breaking
+func TestRepeatedEntryToMap(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message Entry {
+			string key = 1;
+			int32 value = 2;
+		}
+		message TestMessage {
+			repeated Entry counts = 1;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message Entry {
+			string key = 1;
+			int32 value = 2;
+		}
+		message TestMessage {
+			map<string, int32> counts = 1;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Field "counts" changed from repeated test.Entry to map<string, int32> in message "TestMessage"; ` +
+			`map entries have a fixed key 1 and value 2 layout, so add a new field and deprecate the old one instead`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(changes) == 1 && changes[0].Rule != RuleFieldNoMapConversion {
+		t.Errorf("Expected rule %s, got %s", RuleFieldNoMapConversion, changes[0].Rule)
+	}
+
+	// Converting back is reported the same way
+	changes = compareFiles(currFileDesc, prevFileDesc, options{rules: DefaultRuleSet()})
+	if len(changes) != 1 || !strings.HasPrefix(changes[0].Message, `Field "counts" changed from map<string, int32> to repeated test.Entry`) {
+		t.Errorf("Expected a map to repeated conversion, got %v", changeMessages(changes))
+	}
+}
+
+// TestMapValueType tests that map value types changing kind or shape are breaking
+func TestMapValueType(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message Price {
+			int64 cents = 1;
+		}
+		message TestMessage {
+			map<string, int32> counts = 1;
+			map<string, Price> prices = 2;
+			Price total = 3;
+			string name = 4;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message Price {
+			int64 cents = 1;
+		}
+		message TestMessage {
+			map<string, int64> counts = 1;
+			Price prices = 2;
+			map<string, Price> total = 3;
+			map<string, string> name = 4;
+		}
+	`)
+
+	// The entry messages are not reported on their own
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Map field "counts" value type changed from int32 to int64 in message "TestMessage"`,
+		`Field "name" changed from scalar string to map<string, string> in message "TestMessage"`,
+		`Field "prices" changed from map<string, test.Price> to message test.Price in message "TestMessage"`,
+		`Field "total" changed from message test.Price to map<string, test.Price> in message "TestMessage"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule != RuleFieldSameType {
+			t.Errorf("Expected rule %s, got %s for %q", RuleFieldSameType, change.Rule, change.Message)
+		}
+	}
+}
+
+// TestMapValueNamedType tests that message and enum map values must keep their type, not just their kind
+func TestMapValueNamedType(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message Price {
+			int64 cents = 1;
+		}
+		message Cost {
+			int64 cents = 1;
+		}
+		message Account {
+			enum Status {
+				UNSPECIFIED = 0;
+			}
+		}
+		message Member {
+			enum Level {
+				UNSPECIFIED = 0;
+			}
+		}
+		message TestMessage {
+			map<string, Price> prices = 1;
+			map<string, Account.Status> statuses = 2;
+			map<string, Price> totals = 3;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message Price {
+			int64 cents = 1;
+		}
+		message Cost {
+			int64 cents = 1;
+		}
+		message Account {
+			enum Status {
+				UNSPECIFIED = 0;
+			}
+		}
+		message Member {
+			enum Level {
+				UNSPECIFIED = 0;
+			}
+		}
+		message TestMessage {
+			map<string, Cost> prices = 1;
+			map<string, Member.Level> statuses = 2;
+			map<string, Price> totals = 3;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Map field "prices" value type changed from test.Price to test.Cost in message "TestMessage"`,
+		`Map field "statuses" value type changed from test.Account.Status to test.Member.Level in message "TestMessage"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule != RuleFieldSameType {
+			t.Errorf("Expected rule %s, got %s for %q", RuleFieldSameType, change.Rule, change.Message)
+		}
+	}
+}
+
+// TestRequiredFields tests that new required fields and fields becoming required are breaking
+func TestRequiredFields(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto2";
+		package test;
+		message TestMessage {
+			optional string name = 1;
+			optional int32 age = 2;
+		}
+	`, `
+		syntax = "proto2";
+		package test;
+		message TestMessage {
+			optional string name = 1;
+			required int32 age = 2;
+			required string email = 3;
+			optional string nickname = 4;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Field "age" changed from optional to required in message "TestMessage"`,
+		`New required field "email" (number 3) added to message "TestMessage"; ` +
+			`generated builders fail to build the message without it, breaking existing callers`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	expectedRules := []string{RuleFieldNoNewRequired, RuleRequiredFieldAdded}
+	for i, change := range changes {
+		if i < len(expectedRules) && (change.Rule != expectedRules[i] || change.Severity != SeverityError) {
+			t.Errorf("Expected a %s error, got %s (%s)", expectedRules[i], change.Rule, change.Severity)
+		}
+	}
+
+	// Relaxing required fields is not reported by these rules
+	changes = compareFiles(currFileDesc, prevFileDesc, options{rules: DefaultRuleSet()})
+	for _, change := range changes {
+		if change.Rule == RuleFieldNoNewRequired || change.Rule == RuleRequiredFieldAdded {
+			t.Errorf("Unexpected change %q", change.Message)
+		}
+	}
+}
+
+// TestMessageEncoding tests that changing features.message_encoding of an editions field is breaking
+func TestMessageEncoding(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		edition = "2023";
+		package test;
+		message Other {}
+		message TestMessage {
+			Other other = 1;
+			Other delimited = 2 [features.message_encoding = DELIMITED];
+		}
+	`, `
+		edition = "2023";
+		package test;
+		message Other {}
+		message TestMessage {
+			Other other = 1 [features.message_encoding = DELIMITED];
+			Other delimited = 2;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Field "delimited" message encoding changed from DELIMITED to LENGTH_PREFIXED in message "TestMessage"`,
+		`Field "other" message encoding changed from LENGTH_PREFIXED to DELIMITED in message "TestMessage"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule != RuleFieldSameMessageEncoding || change.Severity != SeverityError {
+			t.Errorf("Expected a %s error, got %s (%s)", RuleFieldSameMessageEncoding, change.Rule, change.Severity)
+		}
+	}
+}
+
+// TestPackageRename tests that a package rename is reported once and messages are still compared
+func TestPackageRename(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test.v1;
+		message Outer {
+			message Inner {
+				string name = 1;
+				int32 age = 2;
+			}
+			Kind kind = 1;
+		}
+		enum Kind {
+			KIND_UNKNOWN = 0;
+		}
+		service OuterService {
+			rpc Get(Outer) returns (Outer.Inner);
+		}
+	`, `
+		syntax = "proto3";
+		package test.v2;
+		message Outer {
+			message Inner {
+				string name = 1;
+			}
+			Kind kind = 1;
+		}
+		enum Kind {
+			KIND_UNKNOWN = 0;
+		}
+		service OuterService {
+			rpc Get(Outer) returns (Outer.Inner);
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Package renamed from "test.v1" to "test.v2"`,
+		`Field "age" (number 2) was removed from message "Inner"`,
+		`Removed field "age" (number 2) is not reserved in message "Inner"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(changes) > 0 && changes[0].Rule != RuleFileSamePackage {
+		t.Errorf("Expected rule %s, got %s", RuleFileSamePackage, changes[0].Rule)
+	}
+}
+
+// TestFieldSignedness tests that changes between signed and unsigned varint types are reported specifically
+func TestFieldSignedness(t *testing.T) {
+	tests := []struct {
+		prevType string
+		currType string
+	}{
+		{prevType: "int32", currType: "uint32"},
+		{prevType: "uint32", currType: "int32"},
+		{prevType: "int64", currType: "uint64"},
+		{prevType: "uint64", currType: "int64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prevType+"_to_"+tt.currType, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, fmt.Sprintf(`
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					%s balance = 1;
+				}
+			`, tt.prevType), fmt.Sprintf(`
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					%s balance = 1;
+				}
+			`, tt.currType))
+
+			changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+			expected := []string{fmt.Sprintf(`Field "balance" changed signedness (%s→%s) in message "TestMessage"`, tt.prevType, tt.currType)}
+			if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Expected warnings %v, got %v", expected, actual)
+			}
+			if len(changes) == 1 && (changes[0].Rule != RuleFieldSameSignedness || changes[0].Severity != SeverityWarning) {
+				t.Errorf("Expected a %s warning, got %s (%s)", RuleFieldSameSignedness, changes[0].Rule, changes[0].Severity)
+			}
+		})
+	}
+}
+
+// TestFieldZigZag tests that changes between sint and int or uint types are reported as zigzag encoding changes
+func TestFieldZigZag(t *testing.T) {
+	tests := []struct {
+		prevType string
+		currType string
+	}{
+		{prevType: "sint32", currType: "int32"},
+		{prevType: "int32", currType: "sint32"},
+		{prevType: "sint32", currType: "uint32"},
+		{prevType: "uint32", currType: "sint32"},
+		{prevType: "sint64", currType: "int64"},
+		{prevType: "int64", currType: "sint64"},
+		{prevType: "sint64", currType: "uint64"},
+		{prevType: "uint64", currType: "sint64"},
+		{prevType: "sint32", currType: "int64"},
+		{prevType: "uint64", currType: "sint32"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prevType+"_to_"+tt.currType, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, fmt.Sprintf(`
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					%s delta = 1;
+				}
+			`, tt.prevType), fmt.Sprintf(`
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					%s delta = 1;
+				}
+			`, tt.currType))
+
+			changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+			expected := []string{fmt.Sprintf(`Field "delta" changed between zigzag and plain varint encoding (%s→%s) in message "TestMessage"; existing values decode to different numbers`, tt.prevType, tt.currType)}
+			if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Expected errors %v, got %v", expected, actual)
+			}
+			if len(changes) == 1 && (changes[0].Rule != RuleFieldSameZigZag || changes[0].Severity != SeverityError) {
+				t.Errorf("Expected a %s error, got %s (%s)", RuleFieldSameZigZag, changes[0].Rule, changes[0].Severity)
+			}
+		})
+	}
+
+	// Changes within the sint family, or between sint and non-integer varints, are left to other rules
+	for _, kinds := range [][2]protoreflect.Kind{
+		{protoreflect.Sint32Kind, protoreflect.Sint64Kind},
+		{protoreflect.Sint32Kind, protoreflect.BoolKind},
+		{protoreflect.EnumKind, protoreflect.Sint64Kind},
+		{protoreflect.Int32Kind, protoreflect.Uint64Kind},
+	} {
+		if isZigZagChange(kinds[0], kinds[1]) {
+			t.Errorf("Expected %s→%s not to be a zigzag change", kinds[0], kinds[1])
+		}
+	}
+}
+
+// TestFieldOneofMembership tests that fields moving into, out of or between oneofs are breaking
+func TestFieldOneofMembership(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string email = 1;
+			oneof contact {
+				string phone = 2;
+				string fax = 3;
+			}
+			oneof payment {
+				string card = 4;
+			}
+			string nickname = 5;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			oneof contact {
+				string email = 1;
+				string phone = 2;
+			}
+			string fax = 3;
+			oneof billing {
+				string card = 4;
+			}
+			oneof payment {
+				string iban = 6;
+			}
+			optional string nickname = 5;
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{
+		`Field "card" moved from oneof "payment" to oneof "billing" in message "TestMessage"`,
+		`Field "email" moved into oneof "contact" in message "TestMessage"`,
+		`Field "fax" moved out of oneof "contact" in message "TestMessage"`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule != RuleFieldSameOneof {
+			t.Errorf("Expected rule %s, got %s", RuleFieldSameOneof, change.Rule)
+		}
+	}
+}
+
+// mapEntryMessage is a message whose map_entry option is toggled, which the parser does not allow to declare
+type mapEntryMessage struct {
+	protoreflect.MessageDescriptor
+	mapEntry bool
+}
+
+// Options returns message options only setting map_entry
+func (m mapEntryMessage) Options() protoreflect.ProtoMessage {
+	return &descriptorpb.MessageOptions{MapEntry: proto.Bool(m.mapEntry)}
+}
+
+// TestMessageMapEntry tests that toggling the map_entry option of a message is breaking
+func TestMessageMapEntry(t *testing.T) {
+	fileDesc, _ := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message Entry {
+			string key = 1;
+			string value = 2;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+	`)
+	msg := fileDesc.Messages().ByName("Entry")
+
+	changes := compareMessage("Entry", msg, mapEntryMessage{msg, true}, DefaultRuleSet())
+	expected := []string{`Message "Entry" changed map_entry from false to true`}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(changes) == 1 && changes[0].Rule != RuleMessageSameMapEntry {
+		t.Errorf("Expected rule %s, got %s", RuleMessageSameMapEntry, changes[0].Rule)
+	}
+
+	changes = compareMessage("Entry", mapEntryMessage{msg, true}, msg, DefaultRuleSet())
+	expected = []string{`Message "Entry" changed map_entry from true to false`}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+}
+
+// TestFieldJSONName tests that JSON name changes are reported with --check-json, except for plain renames
+func TestFieldJSONName(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string display_name = 1;
+			string email = 2 [json_name = "mail"];
+			string nick = 3;
+			string phone = 4 [json_name = "tel"];
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string display_name = 1 [json_name = "name"];
+			string email = 2;
+			string nickname = 3;
+			string mobile = 4 [json_name = "tel"];
+		}
+	`)
+
+	rules := onlyRules(RuleFieldSameJSONName)
+	expected := []string{
+		`Field "display_name" JSON name changed from "displayName" to "name" in message "TestMessage"`,
+		`Field "email" JSON name changed from "mail" to "email" in message "TestMessage"`,
+	}
+	if actual := changeMessages(compareFiles(prevFileDesc, currFileDesc, options{rules: rules})); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+
+	// The rule is opt-in
+	for _, change := range compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()}) {
+		if change.Rule == RuleFieldSameJSONName {
+			t.Errorf("Unexpected change without --check-json: %q", change.Message)
+		}
+	}
+}
+
+// TestChangeOrderIsStable tests that changes come out in the same order on every run, sorted by path
+// and rule, although messages, enums and services are compared by iterating over maps
+func TestChangeOrderIsStable(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message Alpha { string a = 1; }
+		message Beta { string b = 1; }
+		message Gamma { string c = 1; }
+		message Delta { string d = 1; }
+		enum First { FIRST_UNSPECIFIED = 0; FIRST_ONE = 1; }
+		enum Second { SECOND_UNSPECIFIED = 0; SECOND_ONE = 1; }
+		enum Third { THIRD_UNSPECIFIED = 0; THIRD_ONE = 1; }
+		service North { rpc Get(Alpha) returns (Alpha); }
+		service South { rpc Get(Alpha) returns (Alpha); }
+	`, `
+		syntax = "proto3";
+		package test;
+		message Alpha {}
+		message Beta {}
+		message Gamma {}
+		message Delta {}
+		enum First { FIRST_UNSPECIFIED = 0; }
+		enum Second { SECOND_UNSPECIFIED = 0; }
+		enum Third { THIRD_UNSPECIFIED = 0; }
+	`)
+
+	first := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	if !sort.SliceIsSorted(first, func(i, j int) bool {
+		if c := comparePaths(first[i].Path, first[j].Path); c != 0 {
+			return c < 0
+		}
+		return first[i].Rule < first[j].Rule
+	}) {
+		t.Errorf("Expected changes sorted by path and rule, got %v", changeMessages(first))
+	}
+	for i := 0; i < 20; i++ {
+		changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+		if !reflect.DeepEqual(changeMessages(changes), changeMessages(first)) {
+			t.Fatalf("Expected the same order on every run, got %v and %v", changeMessages(first), changeMessages(changes))
+		}
+	}
+}
+
+// TestMethodTypeAndStreamingChange tests that type and streaming changes of the same method are both reported
+func TestMethodTypeAndStreamingChange(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message Req {}
+		message Req2 {}
+		message Resp {}
+		message Resp2 {}
+		service TestService {
+			rpc Foo(Req) returns (Resp);
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message Req {}
+		message Req2 {}
+		message Resp {}
+		message Resp2 {}
+		service TestService {
+			rpc Foo(stream Req2) returns (stream Resp2);
+		}
+	`)
+
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	expected := []string{RuleRPCSameClientStreaming, RuleRPCSameRequestType, RuleRPCSameResponseType, RuleRPCSameServerStreaming}
+	var actual []string
+	for _, change := range changes {
+		actual = append(actual, change.Rule)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected rules %v, got %v (%v)", expected, actual, changeMessages(changes))
+	}
+}
+
+func TestShowAdditionsBecameRepeated(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			int32 score = 1;
+			string name = 2;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			repeated int32 score = 1;
+			string name = 2;
+		}
+	`)
+
+	// Without --show-additions the change is safe and not reported
+	if changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()}); len(changes) != 0 {
+		t.Errorf("Expected no changes without --show-additions, got %v", changeMessages(changes))
+	}
+
+	rules := rulesWith([]string{RuleFieldBecameRepeated}, nil)
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: rules})
+	expected := []string{`Field "score" became repeated in message "TestMessage"`}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected notes %v, got %v", expected, actual)
+	}
+	if len(changes) == 1 && changes[0].Severity != SeverityInfo {
+		t.Errorf("Expected severity %s, got %s", SeverityInfo, changes[0].Severity)
+	}
+}
+
+func TestRemovedFieldNotReserved(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+			string email = 3;
+			string phone = 4;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			reserved 2, 3;
+			reserved "age";
+			string name = 1;
+		}
+	`)
+
+	// Only age has both its number and name reserved
+	var actual []string
+	for _, change := range compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()}) {
+		if change.Rule == RuleFieldRemovedNotReserved {
+			actual = append(actual, change.Message)
+			if change.Severity != SeverityWarning {
+				t.Errorf("Expected severity %s, got %s", SeverityWarning, change.Severity)
+			}
+		}
+	}
+	expected := []string{
+		`Removed field "email" (number 3) is not reserved in message "TestMessage"`,
+		`Removed field "phone" (number 4) is not reserved in message "TestMessage"`,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, actual)
+	}
+}
+
+func TestRequiredFieldAdded(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto2";
+		package test;
+		message Order {
+			optional string id = 1;
+			message Line {
+				optional string sku = 1;
+			}
+		}
+	`, `
+		syntax = "proto2";
+		package test;
+		message Order {
+			optional string id = 1;
+			required string currency = 2;
+			message Line {
+				optional string sku = 1;
+				required int32 quantity = 2;
+			}
+		}
+	`)
+
+	// Only the new required fields are reported, with the builder note, and nested messages are covered too
+	rules := onlyRules(RuleRequiredFieldAdded)
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: rules})
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Message < changes[j].Message })
+	expected := []string{
+		`New required field "currency" (number 2) added to message "Order"; ` +
+			`generated builders fail to build the message without it, breaking existing callers`,
+		`New required field "quantity" (number 2) added to message "Line"; ` +
+			`generated builders fail to build the message without it, breaking existing callers`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Severity != SeverityError {
+			t.Errorf("Expected severity %s, got %s", SeverityError, change.Severity)
+		}
+	}
+}
+
+func TestMapEntryMessagesSkipped(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			map<string, int32> counts = 1;
+			map<string, string> labels = 2;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			map<int64, int32> counts = 1;
+			map<string, string> tags = 20;
+		}
+	`)
+
+	rules := rulesWith([]string{RuleFieldNoAddInSoftReserved}, nil)
+	opts := options{rules: rules, softReserved: []softReservedRange{{Start: 1, End: 10}}}
+
+	// Neither the removed LabelsEntry nor the key and value of the entries are reported on their own
+	expected := []string{
+		`Map field "counts" key type changed from string to int64 in message "TestMessage"`,
+		`Field "labels" (number 2) was removed from message "TestMessage"`,
+		`Removed field "labels" (number 2) is not reserved in message "TestMessage"`,
+	}
+	if actual := changeMessages(compareFiles(prevFileDesc, currFileDesc, opts)); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+}
+
+func TestEnumValueNumberChange(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		enum Status {
+			STATUS_UNSPECIFIED = 0;
+			ACTIVE = 1;
+			SUSPENDED = 2;
+			CLOSED = 3;
+			ARCHIVED = 4;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		enum Status {
+			STATUS_UNSPECIFIED = 0;
+			SUSPENDED = 1;
+			ACTIVE = 2;
+			DONE = 3;
+			ARCHIVED = 5;
+		}
+	`)
+
+	// Swapped values are reported as renumbered rather than renamed, while a plain rename stays a rename
+	expected := []string{
+		`Enum value "ACTIVE" number changed from 1 to 2 in enum "Status"`,
+		`Enum value "ARCHIVED" number changed from 4 to 5 in enum "Status"`,
+		`Enum value renamed from "CLOSED" to "DONE" in enum "Status"`,
+		`Enum value "SUSPENDED" number changed from 2 to 1 in enum "Status"`,
+	}
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule == RuleEnumValueSameNumber && change.Severity != SeverityError {
+			t.Errorf("Expected severity %s, got %s", SeverityError, change.Severity)
+		}
+	}
+}
+
+func TestFieldTypeMovedPackage(t *testing.T) {
+	root := t.TempDir()
+	writeProtoFile(t, root, "geo/v1/address.proto", `
+		syntax = "proto3";
+		package geo.v1;
+		message Address {
+			string street = 1;
+		}
+	`)
+	writeProtoFile(t, root, "geo/v2/address.proto", `
+		syntax = "proto3";
+		package geo.v2;
+		message Address {
+			string street = 1;
+		}
+		message Location {
+			string street = 1;
+		}
+	`)
+	writeProtoFile(t, root, "old/user.proto", `
+		syntax = "proto3";
+		package test;
+		import "geo/v1/address.proto";
+		message User {
+			geo.v1.Address home = 1;
+			geo.v1.Address work = 2;
+			geo.v1.Address billing = 3;
+		}
+	`)
+	writeProtoFile(t, root, "new/user.proto", `
+		syntax = "proto3";
+		package test;
+		import "geo/v1/address.proto";
+		import "geo/v2/address.proto";
+		message User {
+			geo.v2.Address home = 1;
+			geo.v2.Location work = 2;
+			geo.v1.Address billing = 3;
+		}
+	`)
+
+	prevFileDesc, err := parseProtoFileToReflect(filepath.Join(root, "old", "user.proto"), root)
+	if err != nil {
+		t.Fatalf("Failed to parse old file: %v", err)
+	}
+	currFileDesc, err := parseProtoFileToReflect(filepath.Join(root, "new", "user.proto"), root)
+	if err != nil {
+		t.Fatalf("Failed to parse new file: %v", err)
+	}
+
+	// The field keeping the short name of its type is reported as a package move
+	expected := []string{
+		`Field "home" type Address moved from package "geo.v1" to "geo.v2" in message "User"`,
+		`Field "work" type changed from geo.v1.Address to geo.v2.Location in message "User"`,
+	}
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule != RuleFieldSameType {
+			t.Errorf("Expected rule %s, got %s", RuleFieldSameType, change.Rule)
+		}
+	}
+}
+
+// TestFieldNumberReused tests that a field changing both name and type is reported once as a reused number
+func TestFieldNumberReused(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message User {
+			string name = 1;
+			int32 age = 2;
+			string nickname = 3;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message User {
+			string name = 1;
+			string email = 2;
+			string alias = 3;
+		}
+	`)
+
+	// A field keeping its type is still reported as a rename
+	expected := []string{
+		`Field number 2 reused: was "age" (int32), now "email" (string) in message "User"`,
+		`Field renamed from "nickname" to "alias" in message "User"`,
+	}
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(changes) > 0 && (changes[0].Rule != RuleFieldNoNumberReuse || changes[0].Severity != SeverityError) {
+		t.Errorf("Expected a %s error, got %s (%s)", RuleFieldNoNumberReuse, changes[0].Rule, changes[0].Severity)
+	}
+
+	// Skipping the rule falls back to reporting the rename and the type change separately
+	rules := rulesWith(nil, []string{RuleFieldNoNumberReuse})
+	for _, change := range compareFiles(prevFileDesc, currFileDesc, options{rules: rules}) {
+		if change.Rule == RuleFieldNoNumberReuse {
+			t.Errorf("Expected no %s change when the rule is skipped, got %q", RuleFieldNoNumberReuse, change.Message)
+		}
+	}
+}
+
+// TestReservationRemoved tests that numbers which are no longer reserved nor used by a field are reported
+func TestReservationRemoved(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message User {
+			reserved 5, 7, 10 to 20, 100 to max;
+			string name = 1;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message User {
+			reserved 7, 12 to 20, 100 to max;
+			string name = 1;
+			string email = 11;
+		}
+	`)
+
+	// The number taken by a new field is left to the field addition checks
+	expected := []string{
+		`Reservation of number 5 in message "User" was removed`,
+		`Reservation of number 10 in message "User" was removed`,
+	}
+	changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule != RuleReservedNoDelete || change.Severity != SeverityWarning {
+			t.Errorf("Expected a %s warning, got %s (%s)", RuleReservedNoDelete, change.Rule, change.Severity)
+		}
+	}
+
+	// Dropping a whole range is reported once
+	prevFileDesc, currFileDesc = parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message User {
+			reserved 10 to 20;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message User {}
+	`)
+	expected = []string{`Reservation of numbers 10 to 20 in message "User" was removed`}
+	if actual := changeMessages(compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, actual)
+	}
+}
+
+// TestFileOptions tests that changes to any file option are listed, with severities configured by option name
+func TestFileOptions(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		import "google/protobuf/descriptor.proto";
+		extend google.protobuf.FileOptions {
+			string api_version = 50000;
+			string owner = 50001;
+		}
+		option go_package = "example.com/test/v1";
+		option (test.api_version) = "v1";
+		option (test.owner) = "team-a";
+	`, `
+		syntax = "proto3";
+		package test;
+		import "google/protobuf/descriptor.proto";
+		extend google.protobuf.FileOptions {
+			string api_version = 50000;
+			string owner = 50001;
+		}
+		option go_package = "example.com/test/v2";
+		option java_multiple_files = true;
+		option (test.api_version) = "v2";
+		option (test.owner) = "team-b";
+	`)
+
+	tests := []struct {
+		name       string
+		severities map[string]Severity
+		expected   []string
+		severity   []Severity
+	}{
+		{
+			name: "Unconfigured options are info notes",
+			expected: []string{
+				`File changed option (test.api_version) from "v1" to "v2"`,
+				`File changed option (test.owner) from "team-a" to "team-b"`,
+				`File changed option go_package from "example.com/test/v1" to "example.com/test/v2"`,
+				`File added option java_multiple_files = true`,
+			},
+			severity: []Severity{SeverityInfo, SeverityInfo, SeverityInfo, SeverityInfo},
+		},
+		{
+			name:       "Configured options",
+			severities: map[string]Severity{"go_package": SeverityError, "java_multiple_files": SeverityWarning, "(test.owner)": SeverityOff},
+			expected: []string{
+				`File changed option (test.api_version) from "v1" to "v2"`,
+				`File changed option go_package from "example.com/test/v1" to "example.com/test/v2"`,
+				`File added option java_multiple_files = true`,
+			},
+			severity: []Severity{SeverityInfo, SeverityError, SeverityWarning},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet(), fileOptionSeverities: tt.severities})
+			if actual := changeMessages(changes); !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("Expected changes %v, got %v", tt.expected, actual)
+			}
+			for i, change := range changes {
+				if change.Rule != RuleFileSameOptions || change.Severity != tt.severity[i] {
+					t.Errorf("Expected a %s %s for %q, got %s (%s)", RuleFileSameOptions, tt.severity[i], change.Message, change.Rule, change.Severity)
+				}
+			}
+		})
+	}
+}
+
+// TestEnumMoved tests that enums moving into or out of a message are reported alongside their removal
+func TestEnumMoved(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		enum Status {
+			STATUS_UNKNOWN = 0;
+			STATUS_ACTIVE = 1;
+			STATUS_DISABLED = 2;
+		}
+		message User {
+			enum Role {
+				ROLE_UNKNOWN = 0;
+				ROLE_ADMIN = 1;
+			}
+		}
+		enum Color {
+			COLOR_UNKNOWN = 0;
+			COLOR_RED = 1;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message User {
+			enum Status {
+				STATUS_UNKNOWN = 0;
+				STATUS_ACTIVE = 1;
+				STATUS_DISABLED = 2;
+				STATUS_DELETED = 3;
+			}
+		}
+		enum Role {
+			ROLE_UNKNOWN = 0;
+			ROLE_ADMIN = 1;
+		}
+		enum Shade {
+			SHADE_UNKNOWN = 0;
+			SHADE_DARK = 1;
+		}
+	`)
+
+	// Enums without a similar new enum are only reported as removed
+	changes := compareEnums(prevFileDesc, currFileDesc, DefaultRuleSet())
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Message < changes[j].Message })
+	expected := []string{
+		`Enum "Color" was removed`,
+		`Enum "Status" moved to "User.Status"`,
+		`Enum "Status" was removed`,
+		`Enum "User.Role" moved to "Role"`,
+		`Enum "User.Role" was removed`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected changes %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Rule == RuleEnumMoved && change.Severity != SeverityWarning {
+			t.Errorf("Expected a warning for %q, got %s", change.Message, change.Severity)
+		}
+	}
+}
+
+// nextEditionFile reports a later edition for an editions file. The parser and the
+// Go runtime only accept edition 2023 so far, so a newer edition has to be simulated.
+type nextEditionFile struct {
+	protoreflect.FileDescriptor
+}
+
+// Edition returns EDITION_2024
+func (nextEditionFile) Edition() int32 {
+	return int32(descriptorpb.Edition_EDITION_2024)
+}
+
+// TestEditionChange tests reporting a change of the edition value
+func TestEditionChange(t *testing.T) {
+	fileDesc, err := parseProtoSourceToReflect("test.proto", `
+		edition = "2023";
+		package test;
+		message TestMessage {
+			string name = 1;
+		}
+	`)
+	if err != nil {
+		t.Fatalf("Failed to parse proto: %v", err)
+	}
+
+	if changes := compareFiles(fileDesc, fileDesc, options{rules: DefaultRuleSet()}); len(changes) != 0 {
+		t.Errorf("Expected no changes for the same edition, got %v", changes)
+	}
+
+	changes := compareFiles(fileDesc, nextEditionFile{fileDesc}, options{rules: DefaultRuleSet()})
+	expected := []string{`Edition changed from 2023 to 2024 in file "test.proto"; default features may have shifted`}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(changes) == 1 && changes[0].Severity != SeverityWarning {
+		t.Errorf("Expected a warning, got %s", changes[0].Severity)
+	}
+}
@@ -0,0 +1,108 @@
+package protobreak
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// deprecatable is implemented by the options of every descriptor that can be deprecated
+type deprecatable interface {
+	GetDeprecated() bool
+}
+
+// isDeprecated reports whether the descriptor sets the deprecated option
+func isDeprecated(desc protoreflect.Descriptor) bool {
+	opts, ok := desc.Options().(deprecatable)
+	return ok && opts.GetDeprecated()
+}
+
+// deprecationKind names the kind of a deprecated descriptor in messages
+func deprecationKind(desc protoreflect.Descriptor) string {
+	switch desc.(type) {
+	case protoreflect.MessageDescriptor:
+		return "Message"
+	case protoreflect.EnumDescriptor:
+		return "Enum"
+	case protoreflect.EnumValueDescriptor:
+		return "Enum value"
+	case protoreflect.ServiceDescriptor:
+		return "Service"
+	default:
+		return "Method"
+	}
+}
+
+// deprecationPath returns the name of the descriptor relative to its package. Enum values are
+// scoped to their enum, unlike their full names.
+func deprecationPath(desc protoreflect.Descriptor) string {
+	if value, ok := desc.(protoreflect.EnumValueDescriptor); ok {
+		return relativeName(value.Parent()) + "." + string(value.Name())
+	}
+	return relativeName(desc)
+}
+
+// collectDeprecatable collects every message, enum, enum value, service and method of a file,
+// keyed by their deprecation path. Synthetic map entries are skipped, and fields are left to FIELD_DEPRECATED.
+func collectDeprecatable(file protoreflect.FileDescriptor) map[string]protoreflect.Descriptor {
+	output := make(map[string]protoreflect.Descriptor)
+	var addEnums func(enums protoreflect.EnumDescriptors)
+	addEnums = func(enums protoreflect.EnumDescriptors) {
+		for i := 0; i < enums.Len(); i++ {
+			enum := enums.Get(i)
+			output[deprecationPath(enum)] = enum
+			for j := 0; j < enum.Values().Len(); j++ {
+				output[deprecationPath(enum.Values().Get(j))] = enum.Values().Get(j)
+			}
+		}
+	}
+	var addMessages func(msgs protoreflect.MessageDescriptors)
+	addMessages = func(msgs protoreflect.MessageDescriptors) {
+		for i := 0; i < msgs.Len(); i++ {
+			msg := msgs.Get(i)
+			if msg.IsMapEntry() {
+				continue
+			}
+			output[deprecationPath(msg)] = msg
+			addEnums(msg.Enums())
+			addMessages(msg.Messages())
+		}
+	}
+
+	addMessages(file.Messages())
+	addEnums(file.Enums())
+	for i := 0; i < file.Services().Len(); i++ {
+		service := file.Services().Get(i)
+		output[deprecationPath(service)] = service
+		for j := 0; j < service.Methods().Len(); j++ {
+			output[deprecationPath(service.Methods().Get(j))] = service.Methods().Get(j)
+		}
+	}
+	return output
+}
+
+// compareDeprecations reports elements that existed before and are deprecated in the current file.
+// Elements added already deprecated are not reported.
+func compareDeprecations(prevFile, currFile protoreflect.FileDescriptor, rules RuleSet) []BreakingChange {
+	var breakingChanges []BreakingChange
+
+	prevDescs := collectDeprecatable(prevFile)
+	currDescs := collectDeprecatable(currFile)
+	paths := make([]string, 0, len(currDescs))
+	for path := range currDescs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		currDesc := currDescs[path]
+		prevDesc, ok := prevDescs[path]
+		if !ok || !isDeprecated(currDesc) || isDeprecated(prevDesc) {
+			continue
+		}
+		breakingChanges = append(breakingChanges,
+			newChange(RuleDeprecationAdded, "%s %q was deprecated", deprecationKind(currDesc), path).at(path))
+	}
+
+	return rules.Filter(breakingChanges)
+}
@@ -0,0 +1,86 @@
+package protobreak
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDeprecations tests that new deprecations of fields are notes by default, and that every new
+// deprecation is reported once its rules are enabled
+func TestDeprecations(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message User {
+			string name = 1;
+			string nickname = 2 [deprecated = true];
+			map<string, string> labels = 3;
+		}
+		enum Status {
+			STATUS_UNKNOWN = 0;
+			STATUS_ACTIVE = 1;
+		}
+		service UserService {
+			rpc GetUser(User) returns (User);
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message User {
+			option deprecated = true;
+			string name = 1 [deprecated = true];
+			string nickname = 2 [deprecated = true];
+			map<string, string> labels = 3;
+			string email = 4 [deprecated = true];
+		}
+		enum Status {
+			STATUS_UNKNOWN = 0;
+			STATUS_ACTIVE = 1 [deprecated = true];
+		}
+		service UserService {
+			option deprecated = true;
+			rpc GetUser(User) returns (User) {
+				option deprecated = true;
+			}
+		}
+	`)
+
+	// deprecations returns the changes reported by the deprecation rules, leaving out option changes of enum values
+	deprecations := func(rules RuleSet) []BreakingChange {
+		var changes []BreakingChange
+		for _, change := range compareFiles(prevFileDesc, currFileDesc, options{rules: rules}) {
+			if change.Rule == RuleDeprecationAdded || change.Rule == RuleFieldDeprecated {
+				changes = append(changes, change)
+			}
+		}
+		return changes
+	}
+
+	// By default only fields are reported, as notes
+	changes := deprecations(DefaultRuleSet())
+	expected := []string{`Field "name" was deprecated in message "User"`}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected notes %v by default, got %v", expected, actual)
+	}
+
+	// Already deprecated and new elements are not reported
+	changes = deprecations(newOptions([]Option{WithSeverities(map[string]Severity{
+		RuleDeprecationAdded: SeverityError,
+		RuleFieldDeprecated:  SeverityError,
+	})}).rules)
+	expected = []string{
+		`Enum value "Status.STATUS_ACTIVE" was deprecated`,
+		`Message "User" was deprecated`,
+		`Field "name" was deprecated in message "User"`,
+		`Service "UserService" was deprecated`,
+		`Method "UserService.GetUser" was deprecated`,
+	}
+	if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	for _, change := range changes {
+		if change.Severity != SeverityError {
+			t.Errorf("Expected an error for %q, got %s", change.Message, change.Severity)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package protobreak
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the built-in formatters
+const (
+	formatText        = "text"
+	formatJSON        = "json"
+	formatHTML        = "html"
+	formatConsoleTree = "console-tree"
+	formatSlack       = "slack"
+	formatGitHub      = "github"
+)
+
+// Report holds the reports of every file checked in a run, in file order
+type Report struct {
+	Files []FileReport
+}
+
+// Formatter writes a report in an output format
+type Formatter interface {
+	Format(w io.Writer, r Report) error
+}
+
+// FormatterFunc adapts a function to the Formatter interface
+type FormatterFunc func(w io.Writer, r Report) error
+
+// Format calls f(w, r)
+func (f FormatterFunc) Format(w io.Writer, r Report) error {
+	return f(w, r)
+}
+
+// formattersMu guards formatters, which RegisterFormatter may change while reports are written
+var formattersMu sync.RWMutex
+
+// formatters maps format names to their formatters
+var formatters = map[string]Formatter{
+	formatText: FormatterFunc(func(w io.Writer, r Report) error {
+		for _, report := range r.Files {
+			if err := writeTextReport(w, report); err != nil {
+				return err
+			}
+		}
+		return nil
+	}),
+	formatJSON: FormatterFunc(func(w io.Writer, r Report) error {
+		return writeJSONReport(w, r.Files)
+	}),
+	formatHTML: FormatterFunc(func(w io.Writer, r Report) error {
+		return writeHTMLReport(w, r.Files)
+	}),
+	formatConsoleTree: FormatterFunc(func(w io.Writer, r Report) error {
+		return writeTreeReport(w, r.Files)
+	}),
+	formatSlack: FormatterFunc(func(w io.Writer, r Report) error {
+		return writeSlackReport(w, r.Files)
+	}),
+	formatGitHub: FormatterFunc(func(w io.Writer, r Report) error {
+		return writeGitHubReport(w, r.Files)
+	}),
+}
+
+// RegisterFormatter makes a formatter available under a format name, replacing any
+// formatter already registered under it
+func RegisterFormatter(name string, f Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[name] = f
+}
+
+// formatNames returns the registered format names, sorted
+func formatNames() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupFormatter returns the formatter registered under a format name
+func LookupFormatter(name string) (Formatter, error) {
+	formattersMu.RLock()
+	f, ok := formatters[name]
+	formattersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, expected one of: %s", name, strings.Join(formatNames(), ", "))
+	}
+	return f, nil
+}
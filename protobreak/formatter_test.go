@@ -0,0 +1,101 @@
+package protobreak
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeReports writes the reports with the formatter registered under format
+func writeReports(w io.Writer, format string, reports []FileReport) error {
+	formatter, err := LookupFormatter(format)
+	if err != nil {
+		return err
+	}
+	return formatter.Format(w, Report{Files: reports})
+}
+
+// TestRegisterFormatter tests that a custom formatter can be registered and is used for its format
+func TestRegisterFormatter(t *testing.T) {
+	const formatCount = "count"
+	if _, err := LookupFormatter(formatCount); err == nil {
+		t.Fatal("Expected an error before the formatter is registered")
+	}
+
+	RegisterFormatter(formatCount, FormatterFunc(func(w io.Writer, r Report) error {
+		for _, report := range r.Files {
+			fmt.Fprintf(w, "%s: %d\n", report.File, len(report.BreakingChanges))
+		}
+		return nil
+	}))
+	t.Cleanup(func() { delete(formatters, formatCount) })
+
+	if _, err := LookupFormatter(formatCount); err != nil {
+		t.Fatalf("Expected the registered format to be found: %v", err)
+	}
+
+	reports := []FileReport{
+		{File: "a.proto", BreakingChanges: []BreakingChange{newChange(RuleFieldNoDelete, "removed")}},
+		{File: "b.proto"},
+	}
+	var buf bytes.Buffer
+	if err := writeReports(&buf, formatCount, reports); err != nil {
+		t.Fatalf("Failed to write reports: %v", err)
+	}
+	if expected := "a.proto: 1\nb.proto: 0\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	// Unknown formats list the registered ones
+	err := writeReports(&buf, "sarif", reports)
+	if err == nil || !strings.Contains(err.Error(), "console-tree, count, github, html, json, slack, text") {
+		t.Errorf("Expected an error listing the formats, got %v", err)
+	}
+}
+
+// TestRegisterFormatterConcurrently tests that formatters can be registered while others are looked up
+func TestRegisterFormatterConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("custom-%d", i)
+		t.Cleanup(func() { delete(formatters, name) })
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterFormatter(name, FormatterFunc(func(w io.Writer, r Report) error { return nil }))
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := LookupFormatter(formatText); err != nil {
+				t.Errorf("Expected the text format to be found: %v", err)
+			}
+			LookupFormatter("sarif")
+		}()
+	}
+	wg.Wait()
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+// Write returns an error without writing anything
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// TestFormatterWriteErrors tests that every built-in formatter returns the error of a failed write
+func TestFormatterWriteErrors(t *testing.T) {
+	reports := []FileReport{
+		{File: "a.proto", BreakingChanges: []BreakingChange{newChange(RuleFieldNoDelete, "removed").at("User", "name")}},
+		{File: "b.proto"},
+	}
+	for _, format := range []string{formatText, formatJSON, formatHTML, formatConsoleTree, formatSlack, formatGitHub} {
+		if err := writeReports(failingWriter{}, format, reports); err == nil {
+			t.Errorf("Expected the %s format to return the write error", format)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package protobreak
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// githubLevels are the workflow commands annotating each change in GitHub Actions, by severity
+var githubLevels = map[Severity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "notice",
+}
+
+// githubDataEscaper escapes the message of a workflow command
+var githubDataEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// githubPropertyEscaper escapes the property values of a workflow command
+var githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// writeGitHubReport writes a GitHub Actions workflow command per change, e.g.
+// ::error file=user.proto,line=3,col=1,title=FIELD_NO_DELETE::message, so the changes annotate the diff
+// of pull requests. The line and column are omitted when the file has no source information.
+func writeGitHubReport(w io.Writer, reports []FileReport) error {
+	for _, report := range reports {
+		for _, change := range report.BreakingChanges {
+			properties := []string{"file=" + githubPropertyEscaper.Replace(report.File)}
+			if change.Line > 0 {
+				properties = append(properties, fmt.Sprintf("line=%d", change.Line))
+				if change.Column > 0 {
+					properties = append(properties, fmt.Sprintf("col=%d", change.Column))
+				}
+			}
+			properties = append(properties, "title="+githubPropertyEscaper.Replace(change.Rule))
+			level, ok := githubLevels[change.Severity]
+			if !ok {
+				level = githubLevels[SeverityError]
+			}
+			if _, err := fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(properties, ","), githubDataEscaper.Replace(change.String())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package protobreak
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestGitHubReport tests the workflow commands written for each severity and the escaping of their values
+func TestGitHubReport(t *testing.T) {
+	removal := newChange(RuleFieldNoDelete, "Field %q (number %d) was removed from message %q\n100%% sure", "age", 2, "User").at("User", "age")
+	removal.Line, removal.Column = 3, 1
+	reports := []FileReport{
+		{File: "api/user,v1.proto", BreakingChanges: []BreakingChange{
+			removal,
+			newChange(RuleFieldSameName, `Field renamed from "a" to "b" in message "User"`),
+		}},
+		{File: "clean.proto"},
+		{File: "b.proto", BreakingChanges: []BreakingChange{{Rule: RuleMessageAdded, Severity: SeverityInfo, Message: `Message "B" was added`}}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeReports(&buf, formatGitHub, reports); err != nil {
+		t.Fatalf("Failed to write GitHub report: %v", err)
+	}
+	expected := `::error file=api/user%2Cv1.proto,line=3,col=1,title=FIELD_NO_DELETE::Field "age" (number 2) was removed from message "User"%0A100%25 sure` + "\n" +
+		`::warning file=api/user%2Cv1.proto,title=FIELD_SAME_NAME::Field renamed from "a" to "b" in message "User"` + "\n" +
+		`::notice file=b.proto,title=MESSAGE_ADDED::Message "B" was added` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
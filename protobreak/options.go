@@ -0,0 +1,241 @@
+package protobreak
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// optionChange describes an option that was added, removed or changed on a descriptor
+type optionChange struct {
+	Name string
+	// Prev and Curr are empty when the option is not set in that version
+	Prev string
+	Curr string
+}
+
+// String describes the change, e.g. `changed option (my.replacement) from "A" to "B"`
+func (c optionChange) String() string {
+	switch {
+	case c.Prev == "":
+		return fmt.Sprintf("added option %s = %s", c.Name, c.Curr)
+	case c.Curr == "":
+		return fmt.Sprintf("removed option %s = %s", c.Name, c.Prev)
+	default:
+		return fmt.Sprintf("changed option %s from %s to %s", c.Name, c.Prev, c.Curr)
+	}
+}
+
+// diffOptions compares the options set on two versions of a descriptor, sorted by option name
+func diffOptions(prev, curr protoreflect.Descriptor) []optionChange {
+	prevValues := optionValues(prev)
+	currValues := optionValues(curr)
+
+	var changes []optionChange
+	for name, prevValue := range prevValues {
+		if currValue := currValues[name]; currValue != prevValue {
+			changes = append(changes, optionChange{Name: name, Prev: prevValue, Curr: currValue})
+		}
+	}
+	for name, currValue := range currValues {
+		if _, ok := prevValues[name]; !ok {
+			changes = append(changes, optionChange{Name: name, Curr: currValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// optionValues returns the formatted value of every option set on a descriptor, keyed by name.
+// Custom options are kept as unknown fields by the parser. They are named after the matching
+// extension declared in the file or its imports, or after their field number when there is none.
+func optionValues(desc protoreflect.Descriptor) map[string]string {
+	values := make(map[string]string)
+	opts := desc.Options()
+	if opts == nil {
+		return values
+	}
+	msg := opts.ProtoReflect()
+	if !msg.IsValid() {
+		return values
+	}
+
+	msg.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		values[optionName(field)] = formatOptionValue(field, value)
+		return true
+	})
+
+	unknown := msg.GetUnknown()
+	for len(unknown) > 0 {
+		number, wireType, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			break
+		}
+		unknown = unknown[n:]
+		n = protowire.ConsumeFieldValue(number, wireType, unknown)
+		if n < 0 {
+			break
+		}
+
+		name := fmt.Sprintf("(%d)", number)
+		if ext := findExtension(desc.ParentFile(), msg.Descriptor().FullName(), number); ext != nil {
+			name = optionName(ext)
+		}
+		value := formatWireValue(wireType, unknown[:n])
+		if previous, ok := values[name]; ok {
+			// Repeated options appear once per element
+			value = previous + ", " + value
+		}
+		values[name] = value
+		unknown = unknown[n:]
+	}
+	return values
+}
+
+// optionName returns the name of an option as written in proto source, e.g. deprecated or (my.replacement)
+func optionName(field protoreflect.FieldDescriptor) string {
+	if field.IsExtension() {
+		return "(" + string(field.FullName()) + ")"
+	}
+	return string(field.Name())
+}
+
+// formatOptionValue formats the value of a known option
+func formatOptionValue(field protoreflect.FieldDescriptor, value protoreflect.Value) string {
+	if field.IsList() {
+		var items []string
+		for i := 0; i < value.List().Len(); i++ {
+			items = append(items, formatOptionValue(field, value.List().Get(i)))
+		}
+		return strings.Join(items, ", ")
+	}
+	switch field.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return fmt.Sprintf("%q", value.String())
+	case protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return string(enumValue.Name())
+		}
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// formatWireValue formats the raw encoding of an unknown option
+func formatWireValue(wireType protowire.Type, data []byte) string {
+	switch wireType {
+	case protowire.VarintType:
+		v, _ := protowire.ConsumeVarint(data)
+		return fmt.Sprint(v)
+	case protowire.Fixed32Type:
+		v, _ := protowire.ConsumeFixed32(data)
+		return fmt.Sprint(v)
+	case protowire.Fixed64Type:
+		v, _ := protowire.ConsumeFixed64(data)
+		return fmt.Sprint(v)
+	case protowire.BytesType:
+		v, _ := protowire.ConsumeBytes(data)
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%x", data)
+	}
+}
+
+// findExtension looks for an extension of the options message with the given number
+// declared in file, its messages or its imports
+func findExtension(file protoreflect.FileDescriptor, extendee protoreflect.FullName, number protoreflect.FieldNumber) protoreflect.ExtensionDescriptor {
+	if file == nil {
+		return nil
+	}
+	if ext := findExtensionIn(file.Extensions(), file.Messages(), extendee, number); ext != nil {
+		return ext
+	}
+	for i := 0; i < file.Imports().Len(); i++ {
+		if ext := findExtension(file.Imports().Get(i).FileDescriptor, extendee, number); ext != nil {
+			return ext
+		}
+	}
+	return nil
+}
+
+// findExtensionIn looks for a matching extension in exts or the extensions nested in msgs
+func findExtensionIn(exts protoreflect.ExtensionDescriptors, msgs protoreflect.MessageDescriptors, extendee protoreflect.FullName, number protoreflect.FieldNumber) protoreflect.ExtensionDescriptor {
+	for i := 0; i < exts.Len(); i++ {
+		if ext := exts.Get(i); ext.Number() == number && ext.ContainingMessage().FullName() == extendee {
+			return ext
+		}
+	}
+	for i := 0; i < msgs.Len(); i++ {
+		if ext := findExtensionIn(msgs.Get(i).Extensions(), msgs.Get(i).Messages(), extendee, number); ext != nil {
+			return ext
+		}
+	}
+	return nil
+}
+
+// httpRuleNumber is the field number of the google.api.http method option
+const httpRuleNumber = 72295728
+
+// httpRuleMethods are the HTTP methods of the HttpRule pattern fields, by field number
+var httpRuleMethods = map[protowire.Number]string{2: "GET", 3: "PUT", 4: "POST", 5: "DELETE", 6: "PATCH"}
+
+// httpBinding returns the REST endpoint bound to a method by its google.api.http option, e.g. GET /v1/users/{id}.
+// The option is read from the encoded method options, whether or not its extension was resolved by the parser.
+func httpBinding(method protoreflect.MethodDescriptor) (string, bool) {
+	opts := method.Options()
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return "", false
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(opts)
+	if err != nil {
+		return "", false
+	}
+	rule, ok := findBytesField(data, httpRuleNumber)
+	if !ok {
+		return "", false
+	}
+	for number, name := range httpRuleMethods {
+		if path, ok := findBytesField(rule, number); ok {
+			return name + " " + string(path), true
+		}
+	}
+	// custom is a CustomHttpPattern holding the kind and path of other methods
+	if custom, ok := findBytesField(rule, 8); ok {
+		kind, _ := findBytesField(custom, 1)
+		path, _ := findBytesField(custom, 2)
+		return string(kind) + " " + string(path), true
+	}
+	return "", false
+}
+
+// findBytesField returns the last length-delimited value of the field with the given number in an encoded message
+func findBytesField(data []byte, number protowire.Number) ([]byte, bool) {
+	var value []byte
+	found := false
+	for len(data) > 0 {
+		num, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			break
+		}
+		data = data[n:]
+		if num == number && wireType == protowire.BytesType {
+			v, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				break
+			}
+			value, found = v, true
+		}
+		n = protowire.ConsumeFieldValue(num, wireType, data)
+		if n < 0 {
+			break
+		}
+		data = data[n:]
+	}
+	return value, found
+}
@@ -0,0 +1,78 @@
+// Package protobreak detects breaking changes between two versions of protobuf files.
+// Compare returns the changes of a file, and the formatters write them as reports.
+package protobreak
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// options holds the settings that control how files are compared
+type options struct {
+	rules RuleSet
+	// softReserved are the field number ranges checked by FIELD_NO_ADD_IN_SOFT_RESERVED
+	softReserved []softReservedRange
+	// fileOptionSeverities overrides the severity of FILE_SAME_OPTIONS changes by option name
+	fileOptionSeverities map[string]Severity
+}
+
+// Option configures a comparison
+type Option func(*options)
+
+// WithSeverities overrides the severity of rules by ID. SeverityOff disables a rule, and any other
+// severity enables it, including opt-in rules. Rules that are not listed keep their default severity.
+func WithSeverities(severities map[string]Severity) Option {
+	return func(o *options) {
+		for id, severity := range severities {
+			if _, ok := o.rules[id]; ok {
+				o.rules[id] = severity
+			}
+		}
+	}
+}
+
+// WithSoftReserved adds an inclusive range of field numbers that new fields should not use, checked by
+// the opt-in FIELD_NO_ADD_IN_SOFT_RESERVED rule. An empty message applies the range to every message,
+// and reason is appended to the warnings.
+func WithSoftReserved(message string, start, end int32, reason string) Option {
+	return func(o *options) {
+		o.softReserved = append(o.softReserved, softReservedRange{Message: message, Start: start, End: end, Reason: reason})
+	}
+}
+
+// WithFileOptionSeverities sets the severity of changes to file options by option name, such as
+// go_package or (acme.api_version), overriding the severity of FILE_SAME_OPTIONS
+func WithFileOptionSeverities(severities map[string]Severity) Option {
+	return func(o *options) {
+		o.fileOptionSeverities = severities
+	}
+}
+
+// newOptions returns the default options with opts applied in order
+func newOptions(opts []Option) options {
+	o := options{rules: DefaultRuleSet()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Compare returns the changes between the previous and current versions of a file, sorted by the
+// path of the changed element and then by rule. Changes suppressed by ignore comments in the current file are
+// left out, and the others are located in it.
+func Compare(prev, curr protoreflect.FileDescriptor, opts ...Option) []BreakingChange {
+	return compareFiles(prev, curr, newOptions(opts))
+}
+
+// CompareMessage returns the changes between the previous and current versions of a single message,
+// without its nested messages. A nil curr reports the message as removed.
+func CompareMessage(prev, curr protoreflect.MessageDescriptor, opts ...Option) []BreakingChange {
+	o := newOptions(opts)
+	msgName := relativeName(prev)
+	if curr == nil {
+		return o.rules.Filter([]BreakingChange{newChange(RuleMessageNoDelete, "Message %q was removed", msgName).at(msgName)})
+	}
+	if !o.rules.categoryEnabled(categoryMessage) {
+		return nil
+	}
+	return o.rules.Filter(compareMessage(msgName, prev, curr, o.rules))
+}
@@ -0,0 +1,69 @@
+package protobreak_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// parseSource parses a single proto source held in memory
+func parseSource(t *testing.T, name, content string) *desc.FileDescriptor {
+	t.Helper()
+
+	parser := protoparse.Parser{
+		Accessor:              protoparse.FileContentsFromMap(map[string]string{name: content}),
+		IncludeSourceCodeInfo: true,
+	}
+	fileDescs, err := parser.ParseFiles(name)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", name, err)
+	}
+	return fileDescs[0]
+}
+
+// TestCompare tests calling the exported Compare on two parsed descriptors, as embedding tools do
+func TestCompare(t *testing.T) {
+	prev := parseSource(t, "user.proto", `
+		syntax = "proto3";
+		package acme.users.v1;
+		message User {
+			string name = 1;
+			int32 age = 2;
+		}
+	`)
+	curr := parseSource(t, "user.proto", `
+		syntax = "proto3";
+		package acme.users.v1;
+		message User {
+			string name = 1;
+			reserved 2;
+			reserved "age";
+		}
+	`)
+
+	changes := protobreak.Compare(prev.UnwrapFile(), curr.UnwrapFile())
+	expected := protobreak.BreakingChange{
+		Rule:     protobreak.RuleFieldNoDelete,
+		Severity: protobreak.SeverityError,
+		Message:  `Field "age" (number 2) was removed from message "User"`,
+		Path:     []string{"User", "age"},
+		Line:     4,
+		Column:   17,
+	}
+	if !reflect.DeepEqual(changes, []protobreak.BreakingChange{expected}) {
+		t.Errorf("Expected %+v, got %+v", expected, changes)
+	}
+
+	if changes := protobreak.Compare(curr.UnwrapFile(), curr.UnwrapFile()); len(changes) != 0 {
+		t.Errorf("Expected no changes for identical files, got %v", changes)
+	}
+
+	// Rules turned off through an option are not reported
+	off := protobreak.WithSeverities(map[string]protobreak.Severity{protobreak.RuleFieldNoDelete: protobreak.SeverityOff})
+	if changes := protobreak.Compare(prev.UnwrapFile(), curr.UnwrapFile(), off); len(changes) != 0 {
+		t.Errorf("Expected no changes with %s off, got %v", protobreak.RuleFieldNoDelete, changes)
+	}
+}
@@ -0,0 +1,168 @@
+package protobreak
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// FileReport holds the changes detected in a single file
+type FileReport struct {
+	File            string           `json:"file"`
+	BreakingChanges []BreakingChange `json:"breaking_changes"`
+}
+
+// writeTextReport writes the changes of a file, with warnings and info notes printed separately.
+// The file is written in one go, so the first write error is returned.
+func writeTextReport(w io.Writer, report FileReport) error {
+	errors := FilterSeverity(report.BreakingChanges, SeverityError)
+	warnings := FilterSeverity(report.BreakingChanges, SeverityWarning)
+	infos := FilterSeverity(report.BreakingChanges, SeverityInfo)
+
+	var b strings.Builder
+	if len(errors) == 0 {
+		fmt.Fprintf(&b, "✅ No breaking changes detected in %s\n", report.File)
+	} else {
+		fmt.Fprintf(&b, "🔴 Detected %d breaking changes in %s:\n", len(errors), report.File)
+		for _, change := range errors {
+			fmt.Fprintf(&b, "  - %s\n", change)
+		}
+	}
+	if len(warnings) > 0 {
+		fmt.Fprintf(&b, "🟡 Detected %d warnings in %s:\n", len(warnings), report.File)
+		for _, change := range warnings {
+			fmt.Fprintf(&b, "  - %s\n", change)
+		}
+	}
+	if len(infos) > 0 {
+		fmt.Fprintf(&b, "ℹ️ %d notes in %s:\n", len(infos), report.File)
+		for _, change := range infos {
+			fmt.Fprintf(&b, "  - %s\n", change)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeJSONReport writes all reports as a JSON array, using the structure of the server responses.
+// Empty lists are written as [] rather than null.
+func writeJSONReport(w io.Writer, reports []FileReport) error {
+	encoded := make([]FileReport, 0, len(reports))
+	for _, report := range reports {
+		changes := report.BreakingChanges
+		if changes == nil {
+			changes = []BreakingChange{}
+		}
+		encoded = append(encoded, FileReport{File: report.File, BreakingChanges: changes})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(encoded)
+}
+
+// htmlRow is a single change in the HTML report
+type htmlRow struct {
+	File string
+	BreakingChange
+	// Rank sorts the severity column from errors to info notes, see severityRank
+	Rank int
+}
+
+// htmlReport is the data rendered by htmlTemplate
+type htmlReport struct {
+	Files    int
+	Errors   int
+	Warnings int
+	Rows     []htmlRow
+	// Clean lists the files without any changes
+	Clean []string
+}
+
+// writeHTMLReport writes a self-contained HTML page with a sortable table of all changes,
+// grouped by file and severity
+func writeHTMLReport(w io.Writer, reports []FileReport) error {
+	data := htmlReport{Files: len(reports)}
+	for _, report := range reports {
+		if len(report.BreakingChanges) == 0 {
+			data.Clean = append(data.Clean, report.File)
+		}
+		for _, change := range report.BreakingChanges {
+			data.Rows = append(data.Rows, htmlRow{File: report.File, BreakingChange: change, Rank: severityRank(change.Severity)})
+			switch change.Severity {
+			case SeverityError:
+				data.Errors++
+			case SeverityWarning:
+				data.Warnings++
+			}
+		}
+	}
+
+	sort.SliceStable(data.Rows, func(i, j int) bool {
+		if data.Rows[i].File != data.Rows[j].File {
+			return data.Rows[i].File < data.Rows[j].File
+		}
+		return data.Rows[i].Rank < data.Rows[j].Rank
+	})
+	sort.Strings(data.Clean)
+
+	return htmlTemplate.Execute(w, data)
+}
+
+// htmlTemplate renders the HTML report without any external assets
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Proto Breaking Change Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+.summary span { display: inline-block; margin-right: 2em; font-size: 1.2em; }
+table { border-collapse: collapse; width: 100%; margin-top: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.4em 0.6em; text-align: left; }
+th { background: #f0f0f0; cursor: pointer; user-select: none; }
+tr.ERROR td.severity { color: #b00020; font-weight: bold; }
+tr.WARNING td.severity { color: #a06000; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Proto Breaking Change Report</h1>
+<div class="summary">
+<span>Files: <strong id="file-count">{{.Files}}</strong></span>
+<span>Breaking changes: <strong id="error-count">{{.Errors}}</strong></span>
+<span>Warnings: <strong id="warning-count">{{.Warnings}}</strong></span>
+</div>
+{{if .Rows}}<table id="changes">
+<thead><tr><th>File</th><th>Severity</th><th>Rule</th><th>Message</th></tr></thead>
+<tbody>
+{{range .Rows}}<tr class="change {{.Severity}}"><td>{{.File}}</td><td class="severity" data-sort="{{.Rank}}">{{.Severity}}</td><td>{{.Rule}}</td><td>{{.Message}}</td></tr>
+{{end}}</tbody>
+</table>
+<script>
+document.querySelectorAll("#changes th").forEach(function (header, column) {
+  header.addEventListener("click", function () {
+    var body = document.querySelector("#changes tbody");
+    var ascending = header.dataset.order !== "asc";
+    header.dataset.order = ascending ? "asc" : "desc";
+    Array.from(body.rows)
+      .sort(function (a, b) {
+        var key = function (row) { return row.cells[column].dataset.sort || row.cells[column].textContent; };
+        var result = key(a).localeCompare(key(b));
+        return ascending ? result : -result;
+      })
+      .forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+{{else}}<p>No breaking changes detected.</p>
+{{end}}{{if .Clean}}<h2>Files without breaking changes</h2>
+<ul id="clean-files">
+{{range .Clean}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
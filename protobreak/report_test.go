@@ -0,0 +1,132 @@
+package protobreak
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestHTMLReport tests that the HTML report has a row per change and summary counts
+func TestHTMLReport(t *testing.T) {
+	reports := []FileReport{
+		{
+			File: "b.proto",
+			BreakingChanges: []BreakingChange{
+				{Rule: RuleFieldAdded, Severity: SeverityInfo, Message: `Field "email" (number 3) was added to message "User"`},
+				{Rule: RuleFieldWireCompatibleType, Severity: SeverityWarning, Message: `Field "data" type changed from string to bytes`},
+				{Rule: RuleFieldNoDelete, Severity: SeverityError, Message: `Field "age" (number 2) was removed from message "User"`},
+			},
+		},
+		{
+			File:            "clean.proto",
+			BreakingChanges: nil,
+		},
+		{
+			File: "a.proto",
+			BreakingChanges: []BreakingChange{
+				{Rule: RuleMessageNoDelete, Severity: SeverityError, Message: `Message "Old" was removed`},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeReports(&buf, formatHTML, reports); err != nil {
+		t.Fatalf("Failed to write HTML report: %v", err)
+	}
+	html := buf.String()
+
+	if rows := strings.Count(html, `<tr class="change `); rows != 4 {
+		t.Errorf("Expected 4 change rows, got %d", rows)
+	}
+	for _, summary := range []string{
+		`<strong id="file-count">3</strong>`,
+		`<strong id="error-count">2</strong>`,
+		`<strong id="warning-count">1</strong>`,
+	} {
+		if !strings.Contains(html, summary) {
+			t.Errorf("Expected summary %s in report", summary)
+		}
+	}
+
+	// Rows are grouped by file, then by severity from errors to info notes
+	order := []string{`Message &#34;Old&#34; was removed`, `Field &#34;age&#34; (number 2)`, `Field &#34;data&#34; type changed`, `Field &#34;email&#34; (number 3)`}
+	last := -1
+	for _, message := range order {
+		index := strings.Index(html, message)
+		if index < 0 {
+			t.Fatalf("Expected %s in report", message)
+		}
+		if index < last {
+			t.Errorf("Expected %s to be listed after the previous rows", message)
+		}
+		last = index
+	}
+
+	if strings.Contains(html, "<link") || strings.Contains(html, "src=") {
+		t.Error("Expected the report to be self-contained")
+	}
+}
+
+// TestJSONReport tests the exact shape of the JSON report, which carries the structured changes of every file
+func TestJSONReport(t *testing.T) {
+	reports := []FileReport{
+		{
+			File: "user.proto",
+			BreakingChanges: []BreakingChange{{
+				Rule:     RuleFieldNoDelete,
+				Severity: SeverityError,
+				Message:  `Field "age" (number 2) was removed from message "User"`,
+				Path:     []string{"User", "age"},
+				Line:     3,
+				Column:   5,
+			}},
+		},
+		{File: "clean.proto"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeReports(&buf, formatJSON, reports); err != nil {
+		t.Fatalf("Failed to write JSON report: %v", err)
+	}
+
+	expected := `[
+  {
+    "file": "user.proto",
+    "breaking_changes": [
+      {
+        "type": "field_removed",
+        "rule": "FIELD_NO_DELETE",
+        "severity": "ERROR",
+        "message": "Field \"age\" (number 2) was removed from message \"User\"",
+        "path": "User.age",
+        "line": 3,
+        "column": 5
+      }
+    ]
+  },
+  {
+    "file": "clean.proto",
+    "breaking_changes": []
+  }
+]
+`
+	if buf.String() != expected {
+		t.Errorf("Expected JSON report:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// The dotted path is read back into its elements
+	var decoded []FileReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode JSON report: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(decoded[0].BreakingChanges, reports[0].BreakingChanges) {
+		t.Errorf("Expected %v, got %v", reports[0].BreakingChanges, decoded[0].BreakingChanges)
+	}
+
+	buf.Reset()
+	if err := writeReports(&buf, formatJSON, nil); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("Expected an empty array without reports, got %q (%v)", buf.String(), err)
+	}
+}
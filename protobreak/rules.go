@@ -0,0 +1,517 @@
+package protobreak
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Rule identifiers reported in BreakingChange.Rule, one for every check performed by Compare
+const (
+	RuleMessageNoDelete           = "MESSAGE_NO_DELETE"
+	RuleMessageAdded              = "MESSAGE_ADDED"
+	RuleRPCMessageNoDelete        = "RPC_MESSAGE_NO_DELETE"
+	RuleMessageSameWireFormat     = "MESSAGE_SAME_MESSAGE_SET_WIRE_FORMAT"
+	RuleMessageSameMapEntry       = "MESSAGE_SAME_MAP_ENTRY"
+	RuleFieldNoDelete             = "FIELD_NO_DELETE"
+	RuleFieldAdded                = "FIELD_ADDED"
+	RuleFieldMovedToNested        = "FIELD_MOVED_TO_NESTED_MESSAGE"
+	RuleFieldRemovedNotReserved   = "FIELD_REMOVED_NOT_RESERVED"
+	RuleFieldSameName             = "FIELD_SAME_NAME"
+	RuleFieldUniqueNumber         = "FIELD_UNIQUE_NUMBER"
+	RuleFieldNoNumberReuse        = "FIELD_NO_NUMBER_REUSE"
+	RuleReservedNoDelete          = "RESERVED_NUMBER_NO_DELETE"
+	RuleFieldNoExtensionOverlap   = "FIELD_NO_EXTENSION_RANGE_OVERLAP"
+	RuleFieldSameType             = "FIELD_SAME_TYPE"
+	RuleFieldWireCompatibleType   = "FIELD_WIRE_COMPATIBLE_TYPE"
+	RuleFieldSameSignedness       = "FIELD_SAME_SIGNEDNESS"
+	RuleFieldSameZigZag           = "FIELD_SAME_ZIGZAG"
+	RuleFieldSameMessageEncoding  = "FIELD_SAME_MESSAGE_ENCODING"
+	RuleFieldIntEnumMigration     = "FIELD_INT_ENUM_MIGRATION"
+	RuleFieldSameCardinality      = "FIELD_SAME_CARDINALITY"
+	RuleFieldNoNewRequired        = "FIELD_NO_NEW_REQUIRED"
+	RuleRequiredFieldAdded        = "REQUIRED_FIELD_ADDED"
+	RuleFieldSamePresence         = "FIELD_SAME_PRESENCE"
+	RuleFieldSameLazy             = "FIELD_SAME_LAZY"
+	RuleFieldSameWeak             = "FIELD_SAME_WEAK"
+	RuleFieldSamePacked           = "FIELD_SAME_PACKED"
+	RuleFieldNoMapConversion      = "FIELD_NO_MAP_CONVERSION"
+	RuleMapKeyNoNarrowing         = "MAP_KEY_NO_NARROWING"
+	RuleMapEnumValueSameZeroValue = "MAP_ENUM_VALUE_SAME_ZERO_VALUE"
+	RuleFieldSameTextName         = "FIELD_SAME_TEXT_NAME"
+	RuleFieldSameJSONName         = "FIELD_SAME_JSON_NAME"
+	RuleFieldSameJSONType         = "FIELD_SAME_JSON_TYPE"
+	RuleFieldSameOneof            = "FIELD_SAME_ONEOF"
+	RuleOneofNoWrapExistingFields = "ONEOF_NO_WRAP_EXISTING_FIELDS"
+	RuleFieldNoAddInSoftReserved  = "FIELD_NO_ADD_IN_SOFT_RESERVED"
+	RuleFieldBecameRepeated       = "FIELD_BECAME_REPEATED"
+	RuleEnumNoDelete              = "ENUM_NO_DELETE"
+	RuleEnumMoved                 = "ENUM_MOVED"
+	RuleEnumValueNoDelete         = "ENUM_VALUE_NO_DELETE"
+	RuleEnumValueAdded            = "ENUM_VALUE_ADDED"
+	RuleEnumValueSameName         = "ENUM_VALUE_SAME_NAME"
+	RuleEnumValueSameNumber       = "ENUM_VALUE_SAME_NUMBER"
+	RuleEnumSameZeroValue         = "ENUM_SAME_ZERO_VALUE"
+	RuleEnumValueSameOptions      = "ENUM_VALUE_SAME_OPTIONS"
+	RuleServiceNoDelete           = "SERVICE_NO_DELETE"
+	RuleServiceAdded              = "SERVICE_ADDED"
+	RuleServiceNoRewrite          = "SERVICE_NO_REWRITE"
+	RuleRPCNoDelete               = "RPC_NO_DELETE"
+	RuleRPCAdded                  = "RPC_ADDED"
+	RuleRPCSameRequestType        = "RPC_SAME_REQUEST_TYPE"
+	RuleRPCSameResponseType       = "RPC_SAME_RESPONSE_TYPE"
+	RuleRPCSameClientStreaming    = "RPC_SAME_CLIENT_STREAMING"
+	RuleRPCSameServerStreaming    = "RPC_SAME_SERVER_STREAMING"
+	RuleFileSameSyntax            = "FILE_SAME_SYNTAX"
+	RuleFileSameEdition           = "FILE_SAME_EDITION"
+	RuleFileSamePackage           = "FILE_SAME_PACKAGE"
+	RuleFileNoDelete              = "FILE_NO_DELETE"
+	RuleFileSameOptions           = "FILE_SAME_OPTIONS"
+	RuleDeprecationAdded          = "DEPRECATION_ADDED"
+	RuleFieldDeprecated           = "FIELD_DEPRECATED"
+)
+
+// Severity classifies how serious a change is
+type Severity string
+
+// Severity levels, from most to least serious
+const (
+	SeverityError   Severity = "ERROR"
+	SeverityWarning Severity = "WARNING"
+	// SeverityInfo marks safe changes that are only reported for review
+	SeverityInfo Severity = "INFO"
+	// SeverityOff disables a rule
+	SeverityOff Severity = "OFF"
+)
+
+// severityRank orders severities from most to least serious: errors, warnings, then info notes
+func severityRank(severity Severity) int {
+	switch severity {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	case SeverityInfo:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Rule categories, matching the comparison pass that produces them
+const (
+	categoryMessage = "message"
+	categoryEnum    = "enum"
+	categoryService = "service"
+	categoryFile    = "file"
+)
+
+// Rule describes a single breaking change check
+type Rule struct {
+	ID          string
+	Category    string
+	Description string
+	// Severity defaults to SeverityError when empty
+	Severity Severity
+	// OptIn rules only run when explicitly requested
+	OptIn bool
+}
+
+// allRules lists every known rule in the order they are reported
+var allRules = []Rule{
+	{ID: RuleMessageNoDelete, Category: categoryMessage, Description: "Messages must not be removed"},
+	{ID: RuleMessageAdded, Category: categoryMessage, Severity: SeverityInfo, OptIn: true, Description: "New messages are listed as notes"},
+	{ID: RuleRPCMessageNoDelete, Category: categoryMessage,
+		Description: "Messages used as a method input or output must not be removed"},
+	{ID: RuleMessageSameWireFormat, Category: categoryMessage,
+		Description: "Messages must not toggle message_set_wire_format, which changes their whole encoding"},
+	{ID: RuleMessageSameMapEntry, Category: categoryMessage,
+		Description: "Messages must not toggle map_entry, which changes whether they are a type or the entries of a map"},
+	{ID: RuleFieldNoDelete, Category: categoryMessage, Description: "Fields must not be removed"},
+	{ID: RuleFieldAdded, Category: categoryMessage, Severity: SeverityInfo, OptIn: true, Description: "New fields are listed as notes"},
+	{ID: RuleFieldRemovedNotReserved, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Removed fields should have their number and name reserved, so that they are not reused"},
+	{ID: RuleFieldMovedToNested, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Fields should not move into a new nested message, which hides them from consumers of the outer message"},
+	{ID: RuleFieldSameName, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Fields should not be renamed, which breaks generated code but not the binary encoding"},
+	{ID: RuleFieldUniqueNumber, Category: categoryMessage, Description: "Fields must not share a number with another field of the message"},
+	{ID: RuleFieldNoNumberReuse, Category: categoryMessage,
+		Description: "Field numbers must not be reused by a field with a different name and type"},
+	{ID: RuleReservedNoDelete, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Reserved field numbers should stay reserved, so that the numbers of deleted fields are not reused"},
+	{ID: RuleFieldNoExtensionOverlap, Category: categoryMessage, Description: "Fields must not use a number inside an extension range of the message"},
+	{ID: RuleFieldSameType, Category: categoryMessage, Description: "Fields must not change to a type with a different wire type or integer encoding"},
+	{ID: RuleFieldWireCompatibleType, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Fields should not change type, even when the wire type is preserved"},
+	{ID: RuleFieldSameSignedness, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Integer fields should not change between signed and unsigned types, which corrupts negative values"},
+	{ID: RuleFieldSameZigZag, Category: categoryMessage,
+		Description: "Integer fields must not change between the zigzag-encoded sint types and the int or uint types, which corrupts values"},
+	{ID: RuleFieldSameMessageEncoding, Category: categoryMessage,
+		Description: "Message fields must not switch between the length-prefixed and delimited encodings"},
+	{ID: RuleFieldIntEnumMigration, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Fields migrating between int32 and an enum are wire-compatible but change the accepted values"},
+	{ID: RuleFieldSameCardinality, Category: categoryMessage, Description: "Repeated fields must not become singular"},
+	{ID: RuleFieldNoNewRequired, Category: categoryMessage, Description: "Existing fields must not become required"},
+	{ID: RuleRequiredFieldAdded, Category: categoryMessage,
+		Description: "Required fields must not be added, which breaks parsing of old messages and callers of generated builders"},
+	{ID: RuleFieldSamePresence, Category: categoryMessage,
+		Description: "Fields must not lose explicit presence when the file syntax changes"},
+	{ID: RuleFieldSameLazy, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Message fields should keep their lazy option, which changes when they are parsed and validated"},
+	{ID: RuleFieldSameWeak, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Message fields should keep their weak option, which changes whether their type is linked into builds"},
+	{ID: RuleFieldSamePacked, Category: categoryMessage, Severity: SeverityWarning,
+		Description: "Repeated scalar fields should keep their effective packed encoding, which older readers may not accept"},
+	{ID: RuleFieldNoMapConversion, Category: categoryMessage,
+		Description: "Repeated entry message fields must not be converted to or from maps, whose entries have a fixed layout"},
+	{ID: RuleMapKeyNoNarrowing, Category: categoryMessage, Description: "Map keys must not be narrowed to a smaller integer type"},
+	{ID: RuleMapEnumValueSameZeroValue, Category: categoryMessage,
+		Description: "Enums used as map values must keep the same zero value, which is the default of missing entries"},
+	{ID: RuleFieldSameTextName, Category: categoryMessage, OptIn: true,
+		Description: "Fields must not be renamed when text format data depends on them"},
+	{ID: RuleFieldSameOneof, Category: categoryMessage, Description: "Fields must not move into an existing oneof, out of a oneof or between oneofs"},
+	{ID: RuleFieldSameJSONName, Category: categoryMessage, OptIn: true,
+		Description: "Fields must keep their JSON name when JSON clients depend on them"},
+	{ID: RuleFieldSameJSONType, Category: categoryMessage, OptIn: true,
+		Description: "Fields must not change to a type with a different JSON encoding when JSON clients depend on them, which replaces the FIELD_WIRE_COMPATIBLE_TYPE warning"},
+	{ID: RuleOneofNoWrapExistingFields, Category: categoryMessage, OptIn: true,
+		Description: "Existing fields must not be moved into a newly added oneof"},
+	{ID: RuleFieldNoAddInSoftReserved, Category: categoryMessage, Severity: SeverityWarning, OptIn: true,
+		Description: "New fields should not use numbers in the soft-reserved ranges of the config"},
+	{ID: RuleFieldBecameRepeated, Category: categoryMessage, Severity: SeverityInfo, OptIn: true,
+		Description: "Singular fields becoming repeated stay wire-compatible but change the generated types"},
+	{ID: RuleEnumNoDelete, Category: categoryEnum, Description: "Enums must not be removed"},
+	{ID: RuleEnumMoved, Category: categoryEnum, Severity: SeverityWarning,
+		Description: "Enums should not move into or out of a message, which changes their full name"},
+	{ID: RuleEnumValueNoDelete, Category: categoryEnum, Description: "Enum values must not be removed"},
+	{ID: RuleEnumValueAdded, Category: categoryEnum, Severity: SeverityInfo, OptIn: true, Description: "New enum values are listed as notes"},
+	{ID: RuleEnumValueSameName, Category: categoryEnum, Severity: SeverityWarning,
+		Description: "Enum values should not be renamed, which breaks generated code but not the binary encoding"},
+	{ID: RuleEnumValueSameNumber, Category: categoryEnum, Description: "Enum values must keep their number"},
+	{ID: RuleEnumSameZeroValue, Category: categoryEnum, Description: "Enums must keep the number of their default (first declared) value and their zero value"},
+	{ID: RuleEnumValueSameOptions, Category: categoryEnum, Severity: SeverityWarning,
+		Description: "Enum values should keep their options, such as custom lifecycle annotations"},
+	{ID: RuleServiceNoDelete, Category: categoryService, Description: "Services must not be removed"},
+	{ID: RuleServiceAdded, Category: categoryService, Severity: SeverityInfo, OptIn: true, Description: "New services are listed as notes"},
+	{ID: RuleServiceNoRewrite, Category: categoryService, Severity: SeverityWarning,
+		Description: "Services should not change the signature of most of their methods at once"},
+	{ID: RuleRPCNoDelete, Category: categoryService, Description: "Methods must not be removed"},
+	{ID: RuleRPCAdded, Category: categoryService, Severity: SeverityInfo, OptIn: true, Description: "New methods are listed as notes"},
+	{ID: RuleRPCSameRequestType, Category: categoryService, Description: "Methods must not change their input type"},
+	{ID: RuleRPCSameResponseType, Category: categoryService, Description: "Methods must not change their output type"},
+	{ID: RuleRPCSameClientStreaming, Category: categoryService, Description: "Methods must not change client streaming"},
+	{ID: RuleRPCSameServerStreaming, Category: categoryService, Description: "Methods must not change server streaming"},
+	{ID: RuleFileSameSyntax, Category: categoryFile, Severity: SeverityWarning,
+		Description: "Files should keep the same syntax, which changes field defaults and presence"},
+	{ID: RuleFileSameEdition, Category: categoryFile, Severity: SeverityWarning,
+		Description: "Files should keep the same edition, which changes the default features"},
+	{ID: RuleFileSamePackage, Category: categoryFile,
+		Description: "Files must keep their package, which is part of the full name of every type they declare"},
+	{ID: RuleFileNoDelete, Category: categoryFile, Description: "Files must not be removed"},
+	{ID: RuleFileSameOptions, Category: categoryFile, Severity: SeverityInfo,
+		Description: "File options that change generated code, such as go_package, are listed, including unknown custom options"},
+	{ID: RuleDeprecationAdded, Category: categoryFile, Severity: SeverityInfo, OptIn: true,
+		Description: "Newly deprecated messages, enums, enum values, services and methods are listed for sign-off"},
+	{ID: RuleFieldDeprecated, Category: categoryMessage, Severity: SeverityInfo,
+		Description: "Newly deprecated fields are listed as notes"},
+}
+
+// changeTypes names the kind of change reported by each rule in the type field of JSON output
+var changeTypes = map[string]string{
+	RuleMessageNoDelete:           "message_removed",
+	RuleMessageAdded:              "message_added",
+	RuleRPCMessageNoDelete:        "rpc_message_removed",
+	RuleMessageSameWireFormat:     "message_wire_format_changed",
+	RuleMessageSameMapEntry:       "message_map_entry_changed",
+	RuleFieldNoDelete:             "field_removed",
+	RuleFieldAdded:                "field_added",
+	RuleFieldRemovedNotReserved:   "field_removed_not_reserved",
+	RuleFieldMovedToNested:        "field_moved_to_nested_message",
+	RuleFieldSameName:             "field_renamed",
+	RuleFieldUniqueNumber:         "field_number_duplicated",
+	RuleFieldNoNumberReuse:        "field_number_reused",
+	RuleReservedNoDelete:          "reserved_number_removed",
+	RuleFieldNoExtensionOverlap:   "field_in_extension_range",
+	RuleFieldSameType:             "field_type_changed",
+	RuleFieldWireCompatibleType:   "field_type_changed_compatibly",
+	RuleFieldSameSignedness:       "field_signedness_changed",
+	RuleFieldSameZigZag:           "field_zigzag_changed",
+	RuleFieldSameMessageEncoding:  "field_message_encoding_changed",
+	RuleFieldIntEnumMigration:     "field_int_enum_migrated",
+	RuleFieldSameCardinality:      "field_cardinality_changed",
+	RuleFieldNoNewRequired:        "field_became_required",
+	RuleRequiredFieldAdded:        "required_field_added",
+	RuleFieldSamePresence:         "field_presence_changed",
+	RuleFieldSameLazy:             "field_lazy_changed",
+	RuleFieldSameWeak:             "field_weak_changed",
+	RuleFieldSamePacked:           "field_packed_changed",
+	RuleFieldNoMapConversion:      "field_map_conversion",
+	RuleMapKeyNoNarrowing:         "map_key_narrowed",
+	RuleMapEnumValueSameZeroValue: "map_enum_value_zero_value_changed",
+	RuleFieldSameTextName:         "field_text_name_changed",
+	RuleFieldSameOneof:            "field_oneof_changed",
+	RuleFieldSameJSONName:         "field_json_name_changed",
+	RuleFieldSameJSONType:         "field_json_type_changed",
+	RuleOneofNoWrapExistingFields: "oneof_wraps_existing_fields",
+	RuleFieldNoAddInSoftReserved:  "field_added_in_soft_reserved_range",
+	RuleFieldBecameRepeated:       "field_became_repeated",
+	RuleEnumNoDelete:              "enum_removed",
+	RuleEnumMoved:                 "enum_moved",
+	RuleEnumValueNoDelete:         "enum_value_removed",
+	RuleEnumValueAdded:            "enum_value_added",
+	RuleEnumValueSameName:         "enum_value_renamed",
+	RuleEnumValueSameNumber:       "enum_value_number_changed",
+	RuleEnumSameZeroValue:         "enum_zero_value_changed",
+	RuleEnumValueSameOptions:      "enum_value_options_changed",
+	RuleServiceNoDelete:           "service_removed",
+	RuleServiceAdded:              "service_added",
+	RuleServiceNoRewrite:          "service_rewritten",
+	RuleRPCNoDelete:               "rpc_removed",
+	RuleRPCAdded:                  "rpc_added",
+	RuleRPCSameRequestType:        "rpc_request_type_changed",
+	RuleRPCSameResponseType:       "rpc_response_type_changed",
+	RuleRPCSameClientStreaming:    "rpc_client_streaming_changed",
+	RuleRPCSameServerStreaming:    "rpc_server_streaming_changed",
+	RuleFileSameSyntax:            "file_syntax_changed",
+	RuleFileSameEdition:           "file_edition_changed",
+	RuleFileSamePackage:           "file_package_changed",
+	RuleFileNoDelete:              "file_removed",
+	RuleFileSameOptions:           "file_options_changed",
+	RuleDeprecationAdded:          "deprecation_added",
+	RuleFieldDeprecated:           "field_deprecated",
+}
+
+// defaultSeverity returns the severity of the rule when no configuration overrides it
+func (r Rule) defaultSeverity() Severity {
+	if r.Severity == "" {
+		return SeverityError
+	}
+	return r.Severity
+}
+
+// Rules returns every known rule in the order they are reported, with their default severity
+func Rules() []Rule {
+	rules := make([]Rule, len(allRules))
+	for i, rule := range allRules {
+		rule.Severity = rule.defaultSeverity()
+		rules[i] = rule
+	}
+	return rules
+}
+
+// LookupRule looks up a rule by ID, with its default severity
+func LookupRule(id string) (Rule, bool) {
+	for _, rule := range allRules {
+		if rule.ID == id {
+			rule.Severity = rule.defaultSeverity()
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// ParseSeverity parses a severity name case-insensitively
+func ParseSeverity(value string) (Severity, error) {
+	severity := Severity(strings.ToUpper(strings.TrimSpace(value)))
+	switch severity {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		return severity, nil
+	default:
+		return "", fmt.Errorf("unknown severity %q", value)
+	}
+}
+
+// BreakingChange describes a single incompatible change between two versions of a file
+type BreakingChange struct {
+	Rule     string
+	Severity Severity
+	Message  string
+	// Path locates the changed element within the file, e.g. a message and one of its fields
+	Path []string
+	// Line and Column locate the element, or the closest one enclosing it, in the current file.
+	// They start at 1 and are 0 when the file has no source information.
+	Line   int
+	Column int
+}
+
+// newChange creates a BreakingChange for the given rule with a formatted message
+func newChange(id, format string, args ...interface{}) BreakingChange {
+	severity := SeverityError
+	if rule, ok := LookupRule(id); ok {
+		severity = rule.Severity
+	}
+	return BreakingChange{Rule: id, Severity: severity, Message: fmt.Sprintf(format, args...)}
+}
+
+// at returns the change located at the given path within the file
+func (c BreakingChange) at(path ...string) BreakingChange {
+	c.Path = path
+	return c
+}
+
+// jsonChange is the JSON form of a BreakingChange, with the kind of change and a dotted path
+type jsonChange struct {
+	Type     string   `json:"type"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Path is a string such as "TestMessage.age" when written, and may also be an array of elements when read
+	Path   json.RawMessage `json:"path,omitempty"`
+	Line   int             `json:"line,omitempty"`
+	Column int             `json:"column,omitempty"`
+}
+
+// MarshalJSON writes the change with the type of its rule, e.g. field_removed, and its path
+// joined with dots, e.g. "TestMessage.age"
+func (c BreakingChange) MarshalJSON() ([]byte, error) {
+	encoded := jsonChange{Type: changeTypes[c.Rule], Rule: c.Rule, Severity: c.Severity, Message: c.Message, Line: c.Line, Column: c.Column}
+	if len(c.Path) > 0 {
+		path, err := json.Marshal(strings.Join(c.Path, "."))
+		if err != nil {
+			return nil, err
+		}
+		encoded.Path = path
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON reads a change written by MarshalJSON. Paths written as arrays of elements,
+// as in baselines recorded by earlier versions, are read as well.
+func (c *BreakingChange) UnmarshalJSON(data []byte) error {
+	var decoded jsonChange
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*c = BreakingChange{Rule: decoded.Rule, Severity: decoded.Severity, Message: decoded.Message, Line: decoded.Line, Column: decoded.Column}
+	if len(decoded.Path) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(decoded.Path, &c.Path); err == nil {
+		return nil
+	}
+	var dotted string
+	if err := json.Unmarshal(decoded.Path, &dotted); err != nil {
+		return fmt.Errorf("invalid change path %s", decoded.Path)
+	}
+	if dotted != "" {
+		c.Path = strings.Split(dotted, ".")
+	}
+	return nil
+}
+
+// String returns the human readable message of the change
+func (c BreakingChange) String() string {
+	return c.Message
+}
+
+// FilterSeverity returns the changes with the given severity
+func FilterSeverity(changes []BreakingChange, severity Severity) []BreakingChange {
+	var matched []BreakingChange
+	for _, change := range changes {
+		if change.Severity == severity {
+			matched = append(matched, change)
+		}
+	}
+	return matched
+}
+
+// RuleSet tracks the resolved severity of every rule for a run
+type RuleSet map[string]Severity
+
+// NewRuleSet resolves the severity of every rule. Severities are applied
+// first, then the opt-in rules in optIn are enabled, and finally only and
+// skip decide which rules run at all. An empty only list keeps every enabled
+// rule. Unknown rule IDs are reported as errors.
+func NewRuleSet(only, skip, optIn []string, severities map[string]Severity) (RuleSet, error) {
+	for _, id := range append(append([]string{}, only...), skip...) {
+		if _, ok := LookupRule(id); !ok {
+			return nil, fmt.Errorf("unknown rule %q", id)
+		}
+	}
+	for id := range severities {
+		if _, ok := LookupRule(id); !ok {
+			return nil, fmt.Errorf("unknown rule %q", id)
+		}
+	}
+
+	rs := DefaultRuleSet()
+	for id, severity := range severities {
+		rs[id] = severity
+	}
+
+	// enable turns a rule on at its configured or default severity
+	enable := func(id string) {
+		if rs[id] == SeverityOff {
+			rule, _ := LookupRule(id)
+			rs[id] = rule.Severity
+			if severity, ok := severities[id]; ok && severity != SeverityOff {
+				rs[id] = severity
+			}
+		}
+	}
+	for _, id := range optIn {
+		enable(id)
+	}
+	if len(only) > 0 {
+		selected := make(map[string]bool, len(only))
+		for _, id := range only {
+			selected[id] = true
+			enable(id)
+		}
+		for id := range rs {
+			if !selected[id] {
+				rs[id] = SeverityOff
+			}
+		}
+	}
+	for _, id := range skip {
+		rs[id] = SeverityOff
+	}
+	return rs, nil
+}
+
+// DefaultRuleSet returns the rule set with every rule at its default severity and opt-in rules off
+func DefaultRuleSet() RuleSet {
+	rs := make(RuleSet, len(allRules))
+	for _, rule := range allRules {
+		rs[rule.ID] = SeverityOff
+		if !rule.OptIn {
+			rs[rule.ID] = rule.defaultSeverity()
+		}
+	}
+	return rs
+}
+
+// enabled reports whether any of the given rules is enabled
+func (rs RuleSet) enabled(ids ...string) bool {
+	for _, id := range ids {
+		if severity, ok := rs[id]; ok && severity != SeverityOff {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryEnabled reports whether any rule in the category is enabled
+func (rs RuleSet) categoryEnabled(category string) bool {
+	for _, rule := range allRules {
+		if rule.Category == category && rs.enabled(rule.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter drops changes produced by disabled rules and applies the resolved severity to the rest
+func (rs RuleSet) Filter(changes []BreakingChange) []BreakingChange {
+	var kept []BreakingChange
+	for _, change := range changes {
+		if rs.enabled(change.Rule) {
+			change.Severity = rs[change.Rule]
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
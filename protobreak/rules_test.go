@@ -0,0 +1,86 @@
+package protobreak
+
+import (
+	"testing"
+)
+
+// rulesWith returns the default rule set with the opt-in rules in optIn enabled at their default
+// severity and the rules in skip disabled
+func rulesWith(optIn, skip []string) RuleSet {
+	rs, _ := NewRuleSet(nil, skip, optIn, nil)
+	return rs
+}
+
+// onlyRules returns a rule set running only the given rules, at their default severity
+func onlyRules(ids ...string) RuleSet {
+	rs, _ := NewRuleSet(ids, nil, nil, nil)
+	return rs
+}
+
+// TestWithSeverities tests overriding the default severity of rules, including opt-in rules
+func TestWithSeverities(t *testing.T) {
+	rules := newOptions([]Option{WithSeverities(map[string]Severity{
+		RuleFieldNoDelete:     SeverityOff,
+		RuleFieldSameType:     SeverityWarning,
+		RuleFieldSameTextName: SeverityError,
+		"NOT_A_RULE":          SeverityError,
+	})}).rules
+
+	expected := map[string]Severity{
+		RuleFieldNoDelete:           SeverityOff,
+		RuleFieldSameType:           SeverityWarning,
+		RuleFieldSameTextName:       SeverityError,
+		RuleFieldWireCompatibleType: SeverityWarning,
+		RuleFieldSameJSONName:       SeverityOff,
+	}
+	for id, severity := range expected {
+		if rules[id] != severity {
+			t.Errorf("Expected %s to be %s, got %s", id, severity, rules[id])
+		}
+	}
+	if len(rules) != len(allRules) {
+		t.Errorf("Expected %d rules, got %d", len(allRules), len(rules))
+	}
+}
+
+// TestUnknownRule tests that unknown rule IDs are rejected
+func TestUnknownRule(t *testing.T) {
+	if _, err := NewRuleSet([]string{"NOT_A_RULE"}, nil, nil, nil); err == nil {
+		t.Error("Expected an error for an unknown rule")
+	}
+	if _, err := NewRuleSet(nil, nil, nil, map[string]Severity{"NOT_A_RULE": SeverityOff}); err == nil {
+		t.Error("Expected an error for an unknown rule in the severities")
+	}
+}
+
+// TestRules tests that the listed rules carry their default severity
+func TestRules(t *testing.T) {
+	rules := Rules()
+	if len(rules) != len(allRules) {
+		t.Fatalf("Expected %d rules, got %d", len(allRules), len(rules))
+	}
+	for _, rule := range rules {
+		if rule.Severity == "" {
+			t.Errorf("Expected a default severity for %s", rule.ID)
+		}
+	}
+	if rules[0].ID != RuleMessageNoDelete || rules[0].Severity != SeverityError {
+		t.Errorf("Expected %s (%s) first, got %s (%s)", RuleMessageNoDelete, SeverityError, rules[0].ID, rules[0].Severity)
+	}
+}
+
+// TestChangeTypes tests that every rule names its kind of change for JSON output
+func TestChangeTypes(t *testing.T) {
+	seen := make(map[string]string)
+	for _, rule := range allRules {
+		changeType, ok := changeTypes[rule.ID]
+		if !ok {
+			t.Errorf("Expected a change type for %s", rule.ID)
+			continue
+		}
+		if other, ok := seen[changeType]; ok {
+			t.Errorf("Expected a distinct change type for %s, %q is used by %s", rule.ID, changeType, other)
+		}
+		seen[changeType] = rule.ID
+	}
+}
@@ -0,0 +1,84 @@
+package protobreak
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// slackMaxChanges is the number of changes listed per file in the Slack report, the rest being counted
+const slackMaxChanges = 10
+
+// slackText is a Block Kit text object
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackBlock is a Block Kit header or section block
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text"`
+}
+
+// slackPayload is the message posted to a Slack webhook
+type slackPayload struct {
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// slackEscaper escapes the characters that Slack reserves in mrkdwn text
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackIcons are the emoji prefixing each change in the Slack report, by severity
+var slackIcons = map[Severity]string{
+	SeverityError:   ":red_circle:",
+	SeverityWarning: ":large_yellow_circle:",
+	SeverityInfo:    ":information_source:",
+}
+
+// writeSlackReport writes a Block Kit payload for Slack webhooks: a header counting breaking changes
+// and warnings, then a section per file with changes listing errors, warnings and notes in that order,
+// truncated after slackMaxChanges with a "+N more" line
+func writeSlackReport(w io.Writer, reports []FileReport) error {
+	errors, warnings, files := 0, 0, 0
+	var sections []slackBlock
+	for _, report := range reports {
+		if len(report.BreakingChanges) == 0 {
+			continue
+		}
+		files++
+		errors += len(FilterSeverity(report.BreakingChanges, SeverityError))
+		warnings += len(FilterSeverity(report.BreakingChanges, SeverityWarning))
+
+		var changes []BreakingChange
+		for _, severity := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+			changes = append(changes, FilterSeverity(report.BreakingChanges, severity)...)
+		}
+		lines := []string{fmt.Sprintf("*%s*", slackEscaper.Replace(report.File))}
+		for i, change := range changes {
+			if i == slackMaxChanges {
+				lines = append(lines, fmt.Sprintf("+%d more", len(changes)-slackMaxChanges))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("%s %s", slackIcons[change.Severity], slackEscaper.Replace(change.String())))
+		}
+		sections = append(sections, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: strings.Join(lines, "\n")}})
+	}
+
+	header := fmt.Sprintf("✅ No breaking changes in %d files", len(reports))
+	if files > 0 {
+		icon := "🔴"
+		if errors == 0 && warnings > 0 {
+			icon = "🟡"
+		} else if errors == 0 {
+			icon = "ℹ️"
+		}
+		header = fmt.Sprintf("%s %d breaking changes, %d warnings in %d files", icon, errors, warnings, files)
+	}
+
+	payload := slackPayload{Blocks: append([]slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: header}}}, sections...)}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(payload)
+}
@@ -0,0 +1,71 @@
+package protobreak
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestSlackReport tests the Block Kit structure of the Slack report and the truncation of long files
+func TestSlackReport(t *testing.T) {
+	var removals []BreakingChange
+	for i := 1; i <= slackMaxChanges+2; i++ {
+		removals = append(removals, newChange(RuleFieldNoDelete, "Field \"f%d\" (number %d) was removed from message \"A\"", i, i))
+	}
+	reports := []FileReport{
+		{File: "a.proto", BreakingChanges: removals},
+		{File: "clean.proto"},
+		{File: "b.proto", BreakingChanges: []BreakingChange{
+			newChange(RuleFieldSameName, `Field renamed from "a" to "b" in message "Map<K>"`),
+			newChange(RuleFieldNoDelete, `Field "c" (number 3) was removed from message "B"`),
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeReports(&buf, formatSlack, reports); err != nil {
+		t.Fatalf("Failed to write Slack report: %v", err)
+	}
+	var payload slackPayload
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("Failed to decode Slack report: %v\n%s", err, buf.String())
+	}
+
+	if len(payload.Blocks) != 3 {
+		t.Fatalf("Expected a header and 2 file sections, got %d blocks:\n%s", len(payload.Blocks), buf.String())
+	}
+	header := payload.Blocks[0]
+	if header.Type != "header" || header.Text.Type != "plain_text" || header.Text.Text != "🔴 13 breaking changes, 1 warnings in 2 files" {
+		t.Errorf("Unexpected header %+v", *header.Text)
+	}
+	for _, block := range payload.Blocks[1:] {
+		if block.Type != "section" || block.Text.Type != "mrkdwn" {
+			t.Errorf("Expected a mrkdwn section, got %s with %s text", block.Type, block.Text.Type)
+		}
+	}
+
+	lines := strings.Split(payload.Blocks[1].Text.Text, "\n")
+	if len(lines) != slackMaxChanges+2 || lines[0] != "*a.proto*" || lines[len(lines)-1] != "+2 more" {
+		t.Errorf("Expected the file, %d changes and +2 more, got %q", slackMaxChanges, lines)
+	}
+	if first := fmt.Sprintf(":red_circle: %s", removals[0]); lines[1] != first {
+		t.Errorf("Expected %q, got %q", first, lines[1])
+	}
+
+	// Errors come first and mrkdwn control characters are escaped
+	expected := "*b.proto*\n" +
+		`:red_circle: Field "c" (number 3) was removed from message "B"` + "\n" +
+		`:large_yellow_circle: Field renamed from "a" to "b" in message "Map&lt;K&gt;"`
+	if actual := payload.Blocks[2].Text.Text; actual != expected {
+		t.Errorf("Expected section:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	buf.Reset()
+	if err := writeReports(&buf, formatSlack, []FileReport{{File: "clean.proto"}}); err != nil {
+		t.Fatalf("Failed to write Slack report: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No breaking changes in 1 files") {
+		t.Errorf("Expected a clean header, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,105 @@
+package protobreak
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// treeNode groups the changes located at one element of a file, e.g. a message or a field
+type treeNode struct {
+	name     string
+	changes  []BreakingChange
+	children map[string]*treeNode
+}
+
+// child returns the child node with the given name, creating it when needed
+func (n *treeNode) child(name string) *treeNode {
+	if n.children == nil {
+		n.children = make(map[string]*treeNode)
+	}
+	if _, ok := n.children[name]; !ok {
+		n.children[name] = &treeNode{name: name}
+	}
+	return n.children[name]
+}
+
+// count returns the number of changes in the node and its descendants
+func (n *treeNode) count() int {
+	total := len(n.changes)
+	for _, child := range n.children {
+		total += child.count()
+	}
+	return total
+}
+
+// buildTree groups the changes of a report by their path
+func buildTree(report FileReport) *treeNode {
+	root := &treeNode{name: report.File}
+	for _, change := range report.BreakingChanges {
+		node := root
+		for _, name := range change.Path {
+			node = node.child(name)
+		}
+		node.changes = append(node.changes, change)
+	}
+	return root
+}
+
+// writeTreeReport writes the changes of every file as a tree grouped by file, then message
+// or other top-level element, then field, with the number of changes below each node.
+// Each file is written in one go, and the first write error is returned.
+func writeTreeReport(w io.Writer, reports []FileReport) error {
+	for _, report := range reports {
+		errors := len(FilterSeverity(report.BreakingChanges, SeverityError))
+		warnings := len(FilterSeverity(report.BreakingChanges, SeverityWarning))
+		var b strings.Builder
+		if len(report.BreakingChanges) == 0 {
+			fmt.Fprintf(&b, "✅ %s\n", report.File)
+		} else {
+			icon := "🔴"
+			if errors == 0 && warnings > 0 {
+				icon = "🟡"
+			} else if errors == 0 {
+				icon = "ℹ️"
+			}
+			fmt.Fprintf(&b, "%s %s (%d breaking, %d warnings)\n", icon, report.File, errors, warnings)
+			writeTreeChildren(&b, buildTree(report), "")
+		}
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTreeChildren writes the changes of a node followed by its children, sorted by name
+func writeTreeChildren(w io.Writer, node *treeNode, prefix string) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	total := len(node.changes) + len(names)
+	line := 0
+	branch := func() (string, string) {
+		line++
+		if line == total {
+			return prefix + "└── ", prefix + "    "
+		}
+		return prefix + "├── ", prefix + "│   "
+	}
+
+	for _, change := range node.changes {
+		head, _ := branch()
+		fmt.Fprintf(w, "%s%s %s\n", head, strings.ToLower(string(change.Severity)), change)
+	}
+	for _, name := range names {
+		child := node.children[name]
+		head, indent := branch()
+		fmt.Fprintf(w, "%s%s (%d)\n", head, name, child.count())
+		writeTreeChildren(w, child, indent)
+	}
+}
@@ -0,0 +1,55 @@
+package protobreak
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestTreeReport tests that nested changes are grouped under their file, message and field
+func TestTreeReport(t *testing.T) {
+	prevFileDesc, currFileDesc := parseTestProtos(t, `
+		syntax = "proto3";
+		package test;
+		message Outer {
+			message Inner {
+				string name = 1;
+				int32 age = 2;
+			}
+			string id = 1;
+		}
+	`, `
+		syntax = "proto3";
+		package test;
+		message Outer {
+			message Inner {
+				int64 name = 1;
+			}
+			bytes id = 1;
+		}
+	`)
+
+	reports := []FileReport{
+		{File: "test.proto", BreakingChanges: compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})},
+		{File: "clean.proto"},
+	}
+	var buf bytes.Buffer
+	if err := writeReports(&buf, formatConsoleTree, reports); err != nil {
+		t.Fatalf("Failed to write tree report: %v", err)
+	}
+
+	expected := `🔴 test.proto (2 breaking, 2 warnings)
+├── Outer (1)
+│   └── id (1)
+│       └── warning Field "id" type changed from string to bytes in message "Outer" (wire type length-delimited preserved)
+└── Outer.Inner (3)
+    ├── age (2)
+    │   ├── error Field "age" (number 2) was removed from message "Inner"
+    │   └── warning Removed field "age" (number 2) is not reserved in message "Inner"
+    └── name (1)
+        └── error Field "name" type changed from string to int64 in message "Inner"
+✅ clean.proto
+`
+	if buf.String() != expected {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
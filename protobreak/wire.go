@@ -0,0 +1,256 @@
+package protobreak
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// kindWireType returns the wire type used to encode a single value of the given kind
+func kindWireType(kind protoreflect.Kind) protowire.Type {
+	switch kind {
+	case protoreflect.BoolKind, protoreflect.EnumKind,
+		protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind:
+		return protowire.VarintType
+	// Fixed-width kinds are only interchangeable with kinds of the same width
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind, protoreflect.FloatKind:
+		return protowire.Fixed32Type
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind, protoreflect.DoubleKind:
+		return protowire.Fixed64Type
+	case protoreflect.GroupKind:
+		return protowire.StartGroupType
+	default:
+		// String, bytes and message values are length-delimited
+		return protowire.BytesType
+	}
+}
+
+// isPackable reports whether repeated values of a kind can use the packed encoding,
+// which excludes strings, bytes and messages
+func isPackable(kind protoreflect.Kind) bool {
+	wireType := kindWireType(kind)
+	return wireType != protowire.BytesType && wireType != protowire.StartGroupType
+}
+
+// fieldWireType returns the wire type used to encode a field, taking packed
+// repeated fields into account since they are length-delimited on the wire
+func fieldWireType(field protoreflect.FieldDescriptor) protowire.Type {
+	if field.IsPacked() {
+		return protowire.BytesType
+	}
+	return kindWireType(field.Kind())
+}
+
+// isZigZag reports whether a kind uses the zigzag varint encoding of signed integers
+func isZigZag(kind protoreflect.Kind) bool {
+	return kind == protoreflect.Sint32Kind || kind == protoreflect.Sint64Kind
+}
+
+// isZigZagChange reports whether an integer kind switches between the zigzag encoding of sint32 and
+// sint64 and the plain varint encoding of the int and uint kinds. The wire type stays varint, so values
+// silently decode to different numbers instead of failing.
+func isZigZagChange(prev, curr protoreflect.Kind) bool {
+	isInteger := func(kind protoreflect.Kind) bool {
+		return kind != protoreflect.BoolKind && kind != protoreflect.EnumKind && kindWireType(kind) == protowire.VarintType
+	}
+	return isInteger(prev) && isInteger(curr) && isZigZag(prev) != isZigZag(curr)
+}
+
+// kindJSONEncoding returns how the canonical JSON mapping writes a single value of the given kind.
+// 64-bit integers are written as quoted strings, but every integer kind parses both numbers and
+// quoted strings, so they share an encoding. Bytes are base64 strings and enums are written by name.
+func kindJSONEncoding(kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind,
+		protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+		return "integer"
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return "number"
+	case protoreflect.BoolKind:
+		return "boolean"
+	case protoreflect.EnumKind:
+		return "enum name"
+	case protoreflect.StringKind:
+		return "string"
+	case protoreflect.BytesKind:
+		return "base64 string"
+	default:
+		return "object"
+	}
+}
+
+// isWireCompatibleKindChange reports whether values written with the previous kind still decode
+// as the same numbers, strings or bytes with the current one. The kinds must share a wire type,
+// and zigzag-encoded sint32 and sint64 only mix with each other.
+func isWireCompatibleKindChange(prev, curr protoreflect.Kind) bool {
+	return kindWireType(prev) == kindWireType(curr) && isZigZag(prev) == isZigZag(curr)
+}
+
+// isWireCompatibleFieldChange reports whether a field changing type keeps its encoding,
+// taking packed repeated fields into account since they are length-delimited on the wire
+func isWireCompatibleFieldChange(prev, curr protoreflect.FieldDescriptor) bool {
+	if prev.IsPacked() || curr.IsPacked() {
+		return fieldWireType(prev) == fieldWireType(curr) && isZigZag(prev.Kind()) == isZigZag(curr.Kind())
+	}
+	return isWireCompatibleKindChange(prev.Kind(), curr.Kind())
+}
+
+// isJSONEncodingChange reports whether a field changing type changes how its values are written in
+// JSON, so that JSON written with the previous type is rejected by the current one
+func isJSONEncodingChange(prev, curr protoreflect.FieldDescriptor) bool {
+	return kindJSONEncoding(prev.Kind()) != kindJSONEncoding(curr.Kind())
+}
+
+// isSignednessChange reports whether a varint integer kind changes between signed and unsigned
+// with the same width. Non-negative values keep decoding, but negative ones turn into large
+// unsigned numbers and back.
+func isSignednessChange(prev, curr protoreflect.Kind) bool {
+	switch {
+	case prev == protoreflect.Int32Kind && curr == protoreflect.Uint32Kind,
+		prev == protoreflect.Uint32Kind && curr == protoreflect.Int32Kind,
+		prev == protoreflect.Int64Kind && curr == protoreflect.Uint64Kind,
+		prev == protoreflect.Uint64Kind && curr == protoreflect.Int64Kind:
+		return true
+	default:
+		return false
+	}
+}
+
+// isNarrowingKindChange reports whether an integer kind changes from 64 to 32 bits,
+// which truncates values that no longer fit
+func isNarrowingKindChange(prev, curr protoreflect.Kind) bool {
+	switch prev {
+	case protoreflect.Int64Kind, protoreflect.Uint64Kind, protoreflect.Sint64Kind,
+		protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+	default:
+		return false
+	}
+	switch curr {
+	case protoreflect.Int32Kind, protoreflect.Uint32Kind, protoreflect.Sint32Kind,
+		protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind:
+		return true
+	default:
+		return false
+	}
+}
+
+// wireTypeName returns a readable name for a wire type
+func wireTypeName(wireType protowire.Type) string {
+	switch wireType {
+	case protowire.VarintType:
+		return "varint"
+	case protowire.Fixed32Type:
+		return "fixed32"
+	case protowire.Fixed64Type:
+		return "fixed64"
+	case protowire.BytesType:
+		return "length-delimited"
+	case protowire.StartGroupType:
+		return "group"
+	default:
+		return "unknown"
+	}
+}
+
+// isMessageSet reports whether a message uses the legacy MessageSet wire format
+func isMessageSet(msg protoreflect.MessageDescriptor) bool {
+	opts, _ := msg.Options().(*descriptorpb.MessageOptions)
+	return opts.GetMessageSetWireFormat()
+}
+
+// hasMapEntryOption reports whether a message is declared as the entry message of a map field
+func hasMapEntryOption(msg protoreflect.MessageDescriptor) bool {
+	opts, _ := msg.Options().(*descriptorpb.MessageOptions)
+	return opts.GetMapEntry()
+}
+
+// isLazy reports whether a message field is parsed lazily
+func isLazy(field protoreflect.FieldDescriptor) bool {
+	opts, _ := field.Options().(*descriptorpb.FieldOptions)
+	return opts.GetLazy()
+}
+
+// isWeak reports whether a message field is weak, which links its message type only when it is used
+func isWeak(field protoreflect.FieldDescriptor) bool {
+	opts, _ := field.Options().(*descriptorpb.FieldOptions)
+	return opts.GetWeak()
+}
+
+// isRepeatedEntryMapChange reports whether a repeated message field became a map or the other
+// way around. Both are lists of messages, but map entries are synthetic messages with key 1 and
+// value 2, so the layout only matches by accident.
+func isRepeatedEntryMapChange(prev, curr protoreflect.FieldDescriptor) bool {
+	prevEntries := prev.IsList() && prev.Message() != nil
+	currEntries := curr.IsList() && curr.Message() != nil
+	return (prevEntries && curr.IsMap()) || (prev.IsMap() && currEntries)
+}
+
+// fieldTypeName returns the declared type of a field, e.g. repeated test.Entry or map<string, int32>
+func fieldTypeName(field protoreflect.FieldDescriptor) string {
+	if field.IsList() {
+		return "repeated " + elementTypeName(field)
+	}
+	if field.IsMap() {
+		return fmt.Sprintf("map<%s, %s>", elementTypeName(field.MapKey()), elementTypeName(field.MapValue()))
+	}
+	return elementTypeName(field)
+}
+
+// fieldShape describes a field for changes between scalars, enums, messages, groups and maps,
+// e.g. scalar string, message test.User, repeated enum test.Status or map<string, int32>
+func fieldShape(field protoreflect.FieldDescriptor) string {
+	if field.IsMap() {
+		return fieldTypeName(field)
+	}
+	var shape string
+	switch field.Kind() {
+	case protoreflect.MessageKind:
+		shape = "message " + elementTypeName(field)
+	case protoreflect.GroupKind:
+		shape = "group " + elementTypeName(field)
+	case protoreflect.EnumKind:
+		shape = "enum " + elementTypeName(field)
+	default:
+		shape = "scalar " + elementTypeName(field)
+	}
+	if field.IsList() {
+		return "repeated " + shape
+	}
+	return shape
+}
+
+// isMessageKind reports whether values of the kind are messages, encoded length-prefixed or delimited
+func isMessageKind(kind protoreflect.Kind) bool {
+	return kind == protoreflect.MessageKind || kind == protoreflect.GroupKind
+}
+
+// elementTypeName returns the type of a single value of a field, e.g. test.Entry or int32
+func elementTypeName(field protoreflect.FieldDescriptor) string {
+	if field.Message() != nil {
+		return string(field.Message().FullName())
+	}
+	if field.Enum() != nil {
+		return string(field.Enum().FullName())
+	}
+	return field.Kind().String()
+}
+
+// isMessageEncodingChange reports whether a message field switched between the length-prefixed
+// and the delimited (group) encoding
+func isMessageEncodingChange(prev, curr protoreflect.Kind) bool {
+	return (prev == protoreflect.MessageKind && curr == protoreflect.GroupKind) ||
+		(prev == protoreflect.GroupKind && curr == protoreflect.MessageKind)
+}
+
+// messageEncoding returns the resolved message_encoding feature of a message field
+func messageEncoding(field protoreflect.FieldDescriptor) string {
+	if field.Kind() == protoreflect.GroupKind {
+		return descriptorpb.FeatureSet_DELIMITED.String()
+	}
+	return descriptorpb.FeatureSet_LENGTH_PREFIXED.String()
+}
@@ -0,0 +1,93 @@
+package protobreak
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TestIsWireCompatibleKindChange tests every pair of kinds against the groups sharing an encoding
+func TestIsWireCompatibleKindChange(t *testing.T) {
+	groups := [][]protoreflect.Kind{
+		{protoreflect.BoolKind, protoreflect.EnumKind, protoreflect.Int32Kind, protoreflect.Int64Kind,
+			protoreflect.Uint32Kind, protoreflect.Uint64Kind},
+		{protoreflect.Sint32Kind, protoreflect.Sint64Kind},
+		{protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind, protoreflect.FloatKind},
+		{protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind, protoreflect.DoubleKind},
+		{protoreflect.StringKind, protoreflect.BytesKind, protoreflect.MessageKind},
+		{protoreflect.GroupKind},
+	}
+
+	group := make(map[protoreflect.Kind]int)
+	for i, kinds := range groups {
+		for _, kind := range kinds {
+			group[kind] = i
+		}
+	}
+
+	for prev := range group {
+		for curr := range group {
+			expected := group[prev] == group[curr]
+			if actual := isWireCompatibleKindChange(prev, curr); actual != expected {
+				t.Errorf("Expected isWireCompatibleKindChange(%s, %s) to be %t, got %t", prev, curr, expected, actual)
+			}
+		}
+	}
+}
+
+// TestJSONIncompatibleTypeChange tests that type changes keeping the wire type but not the JSON
+// encoding are breaking with FIELD_SAME_JSON_TYPE, and only wire-compatible warnings without it
+func TestJSONIncompatibleTypeChange(t *testing.T) {
+	tests := []struct {
+		prevType     string
+		currType     string
+		expectedRule string
+	}{
+		{prevType: "bool", currType: "int32", expectedRule: RuleFieldSameJSONType},
+		{prevType: "int64", currType: "bool", expectedRule: RuleFieldSameJSONType},
+		{prevType: "fixed32", currType: "float", expectedRule: RuleFieldSameJSONType},
+		// Bytes are base64 strings in JSON
+		{prevType: "string", currType: "bytes", expectedRule: RuleFieldSameJSONType},
+		{prevType: "bytes", currType: "string", expectedRule: RuleFieldSameJSONType},
+		{prevType: "string", currType: "Other", expectedRule: RuleFieldSameJSONType},
+		// Every integer kind parses both JSON numbers and quoted strings
+		{prevType: "int32", currType: "int64", expectedRule: RuleFieldWireCompatibleType},
+		{prevType: "uint32", currType: "uint64", expectedRule: RuleFieldWireCompatibleType},
+		{prevType: "sint32", currType: "sint64", expectedRule: RuleFieldWireCompatibleType},
+		{prevType: "sfixed32", currType: "fixed32", expectedRule: RuleFieldWireCompatibleType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prevType+" to "+tt.currType, func(t *testing.T) {
+			prevFileDesc, currFileDesc := parseTestProtos(t, `
+				syntax = "proto3";
+				package test;
+				message Other {}
+				message TestMessage {
+					`+tt.prevType+` value = 1;
+				}
+			`, `
+				syntax = "proto3";
+				package test;
+				message Other {}
+				message TestMessage {
+					`+tt.currType+` value = 1;
+				}
+			`)
+
+			changes := compareFiles(prevFileDesc, currFileDesc, options{rules: rulesWith([]string{RuleFieldSameJSONType}, nil)})
+			if len(changes) != 1 {
+				t.Fatalf("Expected 1 change, got %v", changes)
+			}
+			if changes[0].Rule != tt.expectedRule {
+				t.Errorf("Expected %s, got %s: %s", tt.expectedRule, changes[0].Rule, changes[0].Message)
+			}
+
+			// Without the opt-in rule, every change keeps the wire-compatible warning
+			changes = compareFiles(prevFileDesc, currFileDesc, options{rules: DefaultRuleSet()})
+			if len(changes) != 1 || changes[0].Rule != RuleFieldWireCompatibleType || changes[0].Severity != SeverityWarning {
+				t.Errorf("Expected a single %s warning by default, got %v", RuleFieldWireCompatibleType, changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// formatText is the default output format, streamed file by file
+const formatText = "text"
+
+// Values of --fail-on, the lowest severity of changes that fails the run
+const (
+	failOnError   = "error"
+	failOnWarning = "warning"
+	failOnNone    = "none"
+)
+
+// parseFailOn parses a --fail-on value into the lowest failing severity, where protobreak.SeverityOff
+// means that no change fails the run
+func parseFailOn(value string) (protobreak.Severity, error) {
+	switch value {
+	case failOnError:
+		return protobreak.SeverityError, nil
+	case failOnWarning:
+		return protobreak.SeverityWarning, nil
+	case failOnNone:
+		return protobreak.SeverityOff, nil
+	default:
+		return "", fmt.Errorf("unknown --fail-on value %q, expected %s, %s or %s", value, failOnError, failOnWarning, failOnNone)
+	}
+}
+
+// hasBreakingChanges reports whether any report contains a change at or above the failOn severity.
+// An empty failOn fails on errors.
+func hasBreakingChanges(reports []protobreak.FileReport, failOn protobreak.Severity) bool {
+	if failOn == protobreak.SeverityOff {
+		return false
+	}
+	for _, report := range reports {
+		if len(protobreak.FilterSeverity(report.BreakingChanges, protobreak.SeverityError)) > 0 {
+			return true
+		}
+		if failOn == protobreak.SeverityWarning && len(protobreak.FilterSeverity(report.BreakingChanges, protobreak.SeverityWarning)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// failingReports returns copies of the reports without the changes of report-only rules,
+// which are printed but never affect the exit code
+func failingReports(reports []protobreak.FileReport, reportOnly map[string]bool) []protobreak.FileReport {
+	if len(reportOnly) == 0 {
+		return reports
+	}
+	result := make([]protobreak.FileReport, 0, len(reports))
+	for _, report := range reports {
+		var changes []protobreak.BreakingChange
+		for _, change := range report.BreakingChanges {
+			if !reportOnly[change.Rule] {
+				changes = append(changes, change)
+			}
+		}
+		result = append(result, protobreak.FileReport{File: report.File, BreakingChanges: changes})
+	}
+	return result
+}
+
+// exitProcessingError is the exit code used when a file could not be parsed or compared,
+// taking precedence over the exit code for breaking changes
+const exitProcessingError = 2
+
+// Exit code bits combined by --bit-exit, so that CI can tell breaking changes, warnings and errors apart
+const (
+	exitBitBreaking = 1 << 0
+	exitBitWarnings = 1 << 1
+	exitBitError    = 1 << 2
+)
+
+// exitCode returns the process exit code for the reports, where failed tells whether some files
+// could not be processed. By default it is exitProcessingError after failures and 1 when a change
+// at or above the failOn severity was found. With bitExit, exitBitBreaking, exitBitWarnings and
+// exitBitError are combined whatever failOn is.
+func exitCode(reports []protobreak.FileReport, failed, bitExit bool, failOn protobreak.Severity) int {
+	if !bitExit {
+		if failed {
+			return exitProcessingError
+		}
+		if hasBreakingChanges(reports, failOn) {
+			return 1
+		}
+		return 0
+	}
+
+	code := 0
+	if failed {
+		code |= exitBitError
+	}
+	for _, report := range reports {
+		if len(protobreak.FilterSeverity(report.BreakingChanges, protobreak.SeverityError)) > 0 {
+			code |= exitBitBreaking
+		}
+		if len(protobreak.FilterSeverity(report.BreakingChanges, protobreak.SeverityWarning)) > 0 {
+			code |= exitBitWarnings
+		}
+	}
+	return code
+}
+
+// unchangedReports returns an empty report for every file that was not modified, keeping the order of files
+func unchangedReports(files, modified []string) []protobreak.FileReport {
+	isModified := make(map[string]bool, len(modified))
+	for _, file := range modified {
+		isModified[file] = true
+	}
+
+	var reports []protobreak.FileReport
+	for _, file := range files {
+		if !isModified[file] {
+			reports = append(reports, protobreak.FileReport{File: file})
+		}
+	}
+	return reports
+}
+
+// writeReports writes all reports in the given format
+func writeReports(w io.Writer, format string, reports []protobreak.FileReport) error {
+	formatter, err := protobreak.LookupFormatter(format)
+	if err != nil {
+		return err
+	}
+	return formatter.Format(w, protobreak.Report{Files: reports})
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// ruleChange returns a change of the given rule at its default severity
+func ruleChange(id, message string) protobreak.BreakingChange {
+	rule, _ := protobreak.LookupRule(id)
+	return protobreak.BreakingChange{Rule: id, Severity: rule.Severity, Message: message}
+}
+
+// TestReportUnchanged tests that files without breaking changes appear in the reports
+func TestReportUnchanged(t *testing.T) {
+	reports := unchangedReports([]string{"a.proto", "api/b.proto", "api/c.proto"}, []string{"api/b.proto"})
+	if len(reports) != 2 || reports[0].File != "a.proto" || reports[1].File != "api/c.proto" {
+		t.Fatalf("Expected reports for a.proto and api/c.proto, got %+v", reports)
+	}
+
+	reports = append([]protobreak.FileReport{{
+		File:            "api/b.proto",
+		BreakingChanges: []protobreak.BreakingChange{{Rule: protobreak.RuleFieldNoDelete, Severity: protobreak.SeverityError, Message: `Field "age" (number 2) was removed from message "User"`}},
+	}}, reports...)
+
+	var text bytes.Buffer
+	if err := writeReports(&text, formatText, reports); err != nil {
+		t.Fatalf("Failed to write text report: %v", err)
+	}
+	for _, clean := range []string{"No breaking changes detected in a.proto", "No breaking changes detected in api/c.proto"} {
+		if !strings.Contains(text.String(), clean) {
+			t.Errorf("Expected %q in text report:\n%s", clean, text.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := writeReports(&html, "html", reports); err != nil {
+		t.Fatalf("Failed to write HTML report: %v", err)
+	}
+	if !strings.Contains(html.String(), "<li>a.proto</li>\n<li>api/c.proto</li>") {
+		t.Errorf("Expected the clean files to be listed in the HTML report:\n%s", html.String())
+	}
+	if strings.Contains(html.String(), "<li>api/b.proto</li>") {
+		t.Error("Expected files with breaking changes to be left out of the clean list")
+	}
+}
+
+// TestExitCode tests the default exit code and the bits combined by --bit-exit
+func TestExitCode(t *testing.T) {
+	breaking := protobreak.FileReport{File: "a.proto", BreakingChanges: []protobreak.BreakingChange{{Rule: protobreak.RuleFieldNoDelete, Severity: protobreak.SeverityError}}}
+	warning := protobreak.FileReport{File: "b.proto", BreakingChanges: []protobreak.BreakingChange{{Rule: protobreak.RuleFieldSameLazy, Severity: protobreak.SeverityWarning}}}
+	clean := protobreak.FileReport{File: "c.proto"}
+	info := protobreak.FileReport{File: "d.proto", BreakingChanges: []protobreak.BreakingChange{{Rule: protobreak.RuleFieldAdded, Severity: protobreak.SeverityInfo}}}
+
+	tests := []struct {
+		name     string
+		reports  []protobreak.FileReport
+		expected int
+		bits     int
+	}{
+		{name: "clean", reports: []protobreak.FileReport{clean}, expected: 0, bits: 0},
+		{name: "info notes", reports: []protobreak.FileReport{info, clean}, expected: 0, bits: 0},
+		{name: "breaking", reports: []protobreak.FileReport{breaking, clean}, expected: 1, bits: 1},
+		{name: "warnings", reports: []protobreak.FileReport{warning}, expected: 0, bits: 2},
+		{name: "mixed", reports: []protobreak.FileReport{breaking, warning, clean}, expected: 1, bits: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := exitCode(tt.reports, false, false, protobreak.SeverityError); code != tt.expected {
+				t.Errorf("Expected exit code %d, got %d", tt.expected, code)
+			}
+			if code := exitCode(tt.reports, false, true, protobreak.SeverityError); code != tt.bits {
+				t.Errorf("Expected exit code %d with --bit-exit, got %d", tt.bits, code)
+			}
+		})
+	}
+
+	// Info notes never fail the run, even with --fail-on warning
+	if code := exitCode([]protobreak.FileReport{info}, false, false, protobreak.SeverityWarning); code != 0 {
+		t.Errorf("Expected exit code 0 for info notes, got %d", code)
+	}
+
+	// Processing errors take precedence over breaking changes, or set their own bit
+	mixed := []protobreak.FileReport{breaking, warning}
+	if code := exitCode(mixed, true, false, protobreak.SeverityError); code != exitProcessingError {
+		t.Errorf("Expected exit code %d after processing errors, got %d", exitProcessingError, code)
+	}
+	if code := exitCode(mixed, true, true, protobreak.SeverityError); code != 7 {
+		t.Errorf("Expected exit code 7 with --bit-exit after processing errors, got %d", code)
+	}
+}
+
+// TestFailOn tests which changes fail the run for every --fail-on threshold
+func TestFailOn(t *testing.T) {
+	rename := protobreak.FileReport{File: "a.proto", BreakingChanges: []protobreak.BreakingChange{ruleChange(protobreak.RuleFieldSameName, "renamed")}}
+	removal := protobreak.FileReport{File: "b.proto", BreakingChanges: []protobreak.BreakingChange{ruleChange(protobreak.RuleFieldNoDelete, "removed")}}
+	if rename.BreakingChanges[0].Severity != protobreak.SeverityWarning {
+		t.Fatalf("Expected renames to be warnings, got %s", rename.BreakingChanges[0].Severity)
+	}
+
+	tests := []struct {
+		failOn  string
+		rename  int
+		removal int
+	}{
+		{failOn: failOnError, rename: 0, removal: 1},
+		{failOn: failOnWarning, rename: 1, removal: 1},
+		{failOn: failOnNone, rename: 0, removal: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.failOn, func(t *testing.T) {
+			failOn, err := parseFailOn(tt.failOn)
+			if err != nil {
+				t.Fatalf("Failed to parse --fail-on %s: %v", tt.failOn, err)
+			}
+			if code := exitCode([]protobreak.FileReport{rename}, false, false, failOn); code != tt.rename {
+				t.Errorf("Expected exit code %d for a rename, got %d", tt.rename, code)
+			}
+			if code := exitCode([]protobreak.FileReport{removal}, false, false, failOn); code != tt.removal {
+				t.Errorf("Expected exit code %d for a removal, got %d", tt.removal, code)
+			}
+			// Processing errors fail the run whatever the threshold
+			if code := exitCode(nil, true, false, failOn); code != exitProcessingError {
+				t.Errorf("Expected exit code %d after processing errors, got %d", exitProcessingError, code)
+			}
+		})
+	}
+
+	if _, err := parseFailOn("info"); err == nil {
+		t.Error("Expected an error for an unknown --fail-on value")
+	}
+}
+
+// TestReportOnlyRules tests that changes of report-only rules are kept in reports but never change the exit code
+func TestReportOnlyRules(t *testing.T) {
+	reportOnly, err := parseReportOnlyRules("field_same_json_name, FIELD_SAME_PRESENCE")
+	if err != nil {
+		t.Fatalf("Failed to parse --report-only-rules: %v", err)
+	}
+
+	jsonName := protobreak.FileReport{File: "a.proto", BreakingChanges: []protobreak.BreakingChange{ruleChange(protobreak.RuleFieldSameJSONName, "json name changed")}}
+	jsonName.BreakingChanges[0].Severity = protobreak.SeverityError
+	if code := exitCode([]protobreak.FileReport{jsonName}, false, false, protobreak.SeverityError); code != 1 {
+		t.Fatalf("Expected exit code 1 without --report-only-rules, got %d", code)
+	}
+	if code := exitCode(failingReports([]protobreak.FileReport{jsonName}, reportOnly), false, false, protobreak.SeverityError); code != 0 {
+		t.Errorf("Expected exit code 0 for a report-only rule, got %d", code)
+	}
+	if code := exitCode(failingReports([]protobreak.FileReport{jsonName}, reportOnly), false, true, protobreak.SeverityError); code != 0 {
+		t.Errorf("Expected exit code 0 with --bit-exit for a report-only rule, got %d", code)
+	}
+	if len(jsonName.BreakingChanges) != 1 {
+		t.Errorf("Expected the report-only change to stay in the report, got %v", jsonName.BreakingChanges)
+	}
+
+	// Other rules in the same report still fail the run
+	mixed := protobreak.FileReport{File: "a.proto", BreakingChanges: append([]protobreak.BreakingChange{ruleChange(protobreak.RuleFieldNoDelete, "removed")}, jsonName.BreakingChanges...)}
+	if code := exitCode(failingReports([]protobreak.FileReport{mixed}, reportOnly), false, false, protobreak.SeverityError); code != 1 {
+		t.Errorf("Expected exit code 1 for a removal next to a report-only rule, got %d", code)
+	}
+
+	if _, err := parseReportOnlyRules("NO_SUCH_RULE"); err == nil {
+		t.Error("Expected an error for an unknown rule in --report-only-rules")
+	}
+}
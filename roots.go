@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// packageImportRoot derives the import root of a file whose directories follow its package, by
+// stripping the package path from the file's directory, e.g. proto for proto/acme/users/v1/user.proto
+// in package acme.users.v1. It returns false when the directories do not end with the package path.
+func packageImportRoot(file, pkg string) (string, bool) {
+	dir := path.Dir(filepath.ToSlash(file))
+	if pkg == "" {
+		return dir, true
+	}
+	suffix := strings.ReplaceAll(pkg, ".", "/")
+	if dir == suffix {
+		return ".", true
+	}
+	if root, ok := strings.CutSuffix(dir, "/"+suffix); ok {
+		return root, true
+	}
+	return "", false
+}
+
+// readPackage returns the package declared by the proto file name, read with open without resolving its imports
+func readPackage(open func(path string) (io.ReadCloser, error), name string) (string, error) {
+	parser := protoparse.Parser{Accessor: protoparse.FileAccessor(open)}
+	fileProtos, err := parser.ParseFilesButDoNotLink(name)
+	if err != nil {
+		return "", err
+	}
+	if len(fileProtos) == 0 {
+		return "", fmt.Errorf("no file descriptor produced for %s", name)
+	}
+	return fileProtos[0].GetPackage(), nil
+}
+
+// packageRootPaths returns the import root derived from the package of file under base with --package-roots,
+// or nothing when the flag is not set or the file's directories do not follow its package. Files that cannot
+// be read or parsed are left to the parser, which reports the error.
+func (o options) packageRootPaths(open func(path string) (io.ReadCloser, error), base, file string) []string {
+	if !o.packageRoots {
+		return nil
+	}
+	pkg, err := readPackage(open, filepath.Join(base, file))
+	if err != nil {
+		return nil
+	}
+	root, ok := packageImportRoot(file, pkg)
+	if !ok {
+		return nil
+	}
+	return []string{filepath.Join(base, filepath.FromSlash(root))}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestPackageImportRoot tests deriving import roots by stripping the package path from the file's directory
+func TestPackageImportRoot(t *testing.T) {
+	tests := []struct {
+		file string
+		pkg  string
+		root string
+		ok   bool
+	}{
+		{file: "proto/acme/users/v1/user.proto", pkg: "acme.users.v1", root: "proto", ok: true},
+		{file: "acme/users/v1/user.proto", pkg: "acme.users.v1", root: ".", ok: true},
+		{file: "services/proto/acme/v1/a.proto", pkg: "acme.v1", root: "services/proto", ok: true},
+		{file: "api/user.proto", pkg: "", root: "api", ok: true},
+		{file: "proto/acme/users/user.proto", pkg: "acme.users.v1", ok: false},
+		{file: "proto/xacme/v1/a.proto", pkg: "acme.v1", ok: false},
+	}
+
+	for _, tt := range tests {
+		root, ok := packageImportRoot(tt.file, tt.pkg)
+		if root != tt.root || ok != tt.ok {
+			t.Errorf("Expected packageImportRoot(%q, %q) to be %q, %t, got %q, %t", tt.file, tt.pkg, tt.root, tt.ok, root, ok)
+		}
+	}
+}
+
+// TestPackageRoots tests that imports of a tree whose directories follow packages resolve with --package-roots
+func TestPackageRoots(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	for _, root := range []string{oldDir, newDir} {
+		writeProtoFile(t, root, "proto/acme/common/v1/money.proto", `
+			syntax = "proto3";
+			package acme.common.v1;
+			message Money {
+				int64 units = 1;
+			}
+		`)
+	}
+	writeProtoFile(t, oldDir, "proto/acme/orders/v1/order.proto", `
+		syntax = "proto3";
+		package acme.orders.v1;
+		import "acme/common/v1/money.proto";
+		message Order {
+			acme.common.v1.Money total = 1;
+			string note = 2;
+		}
+	`)
+	writeProtoFile(t, newDir, "proto/acme/orders/v1/order.proto", `
+		syntax = "proto3";
+		package acme.orders.v1;
+		import "acme/common/v1/money.proto";
+		message Order {
+			acme.common.v1.Money total = 1;
+			reserved 2;
+			reserved "note";
+		}
+	`)
+
+	// Without the flag the imports are only found with --proto-path proto
+	if _, failed, err := compareDirs(oldDir, newDir, io.Discard, options{rules: protobreak.DefaultRuleSet()}); err != nil || !failed {
+		t.Fatalf("Expected the imports not to resolve without --package-roots, got failed %t, error %v", failed, err)
+	}
+
+	reports, failed, err := compareDirs(oldDir, newDir, io.Discard, options{rules: protobreak.DefaultRuleSet(), packageRoots: true})
+	if err != nil || failed {
+		t.Fatalf("Failed to compare directories with --package-roots: failed %t, error %v", failed, err)
+	}
+	actual := make(map[string][]string)
+	for _, report := range reports {
+		actual[report.File] = changeMessages(report.BreakingChanges)
+	}
+	expected := map[string][]string{
+		"proto/acme/common/v1/money.proto": {},
+		"proto/acme/orders/v1/order.proto": {`Field "note" (number 2) was removed from message "Order"`},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// fieldRenameRules are skipped by --ignore-field-renames, for schemas only used with the binary encoding
+var fieldRenameRules = []string{protobreak.RuleFieldSameName, protobreak.RuleFieldSameTextName}
+
+// additionRules list additive changes, enabled with --verbose
+var additionRules = []string{protobreak.RuleMessageAdded, protobreak.RuleFieldAdded, protobreak.RuleEnumValueAdded,
+	protobreak.RuleServiceAdded, protobreak.RuleRPCAdded}
+
+// writeRuleList writes every rule with its resolved severity, one per line
+func writeRuleList(w io.Writer, rs protobreak.RuleSet) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tSEVERITY\tDESCRIPTION")
+	for _, rule := range protobreak.Rules() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", rule.ID, strings.ToLower(string(rs[rule.ID])), rule.Description)
+	}
+	return tw.Flush()
+}
+
+// splitRuleList splits a comma separated list of rule IDs
+func splitRuleList(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		id = strings.ToUpper(strings.TrimSpace(id))
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseReportOnlyRules parses the comma separated rule IDs of --report-only-rules into a set
+func parseReportOnlyRules(value string) (map[string]bool, error) {
+	ids := splitRuleList(value)
+	reportOnly := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := protobreak.LookupRule(id); !ok {
+			return nil, fmt.Errorf("unknown rule %q in --report-only-rules", id)
+		}
+		reportOnly[id] = true
+	}
+	return reportOnly, nil
+}
+
+// deprecationRules are the rules reporting new deprecations, made errors by --fail-on-deprecation
+var deprecationRules = []string{protobreak.RuleDeprecationAdded, protobreak.RuleFieldDeprecated}
+
+// failOnDeprecation returns a copy of severities that makes new deprecations errors, for --fail-on-deprecation
+func failOnDeprecation(severities map[string]protobreak.Severity) map[string]protobreak.Severity {
+	result := make(map[string]protobreak.Severity, len(severities)+len(deprecationRules))
+	for id, severity := range severities {
+		result[id] = severity
+	}
+	for _, id := range deprecationRules {
+		result[id] = protobreak.SeverityError
+	}
+	return result
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestRuleSelection tests that --only-rules and --skip-rules limit which rules fire
+func TestRuleSelection(t *testing.T) {
+	prevProto := `
+		syntax = "proto3";
+		package test;
+		enum Status {
+			UNKNOWN = 0;
+			ACTIVE = 1;
+			INACTIVE = 2;
+		}
+		message Request {}
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+		}
+		service TestService {
+			rpc Get(Request) returns (TestMessage);
+			rpc List(Request) returns (TestMessage);
+		}
+	`
+	currProto := `
+		syntax = "proto3";
+		package test;
+		enum Status {
+			UNKNOWN = 0;
+			ENABLED = 1;
+		}
+		message Request {}
+		message TestMessage {
+			int64 name = 1;
+		}
+		service TestService {
+			rpc Get(TestMessage) returns (TestMessage);
+		}
+	`
+
+	tests := []struct {
+		name          string
+		only          string
+		skip          string
+		expectedRules []string
+	}{
+		{
+			name: "All rules",
+			expectedRules: []string{
+				protobreak.RuleEnumValueNoDelete,
+				protobreak.RuleEnumValueSameName,
+				protobreak.RuleFieldNoDelete,
+				protobreak.RuleFieldRemovedNotReserved,
+				protobreak.RuleFieldSameType,
+				protobreak.RuleRPCNoDelete,
+				protobreak.RuleRPCSameRequestType,
+			},
+		},
+		{
+			name: "Only delete rules",
+			only: "FIELD_NO_DELETE,ENUM_VALUE_NO_DELETE,RPC_NO_DELETE",
+			expectedRules: []string{
+				protobreak.RuleEnumValueNoDelete,
+				protobreak.RuleFieldNoDelete,
+				protobreak.RuleRPCNoDelete,
+			},
+		},
+		{
+			name: "Only a single rule",
+			only: "rpc_no_delete",
+			expectedRules: []string{
+				protobreak.RuleRPCNoDelete,
+			},
+		},
+		{
+			name: "Skip rules",
+			skip: "FIELD_SAME_TYPE, ENUM_VALUE_SAME_NAME",
+			expectedRules: []string{
+				protobreak.RuleEnumValueNoDelete,
+				protobreak.RuleFieldNoDelete,
+				protobreak.RuleFieldRemovedNotReserved,
+				protobreak.RuleRPCNoDelete,
+				protobreak.RuleRPCSameRequestType,
+			},
+		},
+		{
+			name: "Only and skip combined",
+			only: "FIELD_NO_DELETE,RPC_NO_DELETE",
+			skip: "RPC_NO_DELETE",
+			expectedRules: []string{
+				protobreak.RuleFieldNoDelete,
+			},
+		},
+	}
+
+	prevFileDesc, currFileDesc := parseTestProtos(t, prevProto, currProto)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := protobreak.NewRuleSet(splitRuleList(tt.only), splitRuleList(tt.skip), nil, nil)
+			if err != nil {
+				t.Fatalf("Failed to build rule set: %v", err)
+			}
+
+			var actualRules []string
+			for _, change := range compareFiles(prevFileDesc, currFileDesc, options{rules: rules}) {
+				actualRules = append(actualRules, change.Rule)
+			}
+			sort.Strings(actualRules)
+
+			if !reflect.DeepEqual(actualRules, tt.expectedRules) {
+				t.Errorf("Expected rules %v, got %v", tt.expectedRules, actualRules)
+			}
+		})
+	}
+}
+
+// TestListRules tests that --list-rules shows the resolved severity of every rule
+func TestListRules(t *testing.T) {
+	rules, err := protobreak.NewRuleSet(nil, []string{protobreak.RuleFieldNoDelete}, nil, map[string]protobreak.Severity{
+		protobreak.RuleFieldSameName:         protobreak.SeverityOff,
+		protobreak.RuleFieldIntEnumMigration: protobreak.SeverityError,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build rule set: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeRuleList(&buf, rules); err != nil {
+		t.Fatalf("Failed to list rules: %v", err)
+	}
+
+	severities := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n")[1:] {
+		columns := strings.Fields(line)
+		severities[columns[0]] = columns[1]
+	}
+
+	expected := map[string]string{
+		protobreak.RuleFieldNoDelete:           "off",
+		protobreak.RuleFieldSameName:           "off",
+		protobreak.RuleFieldSameTextName:       "off",
+		protobreak.RuleFieldIntEnumMigration:   "error",
+		protobreak.RuleFieldSameType:           "error",
+		protobreak.RuleFieldWireCompatibleType: "warning",
+	}
+	for id, severity := range expected {
+		if severities[id] != severity {
+			t.Errorf("Expected %s to be listed as %s, got %q", id, severity, severities[id])
+		}
+	}
+	if len(severities) != len(protobreak.Rules()) {
+		t.Errorf("Expected %d rules to be listed, got %d", len(protobreak.Rules()), len(severities))
+	}
+}
+
+// TestFailOnDeprecation tests that --fail-on-deprecation enables the deprecation rules as errors
+func TestFailOnDeprecation(t *testing.T) {
+	rules, err := protobreak.NewRuleSet(nil, nil, deprecationRules, failOnDeprecation(map[string]protobreak.Severity{
+		protobreak.RuleFieldDeprecated: protobreak.SeverityInfo,
+	}))
+	if err != nil {
+		t.Fatalf("Failed to build rule set: %v", err)
+	}
+	for _, id := range deprecationRules {
+		if rules[id] != protobreak.SeverityError {
+			t.Errorf("Expected %s to be an error, got %s", id, rules[id])
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// jsonSchema is a JSON Schema document or sub-schema
+type jsonSchema map[string]interface{}
+
+// configSchema returns the JSON Schema of the config file. The structure is derived from the
+// yaml and doc tags of config, and the rules property lists every rule of the registry.
+func configSchema() jsonSchema {
+	schema := schemaForType(reflect.TypeOf(config{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = defaultConfigPath
+
+	// Severities are case-insensitive and rule IDs are matched in any case
+	severities := []string{}
+	for _, severity := range []protobreak.Severity{protobreak.SeverityError, protobreak.SeverityWarning, protobreak.SeverityInfo, protobreak.SeverityOff} {
+		severities = append(severities, strings.ToLower(string(severity)), string(severity))
+	}
+	ruleProperties := jsonSchema{}
+	for _, rule := range protobreak.Rules() {
+		defaults := "default: " + strings.ToLower(string(rule.Severity))
+		if rule.OptIn {
+			defaults = "opt-in, " + defaults
+		}
+		ruleProperties[rule.ID] = jsonSchema{
+			"description": fmt.Sprintf("%s (%s)", rule.Description, defaults),
+			"enum":        severities,
+		}
+	}
+	rules := schema["properties"].(jsonSchema)["rules"].(jsonSchema)
+	rules["properties"] = ruleProperties
+	rules["additionalProperties"] = jsonSchema{"enum": severities}
+	fileOptions := schema["properties"].(jsonSchema)["file_options"].(jsonSchema)
+	fileOptions["additionalProperties"] = jsonSchema{"enum": severities}
+	return schema
+}
+
+// schemaForType returns the JSON Schema of a Go type decoded from YAML
+func schemaForType(t reflect.Type) jsonSchema {
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := jsonSchema{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			property := schemaForType(field.Type)
+			if doc := field.Tag.Get("doc"); doc != "" {
+				property["description"] = doc
+			}
+			properties[name] = property
+		}
+		return jsonSchema{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Slice:
+		return jsonSchema{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return jsonSchema{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return jsonSchema{"type": "integer"}
+	case reflect.Bool:
+		return jsonSchema{"type": "boolean"}
+	default:
+		return jsonSchema{"type": "string"}
+	}
+}
+
+// writeConfigSchema writes the JSON Schema of the config file
+func writeConfigSchema(w io.Writer) error {
+	data, err := json.MarshalIndent(configSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestConfigSchema tests that the config schema describes every rule and config key
+func TestConfigSchema(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeConfigSchema(&buf); err != nil {
+		t.Fatalf("Failed to write config schema: %v", err)
+	}
+
+	var schema struct {
+		Properties struct {
+			Rules struct {
+				Properties map[string]struct {
+					Enum []string `json:"enum"`
+				} `json:"properties"`
+			} `json:"rules"`
+			SoftReserved struct {
+				Type  string `json:"type"`
+				Items struct {
+					Properties map[string]struct {
+						Type string `json:"type"`
+					} `json:"properties"`
+				} `json:"items"`
+			} `json:"soft_reserved"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatalf("Failed to decode config schema: %v", err)
+	}
+
+	for _, id := range []string{protobreak.RuleFieldNoDelete, protobreak.RuleFieldSameName, protobreak.RuleFileSameSyntax} {
+		property, ok := schema.Properties.Rules.Properties[id]
+		if !ok {
+			t.Errorf("Expected rule %s in schema", id)
+			continue
+		}
+		if len(property.Enum) != 8 || property.Enum[0] != "error" {
+			t.Errorf("Expected the severities of %s, got %v", id, property.Enum)
+		}
+	}
+	if len(schema.Properties.Rules.Properties) != len(protobreak.Rules()) {
+		t.Errorf("Expected %d rules in schema, got %d", len(protobreak.Rules()), len(schema.Properties.Rules.Properties))
+	}
+
+	if schema.Properties.SoftReserved.Type != "array" || schema.Properties.SoftReserved.Items.Properties["start"].Type != "integer" {
+		t.Errorf("Expected soft_reserved to be an array of ranges, got %+v", schema.Properties.SoftReserved)
+	}
+}
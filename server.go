@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take once the server is stopping
+const shutdownTimeout = 10 * time.Second
+
+// Timeouts for reading requests, so that slow or stalled clients do not hold connections forever
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = time.Minute
+)
+
+// maxRequestBodySize is the size in bytes above which /compare bodies are rejected with 413
+const maxRequestBodySize = 32 << 20
+
+// compareRequest is the body accepted by POST /compare. Either both proto
+// sources or both serialized FileDescriptorSets (base64 in JSON) must be set.
+type compareRequest struct {
+	File             string `json:"file"`
+	Old              string `json:"old"`
+	New              string `json:"new"`
+	OldDescriptorSet []byte `json:"old_descriptor_set"`
+	NewDescriptorSet []byte `json:"new_descriptor_set"`
+}
+
+// serve runs the HTTP server until it receives SIGINT or SIGTERM.
+// Server errors and lifecycle messages are written to stderr through its logger.
+func serve(addr string, opts options) error {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           newServerHandler(opts, logger),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		ErrorLog:          logger,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Printf("Listening on %s", addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Stop accepting connections and let in-flight requests finish
+	logger.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newServerHandler returns the HTTP handler serving the compare endpoint, logging to logger
+func newServerHandler(opts options, logger *log.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/compare", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req compareRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		reports, err := compareRequestBody(req, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(reports); err != nil {
+			logger.Printf("Error writing response: %v", err)
+		}
+	})
+	return mux
+}
+
+// compareRequestBody compares the two versions carried by a compare request
+func compareRequestBody(req compareRequest, opts options) ([]protobreak.FileReport, error) {
+	hasSources := req.Old != "" || req.New != ""
+	hasDescriptorSets := len(req.OldDescriptorSet) > 0 || len(req.NewDescriptorSet) > 0
+
+	switch {
+	case hasSources && hasDescriptorSets:
+		return nil, fmt.Errorf("provide either proto sources or descriptor sets, not both")
+	case hasSources:
+		if req.Old == "" || req.New == "" {
+			return nil, fmt.Errorf("both old and new proto sources are required")
+		}
+		name := req.File
+		if name == "" {
+			name = "input.proto"
+		}
+
+		prevFileDesc, err := parseProtoSourceToReflect(name, req.Old)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing old proto source: %v", err)
+		}
+		currFileDesc, err := parseProtoSourceToReflect(name, req.New)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing new proto source: %v", err)
+		}
+
+		changes := compareFiles(prevFileDesc, currFileDesc, opts)
+		return []protobreak.FileReport{{File: name, BreakingChanges: nonNilChanges(changes)}}, nil
+	case hasDescriptorSets:
+		if len(req.OldDescriptorSet) == 0 || len(req.NewDescriptorSet) == 0 {
+			return nil, fmt.Errorf("both old and new descriptor sets are required")
+		}
+
+		prevFiles, err := unmarshalDescriptorSet(req.OldDescriptorSet)
+		if err != nil {
+			return nil, fmt.Errorf("error reading old descriptor set: %v", err)
+		}
+		currFiles, err := unmarshalDescriptorSet(req.NewDescriptorSet)
+		if err != nil {
+			return nil, fmt.Errorf("error reading new descriptor set: %v", err)
+		}
+
+		// Compare files present in both sets, in path order
+		var paths []string
+		for path := range currFiles {
+			if _, ok := prevFiles[path]; ok {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+
+		reports := []protobreak.FileReport{}
+		for _, path := range paths {
+			changes := compareFiles(prevFiles[path], currFiles[path], opts)
+			reports = append(reports, protobreak.FileReport{File: path, BreakingChanges: nonNilChanges(changes)})
+		}
+		return reports, nil
+	default:
+		return nil, fmt.Errorf("request must contain old and new proto sources or descriptor sets")
+	}
+}
+
+// unmarshalDescriptorSet decodes a serialized FileDescriptorSet into file descriptors keyed by path
+func unmarshalDescriptorSet(data []byte) (map[string]protoreflect.FileDescriptor, error) {
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &fds); err != nil {
+		return nil, err
+	}
+	return filesFromDescriptorSet(&fds)
+}
+
+// nonNilChanges makes sure an empty result is encoded as an empty JSON array
+func nonNilChanges(changes []protobreak.BreakingChange) []protobreak.BreakingChange {
+	if changes == nil {
+		return []protobreak.BreakingChange{}
+	}
+	return changes
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestServerCompare tests the POST /compare endpoint with proto sources and descriptor sets
+func TestServerCompare(t *testing.T) {
+	prevProto := `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+		}
+	`
+	currProto := `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+		}
+	`
+
+	server := httptest.NewServer(newServerHandler(options{rules: protobreak.DefaultRuleSet()}, log.New(io.Discard, "", 0)))
+	defer server.Close()
+
+	post := func(t *testing.T, req compareRequest) []protobreak.FileReport {
+		t.Helper()
+		body, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+		resp, err := http.Post(server.URL+"/compare", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to call server: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var reports []protobreak.FileReport
+		if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return reports
+	}
+
+	expected := protobreak.BreakingChange{
+		Rule:     protobreak.RuleFieldNoDelete,
+		Severity: protobreak.SeverityError,
+		Message:  `Field "age" (number 2) was removed from message "TestMessage"`,
+		Path:     []string{"TestMessage", "age"},
+		Line:     4,
+		Column:   17,
+	}
+
+	t.Run("Proto sources", func(t *testing.T) {
+		reports := post(t, compareRequest{File: "test.proto", Old: prevProto, New: currProto})
+		if len(reports) != 1 || reports[0].File != "test.proto" {
+			t.Fatalf("Expected a single report for test.proto, got %+v", reports)
+		}
+		if len(reports[0].BreakingChanges) != 2 || !reflect.DeepEqual(reports[0].BreakingChanges[0], expected) {
+			t.Errorf("Expected %+v, got %+v", expected, reports[0].BreakingChanges)
+		}
+	})
+
+	t.Run("Descriptor sets", func(t *testing.T) {
+		prevFileDesc, currFileDesc := parseTestProtos(t, prevProto, currProto)
+		encode := func(t *testing.T, file *descriptorpb.FileDescriptorProto) []byte {
+			file.Name = proto.String("test.proto")
+			data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+			if err != nil {
+				t.Fatalf("Failed to encode descriptor set: %v", err)
+			}
+			return data
+		}
+
+		reports := post(t, compareRequest{
+			OldDescriptorSet: encode(t, protodesc.ToFileDescriptorProto(prevFileDesc)),
+			NewDescriptorSet: encode(t, protodesc.ToFileDescriptorProto(currFileDesc)),
+		})
+		if len(reports) != 1 || reports[0].File != "test.proto" {
+			t.Fatalf("Expected a single report for test.proto, got %+v", reports)
+		}
+		// parseTestProtos trims the leading newline of the sources, moving the message up a line
+		located := expected
+		located.Line--
+		if len(reports[0].BreakingChanges) != 2 || !reflect.DeepEqual(reports[0].BreakingChanges[0], located) {
+			t.Errorf("Expected %+v, got %+v", located, reports[0].BreakingChanges)
+		}
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		for _, body := range []string{`{`, `{}`, `{"old": "syntax = \"proto3\";"}`} {
+			resp, err := http.Post(server.URL+"/compare", "application/json", bytes.NewReader([]byte(body)))
+			if err != nil {
+				t.Fatalf("Failed to call server: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", body, resp.StatusCode)
+			}
+		}
+
+		tooLarge := `{"old": "` + strings.Repeat(" ", maxRequestBodySize) + `"}`
+		resp, err := http.Post(server.URL+"/compare", "application/json", strings.NewReader(tooLarge))
+		if err != nil {
+			t.Fatalf("Failed to call server: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413 for a body over %d bytes, got %d", maxRequestBodySize, resp.StatusCode)
+		}
+
+		resp, err = http.Get(server.URL + "/compare")
+		if err != nil {
+			t.Fatalf("Failed to call server: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405 for GET, got %d", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Values of --oversize-action, what happens to files larger than --max-file-size
+const (
+	oversizeSkip  = "skip"
+	oversizeError = "error"
+)
+
+// errFileTooLarge is wrapped by the errors of files larger than --max-file-size
+var errFileTooLarge = errors.New("file exceeds --max-file-size")
+
+// checkOversizeAction validates an --oversize-action value
+func checkOversizeAction(value string) error {
+	if value != oversizeSkip && value != oversizeError {
+		return fmt.Errorf("unknown --oversize-action value %q, expected %s or %s", value, oversizeSkip, oversizeError)
+	}
+	return nil
+}
+
+// checkFileSize returns an error wrapping errFileTooLarge when size exceeds the --max-file-size of opts
+func (o options) checkFileSize(file string, size int64) error {
+	if o.maxFileSize > 0 && size > o.maxFileSize {
+		return fmt.Errorf("%s is %d bytes, over the limit of %d bytes: %w", file, size, o.maxFileSize, errFileTooLarge)
+	}
+	return nil
+}
+
+// skipsOversized reports whether err is about a file larger than --max-file-size that is skipped with a warning
+func (o options) skipsOversized(err error) bool {
+	return o.oversizeAction == oversizeSkip && errors.Is(err, errFileTooLarge)
+}
+
+// checkFileSizeOnDisk applies checkFileSize to a file on disk. Missing files are left to the parser.
+func (o options) checkFileSizeOnDisk(path string) error {
+	if o.maxFileSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	return o.checkFileSize(path, info.Size())
+}
+
+// checkFileSizeAtCommit applies checkFileSize to a file at a commit, without reading its content.
+// Files missing at the commit are left to the parser.
+func (o options) checkFileSizeAtCommit(repo gitRepo, file, commit string) error {
+	if o.maxFileSize <= 0 {
+		return nil
+	}
+	output, err := repo.command("cat-file", "-s", commit+":"+filepath.ToSlash(file)).Output()
+	if err != nil {
+		return nil
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("error reading the size of %s at %s: %v", file, commit, err)
+	}
+	return o.checkFileSize(file+" at "+commit, size)
+}
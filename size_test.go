@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestMaxFileSize tests that files over --max-file-size are rejected before parsing, in git and in directories
+func TestMaxFileSize(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	repo := gitRepo{gitDir: filepath.Join(root, ".git"), workTree: root}
+	runGit(t, gitRepo{}, "init", "--quiet", root)
+
+	large := `syntax = "proto3"; package test; message Large { string padding = 1; } // ` + strings.Repeat("x", 200)
+	small := `syntax = "proto3"; package test; message Large {}`
+	writeRepoFile(t, repo, "large.proto", large)
+	runGit(t, repo, "add", "large.proto")
+	runGit(t, repo, "commit", "--quiet", "-m", "large")
+
+	// The previous version is checked too, even when the working tree version is small
+	writeRepoFile(t, repo, "large.proto", small)
+	opts := options{rules: protobreak.DefaultRuleSet(), maxFileSize: 100, oversizeAction: oversizeError}
+	_, _, err := parseProtoVersions(repo, "large.proto", "HEAD", opts)
+	if !errors.Is(err, errFileTooLarge) {
+		t.Fatalf("Expected a file size error for the previous version, got %v", err)
+	}
+	if opts.skipsOversized(err) {
+		t.Error("Expected oversized files to fail with --oversize-action error")
+	}
+	opts.oversizeAction = oversizeSkip
+	if !opts.skipsOversized(err) {
+		t.Error("Expected oversized files to be skipped with --oversize-action skip")
+	}
+
+	// Files under the limit are parsed as usual
+	opts.maxFileSize = 1000
+	if _, _, err := parseProtoVersions(repo, "large.proto", "HEAD", opts); err != nil {
+		t.Errorf("Expected files under the limit to be parsed, got %v", err)
+	}
+
+	// Skipped files are left out of directory comparisons without failing them
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	writeProtoFile(t, oldDir, "large.proto", large)
+	writeProtoFile(t, newDir, "large.proto", small)
+	opts.maxFileSize = 100
+	reports, failed, err := compareDirs(oldDir, newDir, io.Discard, opts)
+	if err != nil || failed || len(reports) != 0 {
+		t.Errorf("Expected the oversized file to be skipped, got reports %v, failed %t, error %v", reports, failed, err)
+	}
+	opts.oversizeAction = oversizeError
+	if _, failed, _ := compareDirs(oldDir, newDir, io.Discard, opts); !failed {
+		t.Error("Expected the oversized file to fail the comparison with --oversize-action error")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// findProtoFiles returns the .proto files under root as slash-separated relative paths.
+// Hidden directories such as .git are skipped.
+func findProtoFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".proto" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// parseProtoTree parses every proto file under root, keying descriptors by their relative path.
+// Imports that are not under root are resolved from importPaths, then from the files of a
+// baseline image so that types only defined in the image's dependencies still resolve.
+func parseProtoTree(root string, imageFiles map[string]protoreflect.FileDescriptor, importPaths ...string) ([]*desc.FileDescriptor, error) {
+	files, err := findProtoFiles(root)
+	if err != nil {
+		return nil, fmt.Errorf("error finding proto files: %v", err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	imports := make(map[string]*descriptorpb.FileDescriptorProto, len(imageFiles))
+	for path, file := range imageFiles {
+		imports[path] = protodesc.ToFileDescriptorProto(file)
+	}
+	return ParseProtoFilesWithImports(append([]string{root}, importPaths...), imports, files...)
+}
+
+// writeSnapshot writes file descriptors and their dependencies as a FileDescriptorSet
+func writeSnapshot(path string, fileDescs []*desc.FileDescriptor) error {
+	data, err := proto.Marshal(desc.ToFileDescriptorSet(fileDescs...))
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing snapshot: %v", err)
+	}
+	return nil
+}
+
+// loadImageFiles loads the files of a baseline image, keyed by path. The image is either a
+// FileDescriptorSet or an archive of proto sources, such as a release bundle.
+func loadImageFiles(imagePath string, importPaths []string) (map[string]protoreflect.FileDescriptor, error) {
+	if isArchive(imagePath) {
+		prevFiles, err := filesFromArchive(imagePath, importPaths)
+		if err != nil {
+			return nil, fmt.Errorf("error loading archive %s: %v", imagePath, err)
+		}
+		return prevFiles, nil
+	}
+
+	fds, err := loadFileDescriptorSet(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading image %s: %v", imagePath, err)
+	}
+	prevFiles, err := filesFromDescriptorSet(fds)
+	if err != nil {
+		return nil, fmt.Errorf("error building descriptors from image %s: %v", imagePath, err)
+	}
+	return prevFiles, nil
+}
+
+// compareAgainstImage compares current files with the files of the same path in a baseline image
+func compareAgainstImage(prevFiles map[string]protoreflect.FileDescriptor, fileDescs []*desc.FileDescriptor, opts options) []protobreak.FileReport {
+	var reports []protobreak.FileReport
+	for _, fileDesc := range fileDescs {
+		var currFileDesc protoreflect.FileDescriptor = fileDesc.UnwrapFile()
+		prevFileDesc, ok := prevFiles[currFileDesc.Path()]
+		if !ok {
+			// New files cannot break anything
+			continue
+		}
+		changes := compareFiles(prevFileDesc, currFileDesc, opts)
+		reports = append(reports, protobreak.FileReport{File: currFileDesc.Path(), BreakingChanges: changes})
+	}
+	return reports
+}
+
+// compareDescriptorSets compares the files present in both descriptor sets, matched by path and
+// sorted by it. Files only present in one of them, such as dependencies that were added or dropped, are ignored.
+func compareDescriptorSets(prevFiles, currFiles map[string]protoreflect.FileDescriptor, opts options) []protobreak.FileReport {
+	paths := make([]string, 0, len(currFiles))
+	for path := range currFiles {
+		if _, ok := prevFiles[path]; ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	reports := make([]protobreak.FileReport, 0, len(paths))
+	for _, path := range paths {
+		changes := compareFiles(prevFiles[path], currFiles[path], opts)
+		reports = append(reports, protobreak.FileReport{File: path, BreakingChanges: changes})
+	}
+	return reports
+}
+
+// runDescriptorSets compares the descriptor sets given by --old-descriptor-set and --new-descriptor-set,
+// returning the process exit code. The report is written to out and progress to status.
+func runDescriptorSets(oldPath, newPath, format string, out, status io.Writer, opts options) int {
+	fmt.Fprintf(status, "Analyzing changes from %s to %s...\n", oldPath, newPath)
+	prevFiles, err := loadImageFiles(oldPath, opts.importPaths)
+	if err != nil {
+		fmt.Fprintf(status, "Error: %v\n", err)
+		return exitCode(nil, true, opts.bitExit, opts.failOn)
+	}
+	currFiles, err := loadImageFiles(newPath, opts.importPaths)
+	if err != nil {
+		fmt.Fprintf(status, "Error: %v\n", err)
+		return exitCode(nil, true, opts.bitExit, opts.failOn)
+	}
+
+	valid := true
+	for path, currFile := range currFiles {
+		if _, ok := prevFiles[path]; !ok {
+			continue
+		}
+		if err := validateSyntax(currFile, opts.requireSyntax); err != nil {
+			fmt.Fprintf(status, "Error: %s %v\n", path, err)
+			valid = false
+		}
+	}
+	if !valid {
+		return 1
+	}
+
+	return finishReports(compareDescriptorSets(prevFiles, currFiles, opts), false, format, out, status, opts)
+}
+
+// runSnapshot compares the tree under root against a baseline image and/or
+// writes a snapshot of it, returning the process exit code. The report is written to out and progress to status.
+func runSnapshot(root, againstImage, writeSnapshotPath, format string, out, status io.Writer, opts options) int {
+	// Load the image first so that its files can resolve imports of the tree
+	var prevFiles map[string]protoreflect.FileDescriptor
+	if againstImage != "" {
+		var err error
+		prevFiles, err = loadImageFiles(againstImage, opts.importPaths)
+		if err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	fileDescs, err := parseProtoTree(root, prevFiles, opts.importPathsUnder(root)...)
+	if err != nil {
+		fmt.Fprintf(status, "Error parsing proto files: %v\n", err)
+		return exitCode(nil, true, opts.bitExit, opts.failOn)
+	}
+
+	valid := true
+	for _, fileDesc := range fileDescs {
+		if err := validateSyntax(fileDesc.UnwrapFile(), opts.requireSyntax); err != nil {
+			fmt.Fprintf(status, "Error: %s %v\n", fileDesc.GetName(), err)
+			valid = false
+		}
+	}
+	if !valid {
+		return 1
+	}
+
+	var reports []protobreak.FileReport
+	if againstImage != "" {
+		if opts.anchorType != "" {
+			currFiles := make(map[string]protoreflect.FileDescriptor, len(fileDescs))
+			for _, fileDesc := range fileDescs {
+				currFiles[fileDesc.GetName()] = fileDesc.UnwrapFile()
+			}
+			report, err := compareAnchoredType(prevFiles, currFiles, opts.anchorType, opts)
+			if err != nil {
+				fmt.Fprintf(status, "Error: %v\n", err)
+				return 1
+			}
+			reports = []protobreak.FileReport{report}
+		} else {
+			reports = compareAgainstImage(prevFiles, fileDescs, opts)
+		}
+
+		for i, report := range reports {
+			reports[i].BreakingChanges = opts.suppressions.filter(report.BreakingChanges)
+		}
+		warnUnmatchedSuppressions(status, opts.suppressions)
+
+		if opts.writeBaselinePath != "" {
+			if err := writeBaseline(opts.writeBaselinePath, reports, opts.baselineIgnoreWarnings); err != nil {
+				fmt.Fprintf(status, "Error: %v\n", err)
+				return 1
+			}
+		}
+		for i, report := range reports {
+			reports[i] = opts.baseline.diff(report)
+		}
+
+		fmt.Fprintf(status, "Found %d proto files to compare against %s\n", len(reports), againstImage)
+		if err := writeReports(out, format, reports); err != nil {
+			fmt.Fprintf(status, "Error writing report: %v\n", err)
+			return 1
+		}
+	}
+
+	// Write the snapshot last so that it can replace the image it was compared against
+	if writeSnapshotPath != "" {
+		if err := writeSnapshot(writeSnapshotPath, fileDescs); err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(status, "Wrote snapshot of %d proto files to %s\n", len(fileDescs), writeSnapshotPath)
+	}
+
+	return exitCode(failingReports(reports, opts.reportOnlyRules), false, opts.bitExit, opts.failOn)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Helper function to write a proto file below a root directory
+func writeProtoFile(t *testing.T, root, file, content string) {
+	t.Helper()
+
+	path := filepath.Join(root, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory for %s: %v", file, err)
+	}
+	if err := os.WriteFile(path, []byte(strings.TrimSpace(content)), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", file, err)
+	}
+}
+
+// TestSnapshotRoundTrip tests writing a snapshot and using it as the baseline of a later run
+func TestSnapshotRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.binpb")
+
+	writeProtoFile(t, root, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+			int32 age = 2;
+		}
+	`)
+	writeProtoFile(t, root, "README.txt", "not a proto file")
+
+	fileDescs, err := parseProtoTree(root, nil)
+	if err != nil {
+		t.Fatalf("Failed to parse proto tree: %v", err)
+	}
+	if err := writeSnapshot(snapshotPath, fileDescs); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	// Change the tree and compare it against the snapshot
+	writeProtoFile(t, root, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		message TestMessage {
+			string name = 1;
+		}
+	`)
+	writeProtoFile(t, root, "api/new.proto", `
+		syntax = "proto3";
+		package test;
+		message NewMessage {}
+	`)
+
+	fileDescs, err = parseProtoTree(root, nil)
+	if err != nil {
+		t.Fatalf("Failed to parse proto tree: %v", err)
+	}
+	prevFiles, err := loadImageFiles(snapshotPath, nil)
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	reports := compareAgainstImage(prevFiles, fileDescs, options{rules: protobreak.DefaultRuleSet()})
+
+	if len(reports) != 1 || reports[0].File != "api/test.proto" {
+		t.Fatalf("Expected a single report for api/test.proto, got %+v", reports)
+	}
+	expected := []string{
+		`Field "age" (number 2) was removed from message "TestMessage"`,
+		`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+	}
+	if !reflect.DeepEqual(changeMessages(reports[0].BreakingChanges), expected) {
+		t.Errorf("Expected errors %v, got %v", expected, changeMessages(reports[0].BreakingChanges))
+	}
+}
+
+// TestSnapshotResolvesImageImports tests that local files can import types only present in the image's dependencies
+func TestSnapshotResolvesImageImports(t *testing.T) {
+	imageRoot := t.TempDir()
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.binpb")
+
+	writeProtoFile(t, imageRoot, "deps/common.proto", `
+		syntax = "proto3";
+		package deps;
+		message Common {}
+	`)
+	writeProtoFile(t, imageRoot, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		import "deps/common.proto";
+		message TestMessage {
+			deps.Common common = 1;
+			int32 age = 2;
+		}
+	`)
+	fileDescs, err := parseProtoTree(imageRoot, nil)
+	if err != nil {
+		t.Fatalf("Failed to parse image tree: %v", err)
+	}
+	if err := writeSnapshot(snapshotPath, fileDescs); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	// The local tree does not contain deps/common.proto
+	root := t.TempDir()
+	writeProtoFile(t, root, "api/test.proto", `
+		syntax = "proto3";
+		package test;
+		import "deps/common.proto";
+		message TestMessage {
+			deps.Common common = 1;
+		}
+	`)
+
+	prevFiles, err := loadImageFiles(snapshotPath, nil)
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if _, err := parseProtoTree(root, nil); err == nil {
+		t.Fatal("Expected the import to be unresolved without the image")
+	}
+	fileDescs, err = parseProtoTree(root, prevFiles)
+	if err != nil {
+		t.Fatalf("Failed to parse proto tree with image imports: %v", err)
+	}
+	if len(fileDescs) != 1 {
+		t.Fatalf("Expected only the local file to be parsed, got %d files", len(fileDescs))
+	}
+
+	reports := compareAgainstImage(prevFiles, fileDescs, options{rules: protobreak.DefaultRuleSet()})
+	if len(reports) != 1 || reports[0].File != "api/test.proto" {
+		t.Fatalf("Expected a single report for api/test.proto, got %+v", reports)
+	}
+	expected := []string{
+		`Field "age" (number 2) was removed from message "TestMessage"`,
+		`Removed field "age" (number 2) is not reserved in message "TestMessage"`,
+	}
+	if !reflect.DeepEqual(changeMessages(reports[0].BreakingChanges), expected) {
+		t.Errorf("Expected errors %v, got %v", expected, changeMessages(reports[0].BreakingChanges))
+	}
+}
+
+// TestAnchorTypeMovedFile tests comparing an anchored message that moved to another file
+func TestAnchorTypeMovedFile(t *testing.T) {
+	prevRoot := t.TempDir()
+	writeProtoFile(t, prevRoot, "old/config.proto", `
+		syntax = "proto3";
+		package test;
+		message SharedConfig {
+			string name = 1;
+			int32 retries = 2;
+		}
+		message Unrelated {
+			string id = 1;
+		}
+	`)
+	currRoot := t.TempDir()
+	writeProtoFile(t, currRoot, "shared/config.proto", `
+		syntax = "proto3";
+		package test;
+		message SharedConfig {
+			string name = 1;
+		}
+	`)
+
+	parseFiles := func(root string) map[string]protoreflect.FileDescriptor {
+		fileDescs, err := parseProtoTree(root, nil)
+		if err != nil {
+			t.Fatalf("Failed to parse proto tree: %v", err)
+		}
+		files := make(map[string]protoreflect.FileDescriptor)
+		for _, fileDesc := range fileDescs {
+			files[fileDesc.GetName()] = fileDesc.UnwrapFile()
+		}
+		return files
+	}
+	prevFiles := parseFiles(prevRoot)
+	currFiles := parseFiles(currRoot)
+	opts := options{rules: protobreak.DefaultRuleSet()}
+
+	report, err := compareAnchoredType(prevFiles, currFiles, "test.SharedConfig", opts)
+	if err != nil {
+		t.Fatalf("Failed to compare anchored type: %v", err)
+	}
+	if report.File != "shared/config.proto" {
+		t.Errorf("Expected the report for shared/config.proto, got %s", report.File)
+	}
+	// The removed Unrelated message is not reported
+	expected := []string{
+		`Field "retries" (number 2) was removed from message "SharedConfig"`,
+		`Removed field "retries" (number 2) is not reserved in message "SharedConfig"`,
+	}
+	if !reflect.DeepEqual(changeMessages(report.BreakingChanges), expected) {
+		t.Errorf("Expected errors %v, got %v", expected, changeMessages(report.BreakingChanges))
+	}
+
+	report, err = compareAnchoredType(prevFiles, currFiles, "test.Unrelated", opts)
+	if err != nil {
+		t.Fatalf("Failed to compare anchored type: %v", err)
+	}
+	expected = []string{`Message "Unrelated" was removed`}
+	if report.File != "old/config.proto" || !reflect.DeepEqual(changeMessages(report.BreakingChanges), expected) {
+		t.Errorf("Expected errors %v for old/config.proto, got %+v", expected, report)
+	}
+
+	if _, err := compareAnchoredType(prevFiles, currFiles, "test.Missing", opts); err == nil {
+		t.Error("Expected an error for an anchor type missing from the previous files")
+	}
+}
+
+// TestCompareDescriptorSets tests comparing two descriptor sets by file path, including files that import others
+func TestCompareDescriptorSets(t *testing.T) {
+	writeSet := func(files map[string]string) string {
+		t.Helper()
+		root := t.TempDir()
+		for file, content := range files {
+			writeProtoFile(t, root, file, content)
+		}
+		fileDescs, err := parseProtoTree(root, nil)
+		if err != nil {
+			t.Fatalf("Failed to parse proto tree: %v", err)
+		}
+		path := filepath.Join(t.TempDir(), "set.binpb")
+		if err := writeSnapshot(path, fileDescs); err != nil {
+			t.Fatalf("Failed to write descriptor set: %v", err)
+		}
+		return path
+	}
+	common := `
+		syntax = "proto3";
+		package common;
+		message Money {
+			int64 units = 1;
+		}
+	`
+	oldPath := writeSet(map[string]string{
+		"common/money.proto": common,
+		"api/order.proto": `
+			syntax = "proto3";
+			package api;
+			import "common/money.proto";
+			message Order {
+				common.Money total = 1;
+				string note = 2;
+			}
+		`,
+		"api/legacy.proto": `
+			syntax = "proto3";
+			package api;
+			message Legacy {}
+		`,
+	})
+	newPath := writeSet(map[string]string{
+		"common/money.proto": common,
+		"api/order.proto": `
+			syntax = "proto3";
+			package api;
+			import "common/money.proto";
+			message Order {
+				reserved 2;
+				reserved "note";
+				common.Money total = 1;
+			}
+		`,
+	})
+
+	prevFiles, err := loadImageFiles(oldPath, nil)
+	if err != nil {
+		t.Fatalf("Failed to load old descriptor set: %v", err)
+	}
+	currFiles, err := loadImageFiles(newPath, nil)
+	if err != nil {
+		t.Fatalf("Failed to load new descriptor set: %v", err)
+	}
+	reports := compareDescriptorSets(prevFiles, currFiles, options{rules: protobreak.DefaultRuleSet()})
+
+	// Files missing from the new set are ignored and the others are sorted by path
+	var files []string
+	for _, report := range reports {
+		files = append(files, report.File)
+	}
+	if expected := []string{"api/order.proto", "common/money.proto"}; !reflect.DeepEqual(files, expected) {
+		t.Fatalf("Expected reports for %v, got %v", expected, files)
+	}
+	expected := []string{`Field "note" (number 2) was removed from message "Order"`}
+	if actual := changeMessages(reports[0].BreakingChanges); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected errors %v, got %v", expected, actual)
+	}
+	if len(reports[1].BreakingChanges) != 0 {
+		t.Errorf("Expected no changes in common/money.proto, got %v", changeMessages(reports[1].BreakingChanges))
+	}
+}
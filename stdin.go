@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// stdinPairFile names the two sources read by --stdin-pair in reports
+const stdinPairFile = "stdin.proto"
+
+// splitProtoPair splits the old and new proto sources read by --stdin-pair. The sources are
+// separated by a NUL byte or, when there is none, by the first line consisting of ---.
+func splitProtoPair(input string) (string, string, error) {
+	if prev, curr, ok := strings.Cut(input, "\x00"); ok {
+		return prev, curr, nil
+	}
+
+	lines := strings.SplitAfter(input, "\n")
+	for i, line := range lines {
+		if strings.TrimRight(line, "\r\n") == "---" {
+			return strings.Join(lines[:i], ""), strings.Join(lines[i+1:], ""), nil
+		}
+	}
+	return "", "", fmt.Errorf("expected the old and new proto sources separated by a NUL byte or a --- line")
+}
+
+// compareProtoPair parses the two sources read by --stdin-pair in memory and compares them
+func compareProtoPair(input string, opts options) ([]protobreak.BreakingChange, error) {
+	prevSource, currSource, err := splitProtoPair(input)
+	if err != nil {
+		return nil, err
+	}
+
+	prevFileDesc, err := parseProtoSourceToReflect(stdinPairFile, prevSource)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing old proto source: %v", err)
+	}
+	currFileDesc, err := parseProtoSourceToReflect(stdinPairFile, currSource)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing new proto source: %v", err)
+	}
+	if err := validateSyntax(currFileDesc, opts.requireSyntax); err != nil {
+		return nil, fmt.Errorf("new proto source %v", err)
+	}
+
+	return compareFiles(prevFileDesc, currFileDesc, opts), nil
+}
+
+// runStdinPair compares the two proto sources read from in, returning the process exit code.
+// The report is written to out and progress to status.
+func runStdinPair(in io.Reader, format string, out, status io.Writer, opts options) int {
+	input, err := io.ReadAll(in)
+	if err != nil {
+		fmt.Fprintf(status, "Error reading stdin: %v\n", err)
+		return exitCode(nil, true, opts.bitExit, opts.failOn)
+	}
+
+	breakingChanges, err := compareProtoPair(string(input), opts)
+	if err != nil {
+		fmt.Fprintf(status, "Error: %v\n", err)
+		return exitCode(nil, true, opts.bitExit, opts.failOn)
+	}
+	return finishReports([]protobreak.FileReport{{File: stdinPairFile, BreakingChanges: breakingChanges}}, false, format, out, status, opts)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/valentine-shevchenko/proto-break/protobreak"
+)
+
+// TestCompareProtoPair tests comparing two piped sources split by either separator
+func TestCompareProtoPair(t *testing.T) {
+	prev := "syntax = \"proto3\";\npackage test;\nmessage User {\n  string name = 1;\n  int32 age = 2;\n}\n"
+	curr := "syntax = \"proto3\";\npackage test;\nmessage User {\n  reserved 2;\n  reserved \"age\";\n  string name = 1;\n}\n"
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "NUL separator", input: prev + "\x00" + curr},
+		{name: "Dashes separator", input: prev + "---\n" + curr},
+		{name: "Dashes separator with CRLF", input: prev + "---\r\n" + curr},
+	}
+
+	expected := []string{`Field "age" (number 2) was removed from message "User"`}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := compareProtoPair(tt.input, options{rules: protobreak.DefaultRuleSet()})
+			if err != nil {
+				t.Fatalf("Failed to compare sources: %v", err)
+			}
+			if actual := changeMessages(changes); !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Expected errors %v, got %v", expected, actual)
+			}
+		})
+	}
+
+	if _, err := compareProtoPair(prev+curr, options{rules: protobreak.DefaultRuleSet()}); err == nil {
+		t.Error("Expected an error without a separator")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Syntaxes accepted by --require-syntax
+const (
+	syntaxProto2   = "proto2"
+	syntaxProto3   = "proto3"
+	syntaxEditions = "editions"
+)
+
+// checkRequiredSyntax returns an error for values of --require-syntax that are not a known syntax
+func checkRequiredSyntax(syntax string) error {
+	switch syntax {
+	case "", syntaxProto2, syntaxProto3, syntaxEditions:
+		return nil
+	default:
+		return fmt.Errorf("unknown syntax %q (expected proto2, proto3 or editions)", syntax)
+	}
+}
+
+// validateSyntax returns an error when a file does not use the required syntax.
+// Group fields are rejected too unless proto2 is required, since they only exist for
+// compatibility with proto2. An empty requirement allows every file.
+func validateSyntax(file protoreflect.FileDescriptor, required string) error {
+	if required == "" {
+		return nil
+	}
+	if syntax := file.Syntax().String(); syntax != required {
+		return fmt.Errorf("uses %s syntax but %s is required", syntax, required)
+	}
+	if required != syntaxProto2 {
+		if group := findGroupField(file.Messages()); group != nil {
+			return fmt.Errorf("uses group field %q which is not allowed with %s", group.FullName(), required)
+		}
+	}
+	return nil
+}
+
+// findGroupField returns the first group field declared in msgs or their nested messages
+func findGroupField(msgs protoreflect.MessageDescriptors) protoreflect.FieldDescriptor {
+	for i := 0; i < msgs.Len(); i++ {
+		msg := msgs.Get(i)
+		for j := 0; j < msg.Fields().Len(); j++ {
+			if field := msg.Fields().Get(j); field.Kind() == protoreflect.GroupKind {
+				return field
+			}
+		}
+		if field := findGroupField(msg.Messages()); field != nil {
+			return field
+		}
+	}
+	return nil
+}
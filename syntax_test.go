@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestRequireSyntax tests rejecting files that do not use the required syntax
+func TestRequireSyntax(t *testing.T) {
+	tests := []struct {
+		name      string
+		proto     string
+		required  string
+		expectErr bool
+	}{
+		{
+			name: "Proto3 file under proto3 requirement",
+			proto: `
+				syntax = "proto3";
+				package test;
+				message TestMessage {
+					string name = 1;
+				}
+			`,
+			required: syntaxProto3,
+		},
+		{
+			name: "Proto2 file under proto3 requirement",
+			proto: `
+				syntax = "proto2";
+				package test;
+				message TestMessage {
+					optional string name = 1;
+				}
+			`,
+			required:  syntaxProto3,
+			expectErr: true,
+		},
+		{
+			name: "Proto2 file with groups under proto2 requirement",
+			proto: `
+				syntax = "proto2";
+				package test;
+				message TestMessage {
+					optional group Result = 1 {
+						optional string url = 2;
+					}
+				}
+			`,
+			required: syntaxProto2,
+		},
+		{
+			name: "Proto2 file without requirement",
+			proto: `
+				syntax = "proto2";
+				package test;
+				message TestMessage {
+					optional string name = 1;
+				}
+			`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileDesc, err := parseProtoSourceToReflect("test.proto", tt.proto)
+			if err != nil {
+				t.Fatalf("Failed to parse proto: %v", err)
+			}
+
+			err = validateSyntax(fileDesc, tt.required)
+			if tt.expectErr && err == nil {
+				t.Error("Expected a syntax error")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	if err := checkRequiredSyntax("proto4"); err == nil {
+		t.Error("Expected an error for an unknown syntax")
+	}
+}